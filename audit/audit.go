@@ -0,0 +1,161 @@
+// Package audit records who changed what. Services record an Entry after
+// each change worth auditing:
+//
+//	if err := audit.Record(ctx, audit.Entry{
+//		Action:     "note.update",
+//		TargetKind: "Note",
+//		TargetID:   note.ID,
+//		Before:     before,
+//		After:      note,
+//	}); err != nil {
+//		log.Error().Ctx(ctx).Err(err).Msg("failed to record audit event")
+//	}
+//
+// The actor comes from auth.UserFrom(ctx) and the request metadata from
+// middleware.AuditRequest, so services need nothing but the request context.
+// Events are appended to the data layer and searched at /app/admin/audit.
+package audit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/data"
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/tracing"
+)
+
+// Store persists audit events; data.AuditEventRepository implements it
+type Store interface {
+	Create(ctx context.Context, event *data.AuditEvent) error
+	List(ctx context.Context, list query.List) (query.Page[data.AuditEvent], error)
+}
+
+// Entry is one audited action
+type Entry struct {
+	// Action names what happened as "<target>.<verb>", e.g. "note.update"
+	Action     string
+	TargetKind string
+	TargetID   string
+	// Before and After are the target before and after the action, nil for
+	// created and deleted targets. The recorded diff compares their JSON
+	// fields, so a Snapshot taken before changing the target in place works
+	// as Before.
+	Before any
+	After  any
+}
+
+// Request is the request metadata recorded with each event
+type Request struct {
+	ID        string
+	IP        string
+	UserAgent string
+	Method    string
+	Path      string
+}
+
+type requestKey struct{}
+
+// WithRequest returns a copy of ctx carrying the request metadata
+func WithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFrom returns the metadata stored by WithRequest; it is empty
+// outside of requests
+func RequestFrom(ctx context.Context) Request {
+	req, _ := ctx.Value(requestKey{}).(Request)
+	return req
+}
+
+// Recorder appends audit events to a store
+type Recorder struct {
+	store Store
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewRecorder creates a recorder appending to store
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store, now: time.Now}
+}
+
+// Record appends the event of e, attributed to the user and request of ctx.
+// The audited action has already happened when it fails, so callers log the
+// error rather than failing the action.
+func (r *Recorder) Record(ctx context.Context, e Entry) (err error) {
+	ctx, span := tracing.Start(ctx, "audit.Record")
+	defer tracing.End(span, &err)
+
+	if e.Action == "" {
+		return errors.New("audit entry without action")
+	}
+	changes, err := Diff(e.Before, e.After)
+	if err != nil {
+		return err
+	}
+	req := RequestFrom(ctx)
+	event := &data.AuditEvent{
+		ID:         data.NewID(),
+		Action:     e.Action,
+		TargetKind: e.TargetKind,
+		TargetID:   e.TargetID,
+		Changes:    changes,
+		RequestID:  req.ID,
+		TraceID:    tracing.TraceID(ctx),
+		IP:         req.IP,
+		UserAgent:  req.UserAgent,
+		Method:     req.Method,
+		Path:       req.Path,
+		CreatedAt:  r.now().UTC(),
+	}
+	if user := auth.UserFrom(ctx); user != nil {
+		event.ActorID = user.ID
+		event.ActorEmail = strings.ToLower(user.Email)
+	}
+	return r.store.Create(ctx, event)
+}
+
+// List returns one page of events, see data.AuditEventList for the filters
+func (r *Recorder) List(ctx context.Context, list query.List) (query.Page[data.AuditEvent], error) {
+	return r.store.List(ctx, list)
+}
+
+var (
+	defaultRecorder *Recorder
+	defaultLock     = new(sync.RWMutex)
+)
+
+// Default returns the recorder backed by data.AuditEventRepository
+func Default() *Recorder {
+	defaultLock.RLock()
+	r := defaultRecorder
+	defaultLock.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	if defaultRecorder == nil {
+		defaultRecorder = NewRecorder(data.NewAuditEventRepository())
+	}
+	return defaultRecorder
+}
+
+// SetDefault replaces the default recorder, e.g. with one backed by an
+// in-memory store in tests
+func SetDefault(r *Recorder) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultRecorder = r
+}
+
+// Record appends e with the default recorder
+func Record(ctx context.Context, e Entry) error {
+	return Default().Record(ctx, e)
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/data"
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is an in-memory Store
+type memoryStore struct {
+	events []*data.AuditEvent
+}
+
+func (s *memoryStore) Create(ctx context.Context, event *data.AuditEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memoryStore) List(ctx context.Context, list query.List) (query.Page[data.AuditEvent], error) {
+	page := query.Page[data.AuditEvent]{Items: []data.AuditEvent{}}
+	for _, event := range s.events {
+		page.Items = append(page.Items, *event)
+	}
+	return page, nil
+}
+
+type note struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags"`
+	Password string   `json:"password"`
+	Internal string   `json:"-"`
+}
+
+func TestRecorder_Record(t *testing.T) {
+	store := &memoryStore{}
+	r := NewRecorder(store)
+	r.now = func() time.Time { return time.Date(2026, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600)) }
+
+	ctx := auth.WithUser(context.Background(), &auth.User{ID: "u1", Email: "Ada@Example.com"})
+	ctx = WithRequest(ctx, Request{ID: "req-1", IP: "192.0.2.7", UserAgent: "test", Method: "POST", Path: "/notes/n1"})
+	err := r.Record(ctx, Entry{
+		Action:     "note.update",
+		TargetKind: "Note",
+		TargetID:   "n1",
+		Before:     &note{ID: "n1", Title: "Draft"},
+		After:      &note{ID: "n1", Title: "Final"},
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, store.events, 1) {
+		event := store.events[0]
+		assert.NotEmpty(t, event.ID)
+		assert.Equal(t, "note.update", event.Action)
+		assert.Equal(t, "u1", event.ActorID)
+		assert.Equal(t, "ada@example.com", event.ActorEmail)
+		assert.Equal(t, "Note", event.TargetKind)
+		assert.Equal(t, "n1", event.TargetID)
+		assert.Equal(t, []data.AuditChange{{Field: "title", Before: "Draft", After: "Final"}}, event.Changes)
+		assert.Equal(t, "req-1", event.RequestID)
+		assert.Equal(t, "192.0.2.7", event.IP)
+		assert.Equal(t, "POST", event.Method)
+		assert.Equal(t, "/notes/n1", event.Path)
+		assert.Equal(t, time.UTC, event.CreatedAt.Location())
+	}
+}
+
+func TestRecorder_RecordSystem(t *testing.T) {
+	store := &memoryStore{}
+	err := NewRecorder(store).Record(context.Background(), Entry{Action: "job.purge", TargetKind: "Job"})
+	assert.NoError(t, err)
+	if assert.Len(t, store.events, 1) {
+		assert.Empty(t, store.events[0].ActorID)
+		assert.Empty(t, store.events[0].RequestID)
+		assert.Empty(t, store.events[0].Changes)
+	}
+
+	err = NewRecorder(store).Record(context.Background(), Entry{TargetKind: "Job"})
+	assert.Error(t, err)
+	assert.Len(t, store.events, 1)
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   any
+		after    any
+		expected []data.AuditChange
+	}{
+		{
+			name:   "create",
+			before: nil,
+			after:  &note{ID: "n1", Title: "New", Tags: []string{"a"}},
+			expected: []data.AuditChange{
+				{Field: "id", After: "n1"},
+				{Field: "password", After: logging.Redacted},
+				{Field: "tags", After: `["a"]`},
+				{Field: "title", After: "New"},
+			},
+		},
+		{
+			name:     "update",
+			before:   note{ID: "n1", Title: "Old", Tags: []string{"a"}, Internal: "x"},
+			after:    note{ID: "n1", Title: "Old", Tags: []string{"a", "b"}, Internal: "y"},
+			expected: []data.AuditChange{{Field: "tags", Before: `["a"]`, After: `["a","b"]`}},
+		},
+		{
+			name:     "delete",
+			before:   map[string]any{"id": "n1", "count": 2},
+			after:    (*note)(nil),
+			expected: []data.AuditChange{{Field: "count", Before: "2"}, {Field: "id", Before: "n1"}},
+		},
+		{
+			name:     "unchanged",
+			before:   note{ID: "n1"},
+			after:    &note{ID: "n1"},
+			expected: nil,
+		},
+		{
+			name:     "sensitive field",
+			before:   note{Password: "hunter2"},
+			after:    note{Password: "correct horse"},
+			expected: []data.AuditChange{{Field: "password", Before: logging.Redacted, After: logging.Redacted}},
+		},
+		{
+			name:   "nested sensitive field",
+			before: map[string]any{"settings": map[string]any{"theme": "dark", "smtp": map[string]any{"api_key": "k-old"}}},
+			after:  map[string]any{"settings": map[string]any{"theme": "light", "smtp": map[string]any{"api_key": "k-new"}}, "hooks": []any{map[string]any{"secret": "s1"}}},
+			expected: []data.AuditChange{
+				{Field: "hooks", After: `[{"secret":"[REDACTED]"}]`},
+				{
+					Field:  "settings",
+					Before: `{"smtp":{"api_key":"[REDACTED]"},"theme":"dark"}`,
+					After:  `{"smtp":{"api_key":"[REDACTED]"},"theme":"light"}`,
+				},
+			},
+		},
+		{
+			name:     "credential in text",
+			before:   map[string]any{"notes": "none"},
+			after:    map[string]any{"notes": "call with Bearer abc.def"},
+			expected: []data.AuditChange{{Field: "notes", Before: "none", After: "call with Bearer " + logging.Redacted}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Diff(tt.before, tt.after)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, changes)
+		})
+	}
+}
+
+func TestDiff_Snapshot(t *testing.T) {
+	entity := &note{ID: "n1", Title: "Before"}
+	before, err := Snapshot(entity)
+	assert.NoError(t, err)
+	entity.Title = "After"
+
+	changes, err := Diff(before, entity)
+	assert.NoError(t, err)
+	assert.Equal(t, []data.AuditChange{{Field: "title", Before: "Before", After: "After"}}, changes)
+
+	_, err = Diff("not an object", entity)
+	assert.Error(t, err)
+}
+
+func TestDiff_LongValue(t *testing.T) {
+	changes, err := Diff(nil, map[string]any{"body": strings.Repeat("é", MaxValueLength)})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.LessOrEqual(t, len(changes[0].After), MaxValueLength+len("…"))
+		assert.True(t, strings.HasSuffix(changes[0].After, "é…"))
+	}
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+
+	"runtime-dynamics/data"
+	"runtime-dynamics/logging"
+)
+
+// MaxValueLength caps the recorded before and after values of a field
+const MaxValueLength = 1024
+
+// Snapshot returns the JSON fields of v. Take one before changing an entity
+// in place and pass it as Entry.Before.
+func Snapshot(v any) (map[string]any, error) {
+	fields := map[string]any{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return fields, nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit snapshot of %T: %w", v, err)
+	}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, fmt.Errorf("audit snapshot of %T: not a JSON object", v)
+	}
+	return fields, nil
+}
+
+// Diff returns the JSON fields that differ between before and after, sorted
+// by name. Values are redacted as in the logs: sensitive fields, as defined
+// by logging.IsSensitive, are recorded as logging.Redacted, and so are
+// sensitive fields nested in objects and secrets in strings.
+func Diff(before, after any) ([]data.AuditChange, error) {
+	old, err := Snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := Snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(old)+len(changed))
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range changed {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []data.AuditChange
+	for _, name := range names {
+		oldValue, hadOld := old[name]
+		newValue, hasNew := changed[name]
+		if hadOld == hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		change := data.AuditChange{Field: name}
+		if hadOld {
+			change.Before = formatValue(name, oldValue)
+		}
+		if hasNew {
+			change.After = formatValue(name, newValue)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// formatValue renders a redacted JSON value for display: strings as they
+// are, other values as JSON
+func formatValue(name string, value any) string {
+	if logging.IsSensitive(name) {
+		return logging.Redacted
+	}
+	value = logging.Redact(value)
+	s, ok := value.(string)
+	if !ok {
+		encoded, _ := json.Marshal(value)
+		s = string(encoded)
+	}
+	if len(s) > MaxValueLength {
+		cut := MaxValueLength
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut] + "…"
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+// Package auth carries the authenticated user of a request through
+// context.Context, so services can authorize and record who acted without
+// depending on gin.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"runtime-dynamics/config"
+)
+
+// RoleAdmin grants access to /app/admin
+const RoleAdmin = "admin"
+
+// User is the authenticated user of a request
+type User struct {
+	ID    string
+	Email string
+	Roles []string
+}
+
+// HasRole reports whether the user has role
+func (u *User) HasRole(role string) bool {
+	if u == nil {
+		return false
+	}
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator resolves the user of a request. It returns a nil user for
+// anonymous requests and an error only for credentials that are present but
+// invalid.
+type Authenticator func(r *http.Request) (*User, error)
+
+var (
+	authenticator     Authenticator
+	authenticatorLock = new(sync.RWMutex)
+)
+
+// SetAuthenticator installs the function that identifies users, e.g. one
+// verifying the Firebase session cookie. Without one every request is anonymous.
+func SetAuthenticator(a Authenticator) {
+	authenticatorLock.Lock()
+	defer authenticatorLock.Unlock()
+	authenticator = a
+}
+
+// Authenticate identifies the user of r with the installed Authenticator and
+// grants the admin role to the configured ADMIN_EMAILS
+func Authenticate(r *http.Request) (*User, error) {
+	authenticatorLock.RLock()
+	a := authenticator
+	authenticatorLock.RUnlock()
+	if a == nil {
+		return nil, nil
+	}
+	user, err := a(r)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	if isAdminEmail(user.Email) && !user.HasRole(RoleAdmin) {
+		user.Roles = append(user.Roles, RoleAdmin)
+	}
+	return user, nil
+}
+
+func isAdminEmail(email string) bool {
+	cfg := config.Get()
+	if cfg == nil || email == "" {
+		return false
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	for _, admin := range cfg.AdminEmails {
+		if admin == email {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying user
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFrom returns the user stored by WithUser, or nil for anonymous requests
+func UserFrom(ctx context.Context) *User {
+	user, _ := ctx.Value(contextKey{}).(*User)
+	return user
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"runtime-dynamics/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_HasRole(t *testing.T) {
+	var anonymous *User
+	assert.False(t, anonymous.HasRole(RoleAdmin))
+	assert.False(t, (&User{}).HasRole(RoleAdmin))
+	assert.True(t, (&User{Roles: []string{"editor", RoleAdmin}}).HasRole(RoleAdmin))
+}
+
+func TestAuthenticate(t *testing.T) {
+	os.Setenv("ADMIN_EMAILS", "boss@example.com")
+	defer os.Unsetenv("ADMIN_EMAILS")
+	assert.NoError(t, config.LoadConfig())
+	defer SetAuthenticator(nil)
+
+	tests := []struct {
+		name          string
+		authenticator Authenticator
+		expected      *User
+		expectErr     bool
+	}{
+		{
+			name:     "no authenticator",
+			expected: nil,
+		},
+		{
+			name: "anonymous",
+			authenticator: func(r *http.Request) (*User, error) {
+				return nil, nil
+			},
+			expected: nil,
+		},
+		{
+			name: "regular user",
+			authenticator: func(r *http.Request) (*User, error) {
+				return &User{ID: "1", Email: "someone@example.com"}, nil
+			},
+			expected: &User{ID: "1", Email: "someone@example.com"},
+		},
+		{
+			name: "configured admin email",
+			authenticator: func(r *http.Request) (*User, error) {
+				return &User{ID: "2", Email: "Boss@Example.com"}, nil
+			},
+			expected: &User{ID: "2", Email: "Boss@Example.com", Roles: []string{RoleAdmin}},
+		},
+		{
+			name: "invalid credentials",
+			authenticator: func(r *http.Request) (*User, error) {
+				return nil, errors.New("expired session")
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAuthenticator(tt.authenticator)
+			req, _ := http.NewRequest("GET", "/", nil)
+			user, err := Authenticate(req)
+			assert.Equal(t, tt.expectErr, err != nil)
+			assert.Equal(t, tt.expected, user)
+		})
+	}
+}
+
+func TestWithUser(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, UserFrom(ctx))
+
+	user := &User{ID: "1"}
+	assert.Equal(t, user, UserFrom(WithUser(ctx, user)))
+}
@@ -0,0 +1,344 @@
+// Package cache keeps the results of expensive reads, such as Datastore
+// lookups and rendered templ fragments, in memory or in a shared Redis:
+//
+//	report, err := cache.GetOrLoad(ctx, cache.Default(), "report:"+id, 0,
+//		func(ctx context.Context) (*Report, error) {
+//			return buildReport(ctx, id)
+//		}, cache.EntityTag("Report", id))
+//
+// Concurrent misses of a key share one load. Entries expire after their TTL
+// and are invalidated earlier by tag: writers call Invalidate with the tags
+// of the data they changed, which drops every entry stored with one of
+// them, on all instances when the backend is shared.
+//
+// Values are gob-encoded, so each read returns a fresh copy and callers may
+// change what they get.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/metrics"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultTTL is how long entries live without CACHE_TTL
+const DefaultTTL = 5 * time.Minute
+
+// tagTTL bounds how long a tag version is kept. An expired version only
+// turns the entries stored with it into misses.
+const tagTTL = 24 * time.Hour
+
+// Backend stores encoded entries. Memory and Redis implement it.
+type Backend interface {
+	// Get returns the value of key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl; zero keeps it until evicted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// KindTag is the tag of everything read from entities of kind, such as list
+// pages; writes to any entity of kind invalidate it
+func KindTag(kind string) string {
+	return "kind:" + kind
+}
+
+// EntityTag is the tag of everything read from one entity; writes to that
+// entity invalidate it
+func EntityTag(kind string, id string) string {
+	return "entity:" + kind + ":" + id
+}
+
+// Cache reads and writes typed entries with tags on a Backend
+type Cache struct {
+	backend Backend
+	ttl     time.Duration
+	group   singleflight.Group
+}
+
+// entry is what the backend stores: the encoded value and the version of
+// each of its tags when the value was loaded
+type entry struct {
+	Value []byte
+	Tags  map[string]string
+}
+
+// New creates a cache on backend whose entries live for ttl unless set
+// otherwise, DefaultTTL when zero
+func New(backend Backend, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Cache{backend: backend, ttl: ttl}
+}
+
+// Backend returns the backend the cache stores entries in
+func (c *Cache) Backend() Backend {
+	return c.backend
+}
+
+// Close releases the backend's connections, if it has any
+func (c *Cache) Close() error {
+	if closer, ok := c.backend.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Get decodes the entry of key into value, a pointer, and reports whether
+// a current entry was found. Backend failures count as misses.
+func (c *Cache) Get(ctx context.Context, key string, value any) bool {
+	raw, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		log.Warn().Ctx(ctx).Err(err).Str("key", key).Msg("failed to read cache")
+		return false
+	}
+	if !ok {
+		return false
+	}
+	var e entry
+	if err := decode(raw, &e); err != nil {
+		log.Warn().Ctx(ctx).Err(err).Str("key", key).Msg("failed to decode cache entry")
+		return false
+	}
+	for tag, version := range e.Tags {
+		current, err := c.version(ctx, tag)
+		if err != nil || current != version {
+			return false
+		}
+	}
+	if err := decode(e.Value, value); err != nil {
+		log.Warn().Ctx(ctx).Err(err).Str("key", key).Msg("failed to decode cached value")
+		return false
+	}
+	return true
+}
+
+// Set stores value under key with tags for ttl, the cache's TTL when zero
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	versions, err := c.versions(ctx, tags)
+	if err != nil {
+		return err
+	}
+	encoded, err := encode(value)
+	if err != nil {
+		return err
+	}
+	return c.store(ctx, key, encoded, ttl, versions)
+}
+
+// Delete removes the entries of keys
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	return c.backend.Delete(ctx, keys...)
+}
+
+// Invalidate drops every entry stored with one of tags
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		if err := c.backend.Set(ctx, tagKey(tag), []byte(newVersion()), tagTTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetOrLoad returns the entry of key, calling load on a miss and storing
+// its result with tags for ttl, the cache's TTL when zero. Concurrent misses
+// of key wait for a single load. Load errors are returned and not cached;
+// backend failures only cost the caching.
+//
+// The caller that ran load gets its result unchanged; hits and waiters get
+// a decoded copy, in which gob turns empty slices and maps into nil.
+func GetOrLoad[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, load func(context.Context) (T, error), tags ...string) (T, error) {
+	var value T
+	if c.Get(ctx, key, &value) {
+		metrics.ObserveCache(namespace(key), true)
+		return value, nil
+	}
+	metrics.ObserveCache(namespace(key), false)
+
+	var loaded T
+	ran := false
+	encoded, err, _ := c.group.Do(key, func() (any, error) {
+		ran = true
+		// read the versions first, so a write during the load leaves the
+		// stored entry outdated rather than hiding the write
+		versions, versionErr := c.versions(ctx, tags)
+		var err error
+		loaded, err = load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := encode(loaded)
+		if err != nil {
+			return nil, err
+		}
+		if versionErr == nil {
+			versionErr = c.store(ctx, key, encoded, ttl, versions)
+		}
+		if versionErr != nil {
+			log.Warn().Ctx(ctx).Err(versionErr).Str("key", key).Msg("failed to write cache")
+		}
+		return encoded, nil
+	})
+	if err != nil {
+		return value, err
+	}
+	if ran {
+		return loaded, nil
+	}
+	err = decode(encoded.([]byte), &value)
+	return value, err
+}
+
+func (c *Cache) store(ctx context.Context, key string, value []byte, ttl time.Duration, versions map[string]string) error {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	raw, err := encode(entry{Value: value, Tags: versions})
+	if err != nil {
+		return err
+	}
+	return c.backend.Set(ctx, key, raw, ttl)
+}
+
+// versions returns the current version of each tag, creating missing ones.
+// Entries always record a version, so a version lost to eviction or expiry
+// never matches again.
+func (c *Cache) versions(ctx context.Context, tags []string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	versions := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		version, err := c.version(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		if version == "" {
+			version = newVersion()
+			if err := c.backend.Set(ctx, tagKey(tag), []byte(version), tagTTL); err != nil {
+				return nil, err
+			}
+		}
+		versions[tag] = version
+	}
+	return versions, nil
+}
+
+func (c *Cache) version(ctx context.Context, tag string) (string, error) {
+	version, _, err := c.backend.Get(ctx, tagKey(tag))
+	return string(version), err
+}
+
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+func newVersion() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// namespace is the part of key before the first colon, the metrics label
+// of the key
+func namespace(key string) string {
+	ns, _, _ := strings.Cut(key, ":")
+	return ns
+}
+
+func encode(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(raw []byte, value any) error {
+	return gob.NewDecoder(bytes.NewReader(raw)).Decode(value)
+}
+
+// nop is the backend when CACHE_BACKEND is none: it stores nothing, so
+// every read loads, while concurrent misses still share a load
+type nop struct{}
+
+func (nop) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+func (nop) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (nop) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+var (
+	defaultCache *Cache
+	defaultLock  = new(sync.RWMutex)
+)
+
+// Default returns the cache configured by CACHE_BACKEND, CACHE_TTL and
+// CACHE_MAX_ENTRIES, in memory unless configured otherwise
+func Default() *Cache {
+	defaultLock.RLock()
+	c := defaultCache
+	defaultLock.RUnlock()
+	if c != nil {
+		return c
+	}
+
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	if defaultCache == nil {
+		defaultCache = fromConfig(config.Get())
+	}
+	return defaultCache
+}
+
+// SetDefault replaces the default cache, e.g. with a fresh memory cache in
+// tests
+func SetDefault(c *Cache) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultCache = c
+}
+
+// Invalidate drops the entries of the default cache stored with one of tags
+func Invalidate(ctx context.Context, tags ...string) error {
+	return Default().Invalidate(ctx, tags...)
+}
+
+func fromConfig(cfg *config.AppConfig) *Cache {
+	if cfg == nil {
+		return New(NewMemory(0), 0)
+	}
+	switch cfg.CacheBackend {
+	case "none":
+		return New(nop{}, cfg.CacheTTL)
+	case "redis":
+		backend, err := NewRedis(cfg.CacheRedisURL)
+		if err == nil {
+			return New(backend, cfg.CacheTTL)
+		}
+		log.Error().Err(err).Msg("invalid CACHE_REDIS_URL, caching in memory")
+	}
+	return New(NewMemory(cfg.CacheMaxEntries), cfg.CacheTTL)
+}
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"runtime-dynamics/config"
+
+	"github.com/a-h/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	Name  string
+	Count int
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	assert.NoError(t, m.Set(ctx, "a", []byte("1"), 0))
+	assert.NoError(t, m.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := m.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// b is the least recently used entry
+	assert.NoError(t, m.Set(ctx, "c", []byte("3"), 0))
+	_, ok, _ = m.Get(ctx, "b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	assert.Equal(t, 2, m.Len())
+
+	assert.NoError(t, m.Set(ctx, "short", []byte("4"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, _ = m.Get(ctx, "short")
+	assert.False(t, ok, "expired entry should be a miss")
+
+	assert.NoError(t, m.Delete(ctx, "a", "missing"))
+	_, ok, _ = m.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestCache_SetGet(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(0), 0)
+
+	assert.NoError(t, c.Set(ctx, "item:1", &item{Name: "gear", Count: 2}, 0))
+	var got item
+	assert.True(t, c.Get(ctx, "item:1", &got))
+	assert.Equal(t, item{Name: "gear", Count: 2}, got)
+
+	assert.False(t, c.Get(ctx, "item:2", &got))
+
+	assert.NoError(t, c.Delete(ctx, "item:1"))
+	assert.False(t, c.Get(ctx, "item:1", &got))
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(0), 0)
+
+	assert.NoError(t, c.Set(ctx, "a", "A", 0, "posts"))
+	assert.NoError(t, c.Set(ctx, "b", "B", 0, "posts", "post:1"))
+	assert.NoError(t, c.Set(ctx, "c", "C", 0, "users"))
+
+	assert.NoError(t, c.Invalidate(ctx, "post:1"))
+	var got string
+	assert.True(t, c.Get(ctx, "a", &got))
+	assert.False(t, c.Get(ctx, "b", &got))
+
+	assert.NoError(t, c.Invalidate(ctx, "posts"))
+	assert.False(t, c.Get(ctx, "a", &got))
+	assert.True(t, c.Get(ctx, "c", &got))
+}
+
+func TestCache_EvictedTagInvalidates(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(0)
+	c := New(m, 0)
+
+	assert.NoError(t, c.Set(ctx, "a", "A", 0, "posts"))
+	assert.NoError(t, m.Delete(ctx, tagKey("posts")))
+
+	var got string
+	assert.False(t, c.Get(ctx, "a", &got), "an entry whose tag version is lost must not be served")
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(0), 0)
+	loads := 0
+	load := func(ctx context.Context) (*item, error) {
+		loads++
+		return &item{Name: "gear"}, nil
+	}
+
+	first, err := GetOrLoad(ctx, c, "item:1", 0, load)
+	assert.NoError(t, err)
+	first.Name = "changed by caller"
+
+	second, err := GetOrLoad(ctx, c, "item:1", 0, load)
+	assert.NoError(t, err)
+	assert.Equal(t, "gear", second.Name, "cached values are copies")
+	assert.Equal(t, 1, loads)
+
+	_, err = GetOrLoad(ctx, c, "item:2", 0, func(ctx context.Context) (*item, error) {
+		return nil, errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	var got *item
+	assert.False(t, c.Get(ctx, "item:2", &got), "errors are not cached")
+}
+
+func TestGetOrLoad_ReturnsLoadedValue(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(0), 0)
+	load := func(ctx context.Context) ([]string, error) {
+		return []string{}, nil
+	}
+
+	loaded, err := GetOrLoad(ctx, c, "names", 0, load)
+	assert.NoError(t, err)
+	assert.NotNil(t, loaded, "the loading caller gets the loaded value unchanged")
+
+	cached, err := GetOrLoad(ctx, c, "names", 0, load)
+	assert.NoError(t, err)
+	assert.Empty(t, cached)
+}
+
+func TestGetOrLoad_SharesConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(0), 0)
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = GetOrLoad(ctx, c, "slow", 0, func(ctx context.Context) (int, error) {
+				loads.Add(1)
+				<-release
+				return 42, nil
+			})
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+	for _, r := range results {
+		assert.Equal(t, 42, r)
+	}
+}
+
+func TestGetOrLoad_WriteDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(0), 0)
+
+	_, err := GetOrLoad(ctx, c, "a", 0, func(ctx context.Context) (string, error) {
+		// a write invalidates the tag while the old value is being loaded
+		assert.NoError(t, c.Invalidate(ctx, "posts"))
+		return "stale", nil
+	}, "posts")
+	assert.NoError(t, err)
+
+	var got string
+	assert.False(t, c.Get(ctx, "a", &got), "a value loaded before a write must not be served after it")
+}
+
+func TestFragment(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(0), 0)
+	renders := 0
+	component := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		renders++
+		_, err := w.Write([]byte("<ul><li>gear</li></ul>"))
+		return err
+	})
+
+	for i := 0; i < 2; i++ {
+		html, err := templ.ToGoHTML(ctx, c.Fragment("list", 0, component, "posts"))
+		assert.NoError(t, err)
+		assert.Equal(t, "<ul><li>gear</li></ul>", string(html))
+	}
+	assert.Equal(t, 1, renders)
+
+	assert.NoError(t, c.Invalidate(ctx, "posts"))
+	_, err := templ.ToGoHTML(ctx, c.Fragment("list", 0, component, "posts"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, renders)
+}
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.AppConfig
+		want any
+	}{
+		{name: "no config", cfg: nil, want: &Memory{}},
+		{name: "memory", cfg: &config.AppConfig{CacheBackend: "memory"}, want: &Memory{}},
+		{name: "redis", cfg: &config.AppConfig{CacheBackend: "redis", CacheRedisURL: "redis://localhost:6379/0"}, want: &Redis{}},
+		{name: "invalid redis url", cfg: &config.AppConfig{CacheBackend: "redis", CacheRedisURL: "redis://:bad:port"}, want: &Memory{}},
+		{name: "none", cfg: &config.AppConfig{CacheBackend: "none"}, want: nop{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.IsType(t, tt.want, fromConfig(tt.cfg).Backend())
+		})
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	assert.Equal(t, "entity", namespace("entity:Post:1"))
+	assert.Equal(t, "plain", namespace("plain"))
+}
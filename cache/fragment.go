@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// Fragment renders component once per key and serves the cached HTML until
+// ttl passes, the cache's TTL when zero, or one of tags is invalidated:
+//
+//	@cache.Fragment("sidebar:"+user.ID, 0, Sidebar(user), cache.KindTag("Post"))
+//
+// The key must cover everything the output depends on, such as the user or
+// the language. Only the rendering is skipped on a hit, so the component
+// should load its data while rendering rather than before. Failed renders
+// are not cached.
+func Fragment(key string, ttl time.Duration, component templ.Component, tags ...string) templ.Component {
+	return Default().Fragment(key, ttl, component, tags...)
+}
+
+// Fragment is the package Fragment on cache c
+func (c *Cache) Fragment(key string, ttl time.Duration, component templ.Component, tags ...string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		html, err := GetOrLoad(ctx, c, "fragment:"+key, ttl, func(ctx context.Context) (string, error) {
+			var b strings.Builder
+			if err := component.Render(ctx, &b); err != nil {
+				return "", err
+			}
+			return b.String(), nil
+		}, tags...)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, html)
+		return err
+	})
+}
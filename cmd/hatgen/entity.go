@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// fieldTypes maps definition types to Go types
+var fieldTypes = map[string]string{
+	"string": "string",
+	"text":   "string",
+	"int":    "int64",
+	"float":  "float64",
+	"bool":   "bool",
+	"time":   "time.Time",
+}
+
+// initialisms are written in upper case in Go names
+var initialisms = map[string]bool{
+	"id": true, "url": true, "uri": true, "api": true, "html": true, "http": true,
+	"ip": true, "json": true, "sku": true, "uuid": true, "css": true, "sql": true,
+}
+
+// Entity is the parsed entity definition passed to the templates
+type Entity struct {
+	Module string
+
+	Name        string // BlogPost
+	Var         string // blogPost
+	Snake       string // blog_post
+	Plural      string // BlogPosts
+	PluralVar   string // blogPosts
+	PluralSnake string // blog_posts
+	Path        string // blog-posts
+	Label       string // Blog post
+	PluralLabel string // Blog posts
+	Lower       string // blog post
+	PluralLower string // blog posts
+
+	Fields []Field
+}
+
+// Field is one entity field
+type Field struct {
+	Name  string // PublishedAt
+	JSON  string // published_at
+	Label string // Published at
+	Type  string // definition type: string, text, int, float, bool, time
+	Go    string // Go type
+	// Rules is the gin binding tag, e.g. "required,max=120"
+	Rules    string
+	Filter   bool
+	Sortable bool
+}
+
+// Required reports whether the field has a required rule
+func (f Field) Required() bool {
+	return f.hasRule("required")
+}
+
+func (f Field) hasRule(name string) bool {
+	for _, rule := range strings.Split(f.Rules, ",") {
+		if rule == name || strings.HasPrefix(rule, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// InputType is the HTML input type of string and number fields
+func (f Field) InputType() string {
+	switch {
+	case f.Type == "int":
+		return "number"
+	case f.hasRule("email"):
+		return "email"
+	case f.hasRule("url"), f.hasRule("http_url"):
+		return "url"
+	default:
+		return "text"
+	}
+}
+
+// HasType reports whether any field has the definition type t
+func (e Entity) HasType(t string) bool {
+	for _, f := range e.Fields {
+		if f.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRequired reports whether any field is required
+func (e Entity) HasRequired() bool {
+	for _, f := range e.Fields {
+		if f.Required() {
+			return true
+		}
+	}
+	return false
+}
+
+// FirstRequired returns the first required field
+func (e Entity) FirstRequired() Field {
+	for _, f := range e.Fields {
+		if f.Required() {
+			return f
+		}
+	}
+	return Field{}
+}
+
+// Listed returns the fields shown as table columns: everything but text
+func (e Entity) Listed() []Field {
+	var fields []Field
+	for _, f := range e.Fields {
+		if f.Type != "text" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Search returns the json name of the string field the admin search box
+// matches by prefix: the first one in the list whitelist
+func (e Entity) Search() string {
+	for _, f := range e.Fields {
+		if f.Type == "string" && (f.Filter || f.Sortable) {
+			return f.JSON
+		}
+	}
+	return ""
+}
+
+// Columns is the number of list table columns, including the actions column
+func (e Entity) Columns() int {
+	return len(e.Listed()) + 1
+}
+
+// Filterable returns the fields clients may filter by
+func (e Entity) Filterable() []Field {
+	var fields []Field
+	for _, f := range e.Fields {
+		if f.Filter {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// parseEntity parses an entity name and its field definitions of the form
+// name:type[:rules], where rules is a comma-separated gin binding tag that
+// may also contain the generator flags "filter" and "sort"
+func parseEntity(module string, name string, defs []string) (Entity, error) {
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		return Entity{}, fmt.Errorf("invalid entity name %q", name)
+	}
+	words := splitWords(name)
+	if len(words) == 0 {
+		return Entity{}, fmt.Errorf("invalid entity name %q", name)
+	}
+	pluralWords := append(append([]string{}, words[:len(words)-1]...), plural(words[len(words)-1]))
+
+	e := Entity{
+		Module:      module,
+		Name:        pascal(words),
+		Var:         camel(words),
+		Snake:       strings.Join(words, "_"),
+		Plural:      pascal(pluralWords),
+		PluralVar:   camel(pluralWords),
+		PluralSnake: strings.Join(pluralWords, "_"),
+		Path:        strings.Join(pluralWords, "-"),
+		Label:       sentence(words),
+		PluralLabel: sentence(pluralWords),
+		Lower:       strings.Join(words, " "),
+		PluralLower: strings.Join(pluralWords, " "),
+	}
+	if token.IsKeyword(e.Var) {
+		return Entity{}, fmt.Errorf("entity name %q is a Go keyword", name)
+	}
+	if e.Var == e.PluralVar {
+		return Entity{}, fmt.Errorf("entity name %q has the same singular and plural form", name)
+	}
+
+	if len(defs) == 0 {
+		return Entity{}, fmt.Errorf("entity %s needs at least one field", e.Name)
+	}
+	seen := map[string]bool{}
+	for _, def := range defs {
+		f, err := parseField(def)
+		if err != nil {
+			return Entity{}, err
+		}
+		switch f.JSON {
+		case "id", "created_at", "updated_at":
+			return Entity{}, fmt.Errorf("field %q is generated automatically", f.JSON)
+		}
+		if seen[f.JSON] {
+			return Entity{}, fmt.Errorf("duplicate field %q", f.JSON)
+		}
+		seen[f.JSON] = true
+		e.Fields = append(e.Fields, f)
+	}
+	return e, nil
+}
+
+// parseField parses name:type[:rules]
+func parseField(def string) (Field, error) {
+	parts := strings.SplitN(def, ":", 3)
+	if len(parts) < 2 {
+		return Field{}, fmt.Errorf("field %q must look like name:type[:rules]", def)
+	}
+	words := splitWords(parts[0])
+	if len(words) == 0 {
+		return Field{}, fmt.Errorf("invalid field name in %q", def)
+	}
+	goType, ok := fieldTypes[parts[1]]
+	if !ok {
+		return Field{}, fmt.Errorf("field %q has unknown type %q (use string, text, int, float, bool or time)", parts[0], parts[1])
+	}
+
+	f := Field{
+		Name:  pascal(words),
+		JSON:  strings.Join(words, "_"),
+		Label: sentence(words),
+		Type:  parts[1],
+		Go:    goType,
+	}
+	if len(parts) == 3 {
+		var rules []string
+		for _, rule := range strings.Split(parts[2], ",") {
+			rule = strings.TrimSpace(rule)
+			switch {
+			case rule == "":
+			case rule == "filter":
+				f.Filter = true
+			case rule == "sort":
+				f.Sortable = true
+			case rule == "required" && f.Type == "bool":
+				// false is the zero value, so required would reject it
+			case strings.ContainsAny(rule, "`\""):
+				return Field{}, fmt.Errorf("field %q has an invalid rule %q", parts[0], rule)
+			default:
+				rules = append(rules, rule)
+			}
+		}
+		f.Rules = strings.Join(rules, ",")
+	}
+	if f.Type == "text" && (f.Filter || f.Sortable) {
+		return Field{}, fmt.Errorf("text field %q is not indexed and cannot be filtered or sorted", parts[0])
+	}
+	return f, nil
+}
+
+// splitWords splits CamelCase, snake_case and kebab-case names into lower case words
+func splitWords(name string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			flush()
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			return nil
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return words
+}
+
+func pascal(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		if initialisms[w] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+func camel(words []string) string {
+	return words[0] + pascal(words[1:])
+}
+
+func sentence(words []string) string {
+	s := strings.Join(words, " ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// plural returns the English plural of a lower case word
+func plural(word string) string {
+	switch {
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	default:
+		return word + "s"
+	}
+}
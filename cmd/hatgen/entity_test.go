@@ -0,0 +1,140 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEntity_Names(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Entity
+	}{
+		{
+			name:  "camel case",
+			input: "BlogPost",
+			expected: Entity{
+				Name: "BlogPost", Var: "blogPost", Snake: "blog_post",
+				Plural: "BlogPosts", PluralVar: "blogPosts", PluralSnake: "blog_posts",
+				Path: "blog-posts", Label: "Blog post", PluralLabel: "Blog posts",
+				Lower: "blog post", PluralLower: "blog posts",
+			},
+		},
+		{
+			name:  "snake case with initialism and irregular plural",
+			input: "api_category",
+			expected: Entity{
+				Name: "APICategory", Var: "apiCategory", Snake: "api_category",
+				Plural: "APICategories", PluralVar: "apiCategories", PluralSnake: "api_categories",
+				Path: "api-categories", Label: "Api category", PluralLabel: "Api categories",
+				Lower: "api category", PluralLower: "api categories",
+			},
+		},
+		{
+			name:  "plural with es",
+			input: "Box",
+			expected: Entity{
+				Name: "Box", Var: "box", Snake: "box",
+				Plural: "Boxes", PluralVar: "boxes", PluralSnake: "boxes",
+				Path: "boxes", Label: "Box", PluralLabel: "Boxes",
+				Lower: "box", PluralLower: "boxes",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := parseEntity("example.com/app", tt.input, []string{"name:string"})
+			assert.NoError(t, err)
+			e.Module = ""
+			e.Fields = nil
+			assert.Equal(t, tt.expected, e)
+		})
+	}
+}
+
+func TestParseEntity_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		defs   []string
+	}{
+		{name: "no fields", entity: "Post"},
+		{name: "invalid name", entity: "1Post", defs: []string{"title:string"}},
+		{name: "go keyword", entity: "Type", defs: []string{"title:string"}},
+		{name: "generated field", entity: "Post", defs: []string{"created_at:time"}},
+		{name: "duplicate field", entity: "Post", defs: []string{"title:string", "Title:string"}},
+		{name: "missing type", entity: "Post", defs: []string{"title"}},
+		{name: "unknown type", entity: "Post", defs: []string{"title:varchar"}},
+		{name: "filtered text", entity: "Post", defs: []string{"body:text:filter"}},
+		{name: "quote in rule", entity: "Post", defs: []string{"title:string:max=1`"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEntity("example.com/app", tt.entity, tt.defs)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		name     string
+		def      string
+		expected Field
+	}{
+		{
+			name:     "plain string",
+			def:      "title:string",
+			expected: Field{Name: "Title", JSON: "title", Label: "Title", Type: "string", Go: "string"},
+		},
+		{
+			name: "rules and generator flags",
+			def:  "title:string:required, max=120,filter,sort",
+			expected: Field{Name: "Title", JSON: "title", Label: "Title", Type: "string", Go: "string",
+				Rules: "required,max=120", Filter: true, Sortable: true},
+		},
+		{
+			name:     "camel case time",
+			def:      "publishedAt:time:sort",
+			expected: Field{Name: "PublishedAt", JSON: "published_at", Label: "Published at", Type: "time", Go: "time.Time", Sortable: true},
+		},
+		{
+			name:     "required dropped for bool",
+			def:      "done:bool:required,filter",
+			expected: Field{Name: "Done", JSON: "done", Label: "Done", Type: "bool", Go: "bool", Filter: true},
+		},
+		{
+			name:     "initialism",
+			def:      "homepage_url:string:url",
+			expected: Field{Name: "HomepageURL", JSON: "homepage_url", Label: "Homepage url", Type: "string", Go: "string", Rules: "url"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseField(tt.def)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, f)
+		})
+	}
+}
+
+func TestField_InputType(t *testing.T) {
+	tests := []struct {
+		field    Field
+		expected string
+	}{
+		{Field{Type: "string"}, "text"},
+		{Field{Type: "string", Rules: "required,email"}, "email"},
+		{Field{Type: "string", Rules: "url"}, "url"},
+		{Field{Type: "int"}, "number"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.field.InputType(), tt.field.Rules)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
+
+type options struct {
+	root    string
+	dryRun  bool
+	force   bool
+	noTempl bool
+}
+
+// output is a file rendered from a template
+type output struct {
+	template string
+	path     string
+}
+
+// outputs lists the files generated for e, relative to the repository root
+func outputs(e Entity) []output {
+	return []output{
+		{"model.go.tmpl", filepath.Join("data", e.Snake+".go")},
+		{"model_test.go.tmpl", filepath.Join("data", e.Snake+"_test.go")},
+		{"repository.go.tmpl", filepath.Join("data", e.Snake+"_repository.go")},
+		{"service.go.tmpl", filepath.Join("services", e.Snake+"_service.go")},
+		{"service_test.go.tmpl", filepath.Join("services", e.Snake+"_service_test.go")},
+		{"api.go.tmpl", filepath.Join("web", "api", e.Snake+"_handlers.go")},
+		{"api_test.go.tmpl", filepath.Join("web", "api", e.Snake+"_handlers_test.go")},
+		{"app.go.tmpl", filepath.Join("web", "app", e.Snake+"_pages.go")},
+		{"app_test.go.tmpl", filepath.Join("web", "app", e.Snake+"_pages_test.go")},
+		{"pages.templ.tmpl", filepath.Join("views", "pages", e.Snake+".templ")},
+	}
+}
+
+// registration is a call inserted at the end of a registration function
+type registration struct {
+	path string
+	fn   string
+	call string
+}
+
+func registrations(e Entity) []registration {
+	return []registration{
+		{filepath.Join("web", "api", "v1.go"), "func registerV1(", fmt.Sprintf("register%sRoutes(v1)", e.Name)},
+		{filepath.Join("web", "app", "routes.go"), "func RegisterWebRoutes(", fmt.Sprintf("register%sPages(r)", e.Name)},
+	}
+}
+
+// indexFile lists the Datastore composite indexes, relative to the
+// repository root
+const indexFile = "index.yaml"
+
+func run(opts options, name string, defs []string) error {
+	module, err := readModule(opts.root)
+	if err != nil {
+		return err
+	}
+	e, err := parseEntity(module, name, defs)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{}
+	for _, out := range outputs(e) {
+		content, err := render(out.template, e)
+		if err != nil {
+			return fmt.Errorf("%s: %w", out.path, err)
+		}
+		if !opts.force {
+			if _, err := os.Stat(filepath.Join(opts.root, out.path)); err == nil {
+				return fmt.Errorf("%s already exists (use -force to overwrite)", out.path)
+			}
+		}
+		files[out.path] = content
+	}
+
+	edits := map[string][]byte{}
+	for _, reg := range registrations(e) {
+		path := filepath.Join(opts.root, reg.path)
+		src, ok := edits[path]
+		if !ok {
+			if src, err = os.ReadFile(path); err != nil {
+				return err
+			}
+		}
+		updated, err := insertCall(src, reg.fn, reg.call)
+		if err != nil {
+			return fmt.Errorf("%s: %w", reg.path, err)
+		}
+		edits[path] = updated
+	}
+
+	indexes, err := appendIndexes(opts.root, e)
+	if err != nil {
+		return fmt.Errorf("%s: %w", indexFile, err)
+	}
+
+	for _, out := range outputs(e) {
+		fmt.Println("write", out.path)
+		if opts.dryRun {
+			continue
+		}
+		path := filepath.Join(opts.root, out.path)
+		if err := os.WriteFile(path, files[out.path], 0o644); err != nil {
+			return err
+		}
+	}
+	for _, reg := range registrations(e) {
+		fmt.Printf("register %s in %s\n", reg.call, reg.path)
+		if opts.dryRun {
+			continue
+		}
+		path := filepath.Join(opts.root, reg.path)
+		if err := os.WriteFile(path, edits[path], 0o644); err != nil {
+			return err
+		}
+	}
+	if indexes != nil {
+		fmt.Printf("index %s filters in %s\n", e.Name, indexFile)
+		if !opts.dryRun {
+			if err := os.WriteFile(filepath.Join(opts.root, indexFile), indexes, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.dryRun || opts.noTempl {
+		return nil
+	}
+	return generateTempl(opts.root)
+}
+
+// readModule returns the module path declared in root/go.mod
+func readModule(root string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("run hatgen from the repository root or pass -root: %w", err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("go.mod has no module directive")
+}
+
+// render executes the named template for e and gofmts Go output
+func render(name string, e Entity) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, e); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".go.tmpl") {
+		return buf.Bytes(), nil
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not compile: %w", err)
+	}
+	return formatted, nil
+}
+
+// appendIndexes returns index.yaml with the composite indexes that filtering
+// e's list newest first needs appended, or nil when e has no filterable
+// field or its indexes are already listed
+func appendIndexes(root string, e Entity) ([]byte, error) {
+	if len(e.Filterable()) == 0 {
+		return nil, nil
+	}
+	src, err := os.ReadFile(filepath.Join(root, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		src = []byte("indexes:\n")
+	} else if err != nil {
+		return nil, err
+	}
+	// the block's comment names the list spec
+	if bytes.Contains(src, []byte("(data."+e.Name+"List)")) {
+		return nil, nil
+	}
+	block, err := render("index.yaml.tmpl", e)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.Write(bytes.TrimRight(src, "\n"))
+	out.WriteString("\n")
+	out.Write(block)
+	return out.Bytes(), nil
+}
+
+// insertCall adds call as the last statement of the function whose
+// declaration starts with fn. Calls that are already present are left alone.
+func insertCall(src []byte, fn string, call string) ([]byte, error) {
+	if bytes.Contains(src, []byte(call)) {
+		return src, nil
+	}
+	start := bytes.Index(src, []byte(fn))
+	if start < 0 {
+		return nil, fmt.Errorf("%s not found", strings.TrimSuffix(fn, "("))
+	}
+	// the function ends at the first closing brace at the start of a line
+	end := bytes.Index(src[start:], []byte("\n}"))
+	if end < 0 {
+		return nil, fmt.Errorf("end of %s not found", strings.TrimSuffix(fn, "("))
+	}
+	end += start
+
+	body := bytes.TrimRight(src[:end], " \t\n")
+	var out bytes.Buffer
+	out.Write(body)
+	out.WriteString("\n\t" + call)
+	out.Write(src[end:])
+	return out.Bytes(), nil
+}
+
+// generateTempl compiles the new .templ file when the templ CLI is installed
+func generateTempl(root string) error {
+	path, err := exec.LookPath("templ")
+	if err != nil {
+		fmt.Println("templ not found on PATH: run templ generate before building")
+		return nil
+	}
+	for _, args := range [][]string{{"fmt", "views"}, {"generate"}} {
+		cmd := exec.Command(path, args...)
+		cmd.Dir = root
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("templ %s: %w", args[0], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_AllTemplates(t *testing.T) {
+	definitions := [][]string{
+		{"title:string:required,max=120,filter,sort", "body:text", "published:bool:filter", "views:int:gte=0,sort", "rating:float:filter", "published_at:time:sort"},
+		// no required field and no numbers
+		{"note:string"},
+	}
+
+	for _, defs := range definitions {
+		e, err := parseEntity("example.com/app", "BlogPost", defs)
+		assert.NoError(t, err)
+
+		for _, out := range outputs(e) {
+			// Go output is gofmt'ed by render, so it at least parses
+			content, err := render(out.template, e)
+			assert.NoError(t, err, out.path)
+			assert.NotContains(t, string(content), "<no value>", out.path)
+		}
+	}
+}
+
+func TestInsertCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "empty function",
+			src:      "package api\n\nfunc registerV1(v1 *versionGroup) {\n}\n",
+			expected: "package api\n\nfunc registerV1(v1 *versionGroup) {\n\tregisterPostRoutes(v1)\n}\n",
+		},
+		{
+			name:     "after existing statements and blank lines",
+			src:      "package api\n\nfunc registerV1(v1 *versionGroup) {\n\tregisterTagRoutes(v1)\n\n}\n\nfunc other() {\n}\n",
+			expected: "package api\n\nfunc registerV1(v1 *versionGroup) {\n\tregisterTagRoutes(v1)\n\tregisterPostRoutes(v1)\n}\n\nfunc other() {\n}\n",
+		},
+		{
+			name:     "already registered",
+			src:      "package api\n\nfunc registerV1(v1 *versionGroup) {\n\tregisterPostRoutes(v1)\n}\n",
+			expected: "package api\n\nfunc registerV1(v1 *versionGroup) {\n\tregisterPostRoutes(v1)\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := insertCall([]byte(tt.src), "func registerV1(", "registerPostRoutes(v1)")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+
+	_, err := insertCall([]byte("package api\n"), "func registerV1(", "registerPostRoutes(v1)")
+	assert.Error(t, err)
+}
+
+func TestRun_RefusesToOverwrite(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":            "module example.com/app\n\ngo 1.24\n",
+		"web/api/v1.go":     "package api\n\nfunc registerV1(v1 *versionGroup) {\n}\n",
+		"web/app/routes.go": "package app\n\nfunc RegisterWebRoutes(r *gin.Engine) {\n}\n",
+		"data/post.go":      "package data\n",
+		"services/.keep":    "",
+		"views/pages/.keep": "",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		assert.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+	opts := options{root: root, noTempl: true}
+
+	err := run(opts, "Post", []string{"title:string:required"})
+	assert.ErrorContains(t, err, "already exists")
+	v1, _ := os.ReadFile(filepath.Join(root, "web/api/v1.go"))
+	assert.NotContains(t, string(v1), "registerPostRoutes")
+
+	opts.force = true
+	assert.NoError(t, run(opts, "Post", []string{"title:string:required"}))
+	v1, _ = os.ReadFile(filepath.Join(root, "web/api/v1.go"))
+	assert.Contains(t, string(v1), "registerPostRoutes(v1)")
+	routes, _ := os.ReadFile(filepath.Join(root, "web/app/routes.go"))
+	assert.Contains(t, string(routes), "registerPostPages(r)")
+	_, err = os.Stat(filepath.Join(root, "views/pages/post.templ"))
+	assert.NoError(t, err)
+}
+
+func TestRun_AppendsIndexes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":            "module example.com/app\n\ngo 1.24\n",
+		"index.yaml":        "indexes:\n\n- kind: Job\n  properties:\n  - name: State\n  - name: RunAt\n",
+		"web/api/v1.go":     "package api\n\nfunc registerV1(v1 *versionGroup) {\n}\n",
+		"web/app/routes.go": "package app\n\nfunc RegisterWebRoutes(r *gin.Engine) {\n}\n",
+		"data/.keep":        "",
+		"services/.keep":    "",
+		"views/pages/.keep": "",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		assert.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+	opts := options{root: root, noTempl: true}
+	defs := []string{"title:string:required,filter,sort", "published:bool:filter", "views:int:sort"}
+
+	assert.NoError(t, run(opts, "Post", defs))
+	expected := files["index.yaml"] + `
+# posts: filtered by one field, newest first (data.PostList).
+# Datastore merges these indexes when several filters are combined.
+- kind: Post
+  properties:
+  - name: Title
+  - name: CreatedAt
+    direction: desc
+
+- kind: Post
+  properties:
+  - name: Published
+  - name: CreatedAt
+    direction: desc
+`
+	indexes, _ := os.ReadFile(filepath.Join(root, "index.yaml"))
+	assert.Equal(t, expected, string(indexes))
+
+	// generating again with -force does not list the indexes twice
+	opts.force = true
+	assert.NoError(t, run(opts, "Post", defs))
+	indexes, _ = os.ReadFile(filepath.Join(root, "index.yaml"))
+	assert.Equal(t, expected, string(indexes))
+}
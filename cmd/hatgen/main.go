@@ -0,0 +1,40 @@
+// Command hatgen scaffolds a CRUD resource following Docs/CodingGuidelines.md:
+// the data model and repository, the service, JSON API handlers registered
+// on API v1, HTML pages under /app, the templ views and table-driven tests.
+//
+//	go run ./cmd/hatgen BlogPost title:string:required,max=120,filter,sort body:text published:bool:filter
+//
+// Each field is name:type[:rules]. Types are string, text, int, float, bool
+// and time. Rules are gin binding rules plus "filter" and "sort", which
+// whitelist the field for list queries.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.root, "root", ".", "repository root containing go.mod")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "print the files that would be written without writing them")
+	flag.BoolVar(&opts.force, "force", false, "overwrite existing files")
+	flag.BoolVar(&opts.noTempl, "no-templ", false, "do not run templ generate afterwards")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: hatgen [flags] Entity name:type[:rules]...\n\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Types: string, text, int, float, bool, time\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Rules: gin binding rules plus filter and sort, e.g. title:string:required,max=120,filter,sort\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(opts, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "hatgen:", err)
+		os.Exit(1)
+	}
+}
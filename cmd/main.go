@@ -1,14 +1,28 @@
 ﻿package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"runtime-dynamics/config"
+	"syscall"
 	"time"
 
+	"runtime-dynamics/cache"
+	"runtime-dynamics/data"
+	"runtime-dynamics/flags"
+	"runtime-dynamics/jobs"
+	"runtime-dynamics/logging"
+	"runtime-dynamics/metrics"
+	"runtime-dynamics/tracing"
 	"runtime-dynamics/web"
+	"runtime-dynamics/web/middleware"
+	"runtime-dynamics/web/realtime"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -16,24 +30,16 @@ var (
 	version = "source"
 )
 
-func setLogger() {
-	zerolog.TimeFieldFormat = time.RFC3339Nano
-	//if len(os.Getenv("CONSOLE_LOG")) > 0 {
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
-	//}
-	if os.Getenv("DEBUG") != "" {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-		return
-	}
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-}
-
 func main() {
-	setLogger()
+	// console logging until the configuration is loaded
+	logging.Setup(os.Stdout, nil)
 	err := config.LoadConfig()
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
+	if err := logging.Setup(os.Stdout, config.Get()); err != nil {
+		log.Fatal().Err(err).Msg("failed to configure logging")
+	}
 	log.Info().Msgf("Starting StarXAPI (Version: %s)", version)
 	if os.Getenv("DEBUG") == "" {
 		gin.SetMode(gin.ReleaseMode)
@@ -41,25 +47,98 @@ func main() {
 
 	// Desktop token cleanup not required; tokens are stored in datastore and removed on connect
 	router := gin.New()
+	router.Use(middleware.Metrics())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Tracing())
+	router.Use(middleware.Authenticate())
+	router.Use(middleware.AuditRequest())
 	router.Use(func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
 		end := time.Now()
 		latency := end.Sub(start)
 		log.Info().
+			Ctx(c.Request.Context()).
+			Str("request_id", middleware.GetRequestID(c)).
 			Str("host", c.Request.Host).
 			Str("path", c.Request.URL.Path).
 			Str("action", c.Request.Method).
 			Int("status", c.Writer.Status()).
-			Dur("latency", latency)
+			Dur("latency", latency).
+			Msg("request")
 	})
 
 	web.Start(router)
+
+	// stop on Ctrl+C, and on SIGTERM from Cloud Run, which allows 10 seconds
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, config.Get(), version)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to set up tracing")
+	}
+
+	if config.Get().RealtimeBroker == "datastore" {
+		// reach SSE clients connected to other instances
+		realtime.DefaultHub().Connect(ctx, realtime.NewDatastoreBroker(data.Cli()))
+		log.Info().Msg("Realtime events are shared through Datastore")
+	}
+	log.Info().Str("backend", config.Get().CacheBackend).Msg("Caching reads")
+	flags.Default().Start(ctx)
+	jobs.Default().Start(ctx)
+	jobs.DefaultScheduler().Start(ctx)
+
 	port := os.Getenv("LISTEN_PORT")
 	listenPort := ":8080"
 	if port != "" {
 		listenPort = ":" + port
 	}
-	log.Info().Msgf("Listening on %s", listenPort)
-	log.Fatal().Err(router.Run(listenPort))
+	server := &http.Server{Addr: listenPort, Handler: router}
+	// long-lived streams never go idle, so end them when shutdown starts
+	server.RegisterOnShutdown(realtime.DefaultHub().Close)
+	server.RegisterOnShutdown(realtime.DefaultWSRouter().Close)
+	var metricsServer *http.Server
+	if metricsPort := config.Get().MetricsPort; metricsPort > 0 {
+		// keep metrics off the public port, for scrapers inside the network
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsServer = &http.Server{Addr: fmt.Sprintf(":%d", metricsPort), Handler: mux}
+		go func() {
+			log.Info().Msgf("Serving metrics on %s", metricsServer.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal().Err(err).Msg("metrics server failed")
+			}
+		}()
+	}
+	go func() {
+		log.Info().Msgf("Listening on %s", listenPort)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("server failed")
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to stop the server gracefully")
+	}
+	if metricsServer != nil {
+		metricsServer.Shutdown(shutdownCtx)
+	}
+	if err := jobs.DefaultScheduler().Stop(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to stop scheduled tasks gracefully")
+	}
+	if err := jobs.Default().Stop(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to stop job workers gracefully")
+	}
+	flags.Default().Stop()
+	if err := cache.Default().Close(); err != nil {
+		log.Error().Err(err).Msg("failed to close the cache")
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to flush traces")
+	}
 }
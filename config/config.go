@@ -2,10 +2,14 @@ package config
 
 import (
 	"encoding/base64"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -20,6 +24,39 @@ type AppConfig struct {
 	FirebaseAPIKey     string
 	FirebaseAuthDomain string
 	GoogleProjectID    string
+	SiteName           string
+	SiteTagline        string
+	SiteLogoURL        string
+	RobotsDisallow     []string
+	RobotsBlockAll     bool
+	TrustedProxies     []string
+	ForwardedHeader    string
+	AllowedHosts       []string
+	CanonicalURL       string
+	APIDefaultVersion  string
+	AdminEmails        []string
+	RealtimeBroker     string
+	JobConcurrency     int
+	MailTransport      string
+	MailFrom           string
+	MailDir            string
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	MetricsPort        int
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingSampleRatio float64
+	LogFormat          string
+	LogLevel           string
+	LogLevels          map[string]string
+	LogRedactFields    []string
+	FlagsRefresh       time.Duration
+	CacheBackend       string
+	CacheRedisURL      string
+	CacheMaxEntries    int
+	CacheTTL           time.Duration
 }
 
 func Get() *AppConfig {
@@ -37,6 +74,36 @@ func LoadConfig() error {
 		FrontendEndpoint:   strings.TrimSpace(os.Getenv("FRONTEND_ENDPOINT")),
 		FirebaseAPIKey:     strings.TrimSpace(os.Getenv("FIREBASE_API_KEY")),
 		FirebaseAuthDomain: strings.TrimSpace(os.Getenv("FIREBASE_AUTH_DOMAIN")),
+		SiteName:           strings.TrimSpace(os.Getenv("SITE_NAME")),
+		SiteTagline:        strings.TrimSpace(os.Getenv("SITE_TAGLINE")),
+		SiteLogoURL:        strings.TrimSpace(os.Getenv("SITE_LOGO_URL")),
+		RobotsDisallow:     splitList(os.Getenv("ROBOTS_DISALLOW")),
+		RobotsBlockAll:     strings.TrimSpace(os.Getenv("ROBOTS_BLOCK_ALL")) == "true",
+		TrustedProxies:     splitList(os.Getenv("TRUSTED_PROXIES")),
+		ForwardedHeader:    strings.ToLower(strings.TrimSpace(os.Getenv("FORWARDED_HEADER"))),
+		AllowedHosts:       splitList(strings.ToLower(os.Getenv("ALLOWED_HOSTS"))),
+		CanonicalURL:       strings.TrimSuffix(strings.TrimSpace(os.Getenv("CANONICAL_URL")), "/"),
+		APIDefaultVersion:  strings.TrimSpace(os.Getenv("API_DEFAULT_VERSION")),
+		AdminEmails:        splitList(strings.ToLower(os.Getenv("ADMIN_EMAILS"))),
+		RealtimeBroker:     strings.ToLower(strings.TrimSpace(os.Getenv("REALTIME_BROKER"))),
+		MailTransport:      strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_TRANSPORT"))),
+		MailFrom:           strings.TrimSpace(os.Getenv("MAIL_FROM")),
+		MailDir:            strings.TrimSpace(os.Getenv("MAIL_DIR")),
+		SMTPHost:           strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		SMTPUsername:       strings.TrimSpace(os.Getenv("SMTP_USERNAME")),
+		SMTPPassword:       os.Getenv("SMTP_PASSWORD"),
+		TracingExporter:    strings.ToLower(strings.TrimSpace(os.Getenv("TRACING_EXPORTER"))),
+		TracingEndpoint:    strings.TrimSpace(os.Getenv("TRACING_ENDPOINT")),
+		TracingSampleRatio: 1,
+		LogFormat:          strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT"))),
+		LogLevel:           strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL"))),
+		LogRedactFields:    splitList(os.Getenv("LOG_REDACT_FIELDS")),
+		CacheBackend:       strings.ToLower(strings.TrimSpace(os.Getenv("CACHE_BACKEND"))),
+		CacheRedisURL:      strings.TrimSpace(os.Getenv("CACHE_REDIS_URL")),
+	}
+	config.GoogleProjectID = strings.TrimSpace(os.Getenv("GOOGLE_PROJECT_ID"))
+	if len(config.GoogleProjectID) == 0 {
+		config.GoogleProjectID = strings.TrimSpace(os.Getenv("DATASTORE_PROJECT_ID"))
 	}
 	if len(config.DataStoreName) == 0 {
 		log.Info().Msg("Using 'default' datastore database")
@@ -47,9 +114,148 @@ func LoadConfig() error {
 		config.FrontendEndpoint = strings.TrimSpace("http://local.nitecon.net:8080")
 	}
 
+	if len(config.SiteName) == 0 {
+		config.SiteName = "H.A.T. Stack App"
+	}
+
+	switch config.ForwardedHeader {
+	case "":
+		config.ForwardedHeader = "x-forwarded"
+	case "x-forwarded", "forwarded":
+	default:
+		return fmt.Errorf("unknown FORWARDED_HEADER %q (use x-forwarded or forwarded)", config.ForwardedHeader)
+	}
+
+	if value := strings.TrimSpace(os.Getenv("JOBS_CONCURRENCY")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("JOBS_CONCURRENCY must be a positive number, got %q", value)
+		}
+		config.JobConcurrency = n
+	}
+
+	switch config.RealtimeBroker {
+	case "", "datastore":
+	default:
+		return fmt.Errorf("unknown REALTIME_BROKER %q (use datastore or leave empty)", config.RealtimeBroker)
+	}
+
+	if value := strings.TrimSpace(os.Getenv("METRICS_PORT")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("METRICS_PORT must be a port number, got %q", value)
+		}
+		config.MetricsPort = n
+	}
+
+	switch config.LogFormat {
+	case "", "console", "json", "gcp":
+	default:
+		return fmt.Errorf("unknown LOG_FORMAT %q (use console, json or gcp)", config.LogFormat)
+	}
+	if config.LogLevel != "" {
+		if _, err := zerolog.ParseLevel(config.LogLevel); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %q", config.LogLevel)
+		}
+	}
+	config.LogLevels = make(map[string]string)
+	for _, item := range splitList(strings.ToLower(os.Getenv("LOG_LEVELS"))) {
+		pkg, level, ok := strings.Cut(item, "=")
+		pkg, level = strings.Trim(strings.TrimSpace(pkg), "/"), strings.TrimSpace(level)
+		if _, err := zerolog.ParseLevel(level); !ok || pkg == "" || level == "" || err != nil {
+			return fmt.Errorf("invalid LOG_LEVELS entry %q (use package=level)", item)
+		}
+		config.LogLevels[pkg] = level
+	}
+
+	switch config.TracingExporter {
+	case "", "otlp", "stdout":
+	default:
+		return fmt.Errorf("unknown TRACING_EXPORTER %q (use otlp, stdout or leave empty)", config.TracingExporter)
+	}
+	if value := strings.TrimSpace(os.Getenv("TRACING_SAMPLE_RATIO")); value != "" {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %q", value)
+		}
+		config.TracingSampleRatio = ratio
+	}
+
+	if value := strings.TrimSpace(os.Getenv("FLAGS_REFRESH")); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Second {
+			return fmt.Errorf("FLAGS_REFRESH must be a duration of at least 1s, got %q", value)
+		}
+		config.FlagsRefresh = d
+	}
+
+	switch config.CacheBackend {
+	case "":
+		config.CacheBackend = "memory"
+	case "memory", "none":
+	case "redis":
+		if !strings.HasPrefix(config.CacheRedisURL, "redis://") && !strings.HasPrefix(config.CacheRedisURL, "rediss://") {
+			return fmt.Errorf("CACHE_BACKEND redis requires a redis:// or rediss:// CACHE_REDIS_URL")
+		}
+	default:
+		return fmt.Errorf("unknown CACHE_BACKEND %q (use memory, redis or none)", config.CacheBackend)
+	}
+	if value := strings.TrimSpace(os.Getenv("CACHE_MAX_ENTRIES")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("CACHE_MAX_ENTRIES must be a positive number, got %q", value)
+		}
+		config.CacheMaxEntries = n
+	}
+	if value := strings.TrimSpace(os.Getenv("CACHE_TTL")); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Second {
+			return fmt.Errorf("CACHE_TTL must be a duration of at least 1s, got %q", value)
+		}
+		config.CacheTTL = d
+	}
+
+	config.SMTPPort = 587
+	if value := strings.TrimSpace(os.Getenv("SMTP_PORT")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("SMTP_PORT must be a port number, got %q", value)
+		}
+		config.SMTPPort = n
+	}
+	if len(config.MailDir) == 0 {
+		config.MailDir = "tmp/mail"
+	}
+	switch config.MailTransport {
+	case "":
+		// deliver when a mail server is configured, keep files otherwise
+		config.MailTransport = "file"
+		if config.SMTPHost != "" {
+			config.MailTransport = "smtp"
+		}
+	case "smtp":
+		if config.SMTPHost == "" {
+			return fmt.Errorf("MAIL_TRANSPORT smtp requires SMTP_HOST")
+		}
+	case "file", "memory":
+	default:
+		return fmt.Errorf("unknown MAIL_TRANSPORT %q (use smtp, file or memory)", config.MailTransport)
+	}
+
 	return nil
 }
 
+// splitList splits a comma-separated environment value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // decodeBase64Cert decodes a base64-encoded certificate or key from environment variable.
 // If the value is not base64-encoded (e.g., already in PEM format), it returns the value as-is.
 // Returns empty string if the environment variable is not set.
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -101,6 +102,419 @@ func TestLoadConfig(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_SEOSettings(t *testing.T) {
+	os.Setenv("SITE_NAME", "Acme")
+	os.Setenv("ROBOTS_DISALLOW", "/search, ,/tmp/")
+	os.Setenv("ROBOTS_BLOCK_ALL", "true")
+	defer func() {
+		os.Unsetenv("SITE_NAME")
+		os.Unsetenv("ROBOTS_DISALLOW")
+		os.Unsetenv("ROBOTS_BLOCK_ALL")
+	}()
+
+	if err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg := Get()
+
+	if cfg.SiteName != "Acme" {
+		t.Errorf("SiteName = %v, want %v", cfg.SiteName, "Acme")
+	}
+	if len(cfg.RobotsDisallow) != 2 || cfg.RobotsDisallow[0] != "/search" || cfg.RobotsDisallow[1] != "/tmp/" {
+		t.Errorf("RobotsDisallow = %v, want %v", cfg.RobotsDisallow, []string{"/search", "/tmp/"})
+	}
+	if !cfg.RobotsBlockAll {
+		t.Error("RobotsBlockAll = false, want true")
+	}
+
+	os.Unsetenv("SITE_NAME")
+	if err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if Get().SiteName != "H.A.T. Stack App" {
+		t.Errorf("SiteName = %v, want default", Get().SiteName)
+	}
+}
+
+func TestLoadConfig_ProxySettings(t *testing.T) {
+	os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.1")
+	os.Setenv("CANONICAL_URL", "https://www.example.com/")
+	os.Setenv("ALLOWED_HOSTS", "Shop.example.com, www.example.com")
+	defer func() {
+		os.Unsetenv("TRUSTED_PROXIES")
+		os.Unsetenv("CANONICAL_URL")
+		os.Unsetenv("ALLOWED_HOSTS")
+	}()
+
+	if err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg := Get()
+
+	if len(cfg.TrustedProxies) != 2 || cfg.TrustedProxies[1] != "192.168.1.1" {
+		t.Errorf("TrustedProxies = %v, want %v", cfg.TrustedProxies, []string{"10.0.0.0/8", "192.168.1.1"})
+	}
+	if cfg.CanonicalURL != "https://www.example.com" {
+		t.Errorf("CanonicalURL = %v, want %v", cfg.CanonicalURL, "https://www.example.com")
+	}
+	if len(cfg.AllowedHosts) != 2 || cfg.AllowedHosts[0] != "shop.example.com" {
+		t.Errorf("AllowedHosts = %v, want %v", cfg.AllowedHosts, []string{"shop.example.com", "www.example.com"})
+	}
+	if cfg.ForwardedHeader != "x-forwarded" {
+		t.Errorf("ForwardedHeader = %v, want x-forwarded", cfg.ForwardedHeader)
+	}
+}
+
+func TestLoadConfig_ForwardedHeader(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      string
+		expectErr bool
+	}{
+		{value: "", want: "x-forwarded"},
+		{value: " Forwarded ", want: "forwarded"},
+		{value: "x-forwarded", want: "x-forwarded"},
+		{value: "x-real-ip", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			os.Setenv("FORWARDED_HEADER", tt.value)
+			defer os.Unsetenv("FORWARDED_HEADER")
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if got := Get().ForwardedHeader; got != tt.want {
+				t.Errorf("ForwardedHeader = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_APIDefaultVersion(t *testing.T) {
+	os.Setenv("API_DEFAULT_VERSION", " v2 ")
+	defer os.Unsetenv("API_DEFAULT_VERSION")
+
+	if err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got := Get().APIDefaultVersion; got != "v2" {
+		t.Errorf("APIDefaultVersion = %v, want %v", got, "v2")
+	}
+}
+
+func TestLoadConfig_AdminEmails(t *testing.T) {
+	os.Setenv("ADMIN_EMAILS", "Ops@Example.com, admin@example.com")
+	defer os.Unsetenv("ADMIN_EMAILS")
+
+	if err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := []string{"ops@example.com", "admin@example.com"}
+	if got := Get().AdminEmails; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("AdminEmails = %v, want %v", got, want)
+	}
+}
+
+func TestLoadConfig_RealtimeBroker(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      string
+		expectErr bool
+	}{
+		{value: "", want: ""},
+		{value: " Datastore ", want: "datastore"},
+		{value: "redis", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			os.Setenv("REALTIME_BROKER", tt.value)
+			defer os.Unsetenv("REALTIME_BROKER")
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if got := Get().RealtimeBroker; got != tt.want {
+				t.Errorf("RealtimeBroker = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_JobConcurrency(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      int
+		expectErr bool
+	}{
+		{value: "", want: 0},
+		{value: "8", want: 8},
+		{value: "0", expectErr: true},
+		{value: "many", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			os.Setenv("JOBS_CONCURRENCY", tt.value)
+			defer os.Unsetenv("JOBS_CONCURRENCY")
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if got := Get().JobConcurrency; got != tt.want {
+				t.Errorf("JobConcurrency = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Mail(t *testing.T) {
+	tests := []struct {
+		name          string
+		envVars       map[string]string
+		wantTransport string
+		wantPort      int
+		expectErr     bool
+	}{
+		{name: "defaults to files", envVars: map[string]string{}, wantTransport: "file", wantPort: 587},
+		{name: "smtp when a host is set", envVars: map[string]string{"SMTP_HOST": "smtp.example.com", "SMTP_PORT": "465"}, wantTransport: "smtp", wantPort: 465},
+		{name: "explicit memory", envVars: map[string]string{"MAIL_TRANSPORT": "Memory", "SMTP_HOST": "smtp.example.com"}, wantTransport: "memory", wantPort: 587},
+		{name: "smtp without host", envVars: map[string]string{"MAIL_TRANSPORT": "smtp"}, expectErr: true},
+		{name: "unknown transport", envVars: map[string]string{"MAIL_TRANSPORT": "carrier-pigeon"}, expectErr: true},
+		{name: "invalid port", envVars: map[string]string{"SMTP_PORT": "99999"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+			defer func() {
+				for key := range tt.envVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			cfg := Get()
+			if cfg.MailTransport != tt.wantTransport {
+				t.Errorf("MailTransport = %q, want %q", cfg.MailTransport, tt.wantTransport)
+			}
+			if cfg.SMTPPort != tt.wantPort {
+				t.Errorf("SMTPPort = %d, want %d", cfg.SMTPPort, tt.wantPort)
+			}
+			if cfg.MailDir != "tmp/mail" {
+				t.Errorf("MailDir = %q, want %q", cfg.MailDir, "tmp/mail")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_MetricsPort(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      int
+		expectErr bool
+	}{
+		{value: "", want: 0},
+		{value: "9090", want: 9090},
+		{value: "0", expectErr: true},
+		{value: "metrics", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			os.Setenv("METRICS_PORT", tt.value)
+			defer os.Unsetenv("METRICS_PORT")
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if got := Get().MetricsPort; got != tt.want {
+				t.Errorf("MetricsPort = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_FlagsRefresh(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      time.Duration
+		expectErr bool
+	}{
+		{value: "", want: 0},
+		{value: "1m", want: time.Minute},
+		{value: "500ms", expectErr: true},
+		{value: "often", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			os.Setenv("FLAGS_REFRESH", tt.value)
+			defer os.Unsetenv("FLAGS_REFRESH")
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if got := Get().FlagsRefresh; got != tt.want {
+				t.Errorf("FlagsRefresh = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Tracing(t *testing.T) {
+	tests := []struct {
+		name         string
+		envVars      map[string]string
+		wantExporter string
+		wantRatio    float64
+		expectErr    bool
+	}{
+		{name: "disabled", envVars: map[string]string{}, wantExporter: "", wantRatio: 1},
+		{name: "otlp", envVars: map[string]string{"TRACING_EXPORTER": " OTLP ", "TRACING_SAMPLE_RATIO": "0.25"}, wantExporter: "otlp", wantRatio: 0.25},
+		{name: "stdout", envVars: map[string]string{"TRACING_EXPORTER": "stdout"}, wantExporter: "stdout", wantRatio: 1},
+		{name: "unknown exporter", envVars: map[string]string{"TRACING_EXPORTER": "zipkin"}, expectErr: true},
+		{name: "ratio out of range", envVars: map[string]string{"TRACING_SAMPLE_RATIO": "2"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+			defer func() {
+				for key := range tt.envVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			cfg := Get()
+			if cfg.TracingExporter != tt.wantExporter {
+				t.Errorf("TracingExporter = %q, want %q", cfg.TracingExporter, tt.wantExporter)
+			}
+			if cfg.TracingSampleRatio != tt.wantRatio {
+				t.Errorf("TracingSampleRatio = %v, want %v", cfg.TracingSampleRatio, tt.wantRatio)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Logging(t *testing.T) {
+	tests := []struct {
+		name       string
+		envVars    map[string]string
+		wantFormat string
+		wantLevels map[string]string
+		expectErr  bool
+	}{
+		{name: "defaults", envVars: map[string]string{}, wantFormat: "", wantLevels: map[string]string{}},
+		{
+			name:       "gcp with package levels",
+			envVars:    map[string]string{"LOG_FORMAT": "GCP", "LOG_LEVEL": "warn", "LOG_LEVELS": "jobs=debug, /web/realtime/=error"},
+			wantFormat: "gcp",
+			wantLevels: map[string]string{"jobs": "debug", "web/realtime": "error"},
+		},
+		{name: "unknown format", envVars: map[string]string{"LOG_FORMAT": "xml"}, expectErr: true},
+		{name: "unknown level", envVars: map[string]string{"LOG_LEVEL": "loud"}, expectErr: true},
+		{name: "package without level", envVars: map[string]string{"LOG_LEVELS": "jobs"}, expectErr: true},
+		{name: "package with unknown level", envVars: map[string]string{"LOG_LEVELS": "jobs=loud"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+			defer func() {
+				for key := range tt.envVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			cfg := Get()
+			if cfg.LogFormat != tt.wantFormat {
+				t.Errorf("LogFormat = %q, want %q", cfg.LogFormat, tt.wantFormat)
+			}
+			if len(cfg.LogLevels) != len(tt.wantLevels) {
+				t.Errorf("LogLevels = %v, want %v", cfg.LogLevels, tt.wantLevels)
+			}
+			for pkg, level := range tt.wantLevels {
+				if cfg.LogLevels[pkg] != level {
+					t.Errorf("LogLevels[%q] = %q, want %q", pkg, cfg.LogLevels[pkg], level)
+				}
+			}
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	// Load a test config
 	os.Setenv("DATASTORE_NAME", "test-get")
@@ -193,3 +607,57 @@ func TestConfigThreadSafety(t *testing.T) {
 		<-done
 	}
 }
+
+func TestLoadConfig_Cache(t *testing.T) {
+	tests := []struct {
+		name        string
+		envVars     map[string]string
+		wantBackend string
+		wantEntries int
+		wantTTL     time.Duration
+		expectErr   bool
+	}{
+		{name: "defaults", envVars: map[string]string{}, wantBackend: "memory"},
+		{name: "memory", envVars: map[string]string{"CACHE_BACKEND": "Memory", "CACHE_MAX_ENTRIES": "500", "CACHE_TTL": "1m"}, wantBackend: "memory", wantEntries: 500, wantTTL: time.Minute},
+		{name: "redis", envVars: map[string]string{"CACHE_BACKEND": "redis", "CACHE_REDIS_URL": "redis://localhost:6379/0"}, wantBackend: "redis"},
+		{name: "none", envVars: map[string]string{"CACHE_BACKEND": "none"}, wantBackend: "none"},
+		{name: "redis without url", envVars: map[string]string{"CACHE_BACKEND": "redis"}, expectErr: true},
+		{name: "unknown backend", envVars: map[string]string{"CACHE_BACKEND": "memcached"}, expectErr: true},
+		{name: "zero entries", envVars: map[string]string{"CACHE_MAX_ENTRIES": "0"}, expectErr: true},
+		{name: "short ttl", envVars: map[string]string{"CACHE_TTL": "10ms"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+			defer func() {
+				for key := range tt.envVars {
+					os.Unsetenv(key)
+				}
+			}()
+
+			err := LoadConfig()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("LoadConfig() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			cfg := Get()
+			if cfg.CacheBackend != tt.wantBackend {
+				t.Errorf("CacheBackend = %q, want %q", cfg.CacheBackend, tt.wantBackend)
+			}
+			if cfg.CacheMaxEntries != tt.wantEntries {
+				t.Errorf("CacheMaxEntries = %d, want %d", cfg.CacheMaxEntries, tt.wantEntries)
+			}
+			if cfg.CacheTTL != tt.wantTTL {
+				t.Errorf("CacheTTL = %v, want %v", cfg.CacheTTL, tt.wantTTL)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"time"
+
+	"runtime-dynamics/data/query"
+
+	"cloud.google.com/go/datastore"
+)
+
+// AuditEvent records who did what to which entity, stored under the
+// "AuditEvent" kind. Events are only ever inserted, never changed.
+type AuditEvent struct {
+	ID string `json:"id"`
+	// Action names what happened, e.g. "note.update" or "admin.delete"
+	Action string `json:"action"`
+	// ActorID and ActorEmail identify the signed-in user; both are empty for
+	// actions of the system, e.g. background jobs
+	ActorID    string `json:"actor_id"`
+	ActorEmail string `json:"actor_email"`
+	TargetKind string `json:"target_kind"`
+	TargetID   string `json:"target_id"`
+	// Changes lists the fields that differ between before and after
+	Changes []AuditChange `json:"changes" datastore:",noindex"`
+	// RequestID, TraceID, IP, UserAgent, Method and Path describe the request
+	// the action was part of
+	RequestID string    `json:"request_id"`
+	TraceID   string    `json:"trace_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent" datastore:",noindex"`
+	Method    string    `json:"method" datastore:",noindex"`
+	Path      string    `json:"path" datastore:",noindex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditChange is one changed field of an audited entity. Before is empty for
+// created entities and After for deleted ones.
+type AuditChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// SetKey copies the key name into ID when loaded by query.GetPage
+func (e *AuditEvent) SetKey(key *datastore.Key) {
+	e.ID = key.Name
+}
+
+// AuditEventList whitelists the fields audit events can be filtered by.
+// Every filter needs a composite index with CreatedAt descending, see
+// index.yaml.
+var AuditEventList = query.Spec{
+	Fields: map[string]query.Field{
+		"action":      {Property: "Action", Ops: []query.Op{query.Eq, query.In}},
+		"actor_id":    {Property: "ActorID", Ops: []query.Op{query.Eq}},
+		"actor_email": {Property: "ActorEmail", Ops: []query.Op{query.Eq}},
+		"target_kind": {Property: "TargetKind", Ops: []query.Op{query.Eq}},
+		"target_id":   {Property: "TargetID", Ops: []query.Op{query.Eq}},
+		"request_id":  {Property: "RequestID", Ops: []query.Op{query.Eq}},
+		"created_at":  {Property: "CreatedAt", Type: query.Time, Ops: []query.Op{query.Gte, query.Lt}, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"context"
+
+	"runtime-dynamics/data/query"
+
+	"cloud.google.com/go/datastore"
+	"github.com/rs/zerolog/log"
+)
+
+// AuditEventRepository stores audit events. It has no Update or Delete:
+// the audit log is append-only.
+type AuditEventRepository struct {
+	*BaseRepository
+}
+
+func NewAuditEventRepository() *AuditEventRepository {
+	return &AuditEventRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetByID retrieves an audit event by its ID
+func (r *AuditEventRepository) GetByID(ctx context.Context, id string) (*AuditEvent, error) {
+	if id == "" {
+		return nil, datastore.ErrNoSuchEntity
+	}
+	key := datastore.NameKey("AuditEvent", id, nil)
+	event := &AuditEvent{}
+	if err := r.Client().Get(ctx, key, event); err != nil {
+		if !IsNotFound(err) {
+			log.Error().Err(err).Msgf("failed to get AuditEvent by id: %s", id)
+		}
+		return nil, err
+	}
+	event.ID = id
+	return event, nil
+}
+
+// Create inserts a new audit event. It fails instead of overwriting when an
+// event with the same ID exists.
+func (r *AuditEventRepository) Create(ctx context.Context, event *AuditEvent) error {
+	key := datastore.NameKey("AuditEvent", event.ID, nil)
+	log.Debug().Msgf("Creating AuditEvent with id: %s", event.ID)
+	if _, err := r.Client().Mutate(ctx, datastore.NewInsert(key, event)); err != nil {
+		log.Error().Err(err).Msg("failed to create AuditEvent")
+		return err
+	}
+	return nil
+}
+
+// List retrieves one page of audit events
+func (r *AuditEventRepository) List(ctx context.Context, list query.List) (query.Page[AuditEvent], error) {
+	q := datastore.NewQuery("AuditEvent")
+	page, err := query.GetPage[AuditEvent](ctx, r.Client(), q, list)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list AuditEvent")
+		return page, err
+	}
+	return page, nil
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+
+	"runtime-dynamics/cache"
+	"runtime-dynamics/data/query"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CachedStore is a Store reading through a cache: GetByID and List results
+// are cached, and every write through it invalidates the written entity and
+// all cached list pages of its kind. T must have a string ID field, as
+// entities generated by hatgen do.
+//
+// Writes made around the store, e.g. with the Datastore client, are only
+// seen once the cached entries expire.
+type CachedStore[T any] struct {
+	kind  string
+	store Store[T]
+	cache *cache.Cache
+}
+
+// NewCachedStore wraps the repository of kind. A nil c uses cache.Default()
+// at the time of each call, so stores can be created in init.
+func NewCachedStore[T any](kind string, store Store[T], c *cache.Cache) *CachedStore[T] {
+	return &CachedStore[T]{kind: kind, store: store, cache: c}
+}
+
+// GetByID retrieves an entity from the cache or the wrapped store
+func (s *CachedStore[T]) GetByID(ctx context.Context, id string) (*T, error) {
+	if id == "" {
+		return s.store.GetByID(ctx, id)
+	}
+	return cache.GetOrLoad(ctx, s.c(), s.entityKey(id), 0, func(ctx context.Context) (*T, error) {
+		return s.store.GetByID(ctx, id)
+	}, cache.EntityTag(s.kind, id))
+}
+
+// List retrieves a page from the cache or the wrapped store
+func (s *CachedStore[T]) List(ctx context.Context, list query.List) (query.Page[T], error) {
+	encoded, err := json.Marshal(list)
+	if err != nil {
+		return s.store.List(ctx, list)
+	}
+	sum := sha256.Sum256(encoded)
+	key := "list:" + s.kind + ":" + hex.EncodeToString(sum[:16])
+	page, err := cache.GetOrLoad(ctx, s.c(), key, 0, func(ctx context.Context) (query.Page[T], error) {
+		return s.store.List(ctx, list)
+	}, cache.KindTag(s.kind))
+	if err == nil && page.Items == nil {
+		// gob decodes an empty page as nil items; keep them a JSON array
+		page.Items = []T{}
+	}
+	return page, err
+}
+
+// Create stores a new entity and invalidates the lists of its kind
+func (s *CachedStore[T]) Create(ctx context.Context, entity *T) error {
+	err := s.store.Create(ctx, entity)
+	s.invalidate(ctx, entityID(entity))
+	return err
+}
+
+// Update replaces an entity and invalidates it and the lists of its kind
+func (s *CachedStore[T]) Update(ctx context.Context, entity *T) error {
+	err := s.store.Update(ctx, entity)
+	s.invalidate(ctx, entityID(entity))
+	return err
+}
+
+// Delete removes an entity and invalidates it and the lists of its kind
+func (s *CachedStore[T]) Delete(ctx context.Context, id string) error {
+	err := s.store.Delete(ctx, id)
+	s.invalidate(ctx, id)
+	return err
+}
+
+// invalidate runs after every write, failed ones included, as a failed
+// write may still have been applied. The write stands when invalidation
+// fails, so the failure is logged, not returned.
+func (s *CachedStore[T]) invalidate(ctx context.Context, id string) {
+	c := s.c()
+	tags := []string{cache.KindTag(s.kind)}
+	if id != "" {
+		tags = append(tags, cache.EntityTag(s.kind, id))
+		if err := c.Delete(ctx, s.entityKey(id)); err != nil {
+			log.Error().Ctx(ctx).Err(err).Msgf("failed to delete cached %s: %s", s.kind, id)
+		}
+	}
+	if err := c.Invalidate(ctx, tags...); err != nil {
+		log.Error().Ctx(ctx).Err(err).Msgf("failed to invalidate cached %s: %s", s.kind, id)
+	}
+}
+
+func (s *CachedStore[T]) entityKey(id string) string {
+	return "entity:" + s.kind + ":" + id
+}
+
+func (s *CachedStore[T]) c() *cache.Cache {
+	if s.cache != nil {
+		return s.cache
+	}
+	return cache.Default()
+}
+
+// entityID returns the ID field of entity, empty when it has none
+func entityID(entity any) string {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if id := v.FieldByName("ID"); id.IsValid() && id.Kind() == reflect.String {
+		return id.String()
+	}
+	return ""
+}
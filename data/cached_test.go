@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"runtime-dynamics/cache"
+	"runtime-dynamics/data/query"
+)
+
+// countingStore counts the reads reaching the wrapped store
+type countingStore struct {
+	memoryStore
+	gets, lists int
+}
+
+func (s *countingStore) GetByID(ctx context.Context, id string) (*testWidget, error) {
+	s.gets++
+	return s.memoryStore.GetByID(ctx, id)
+}
+
+func (s *countingStore) List(ctx context.Context, list query.List) (query.Page[testWidget], error) {
+	s.lists++
+	return s.memoryStore.List(ctx, list)
+}
+
+func TestCachedStore(t *testing.T) {
+	inner := &countingStore{memoryStore: memoryStore{items: map[string]testWidget{}}}
+	store := NewCachedStore[testWidget]("Widget", inner, cache.New(cache.NewMemory(0), 0))
+	ctx := context.Background()
+
+	if err := store.Create(ctx, &testWidget{ID: "w1", Name: "Sprocket"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := store.GetByID(ctx, "w1")
+		if err != nil || got.Name != "Sprocket" {
+			t.Fatalf("GetByID() = %v, %v", got, err)
+		}
+		got.Name = "changed by caller"
+	}
+	if inner.gets != 1 {
+		t.Errorf("GetByID() reached the store %d times, want 1", inner.gets)
+	}
+
+	list := query.List{Limit: 10}
+	for i := 0; i < 2; i++ {
+		if page, err := store.List(ctx, list); err != nil || len(page.Items) != 1 {
+			t.Fatalf("List() = %v, %v", page, err)
+		}
+	}
+	if _, err := store.List(ctx, query.List{Limit: 20}); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if inner.lists != 2 {
+		t.Errorf("List() reached the store %d times, want 2 (one per distinct list)", inner.lists)
+	}
+
+	if err := store.Update(ctx, &testWidget{ID: "w1", Name: "Gear"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, _ := store.GetByID(ctx, "w1"); got.Name != "Gear" {
+		t.Errorf("GetByID() after Update = %q, want Gear", got.Name)
+	}
+	if page, _ := store.List(ctx, list); page.Items[0].Name != "Gear" {
+		t.Errorf("List() after Update = %v, want Gear", page.Items)
+	}
+	if inner.gets != 2 || inner.lists != 3 {
+		t.Errorf("after Update: gets = %d, lists = %d, want 2 and 3", inner.gets, inner.lists)
+	}
+
+	if err := store.Delete(ctx, "w1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if page, _ := store.List(ctx, list); len(page.Items) != 0 {
+		t.Errorf("List() after Delete = %v, want no items", page.Items)
+	}
+}
+
+func TestEntityID(t *testing.T) {
+	if got := entityID(&testWidget{ID: "w1"}); got != "w1" {
+		t.Errorf("entityID() = %q, want w1", got)
+	}
+	if got := entityID(&struct{ Name string }{}); got != "" {
+		t.Errorf("entityID() without an ID field = %q, want empty", got)
+	}
+}
+
+func TestCachedStore_EmptyList(t *testing.T) {
+	inner := &countingStore{memoryStore: memoryStore{items: map[string]testWidget{}}}
+	store := NewCachedStore[testWidget]("Widget", inner, cache.New(cache.NewMemory(0), 0))
+
+	for i := 0; i < 2; i++ {
+		page, err := store.List(context.Background(), query.List{Limit: 10})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		encoded, _ := json.Marshal(page)
+		if !strings.Contains(string(encoded), `"items":[]`) {
+			t.Errorf("List() #%d = %s, want empty items array", i+1, encoded)
+		}
+	}
+	if inner.lists != 1 {
+		t.Errorf("List() reached the store %d times, want 1", inner.lists)
+	}
+}
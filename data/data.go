@@ -20,7 +20,7 @@ func Cli() *datastore.Client {
 	var err error
 	dataOnce.Do(func() {
 		ctx = context.Background()
-		dataClient, err = datastore.NewClientWithDatabase(ctx, config.Get().GoogleProjectID, config.Get().DataStoreName)
+		dataClient, err = datastore.NewClientWithDatabase(ctx, config.Get().GoogleProjectID, config.Get().DataStoreName, clientOptions()...)
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to create datastore client")
 		}
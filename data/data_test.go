@@ -91,3 +91,13 @@ func TestCli_Integration(t *testing.T) {
 	}
 }
 */
+
+func TestNewID(t *testing.T) {
+	a, b := NewID(), NewID()
+	if len(a) != 32 {
+		t.Errorf("NewID() length = %d, want 32", len(a))
+	}
+	if a == b {
+		t.Error("NewID() returned the same ID twice")
+	}
+}
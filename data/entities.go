@@ -0,0 +1,210 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/errs"
+)
+
+// Store is the CRUD interface of entity repositories, as generated by hatgen
+type Store[T any] interface {
+	GetByID(ctx context.Context, id string) (*T, error)
+	List(ctx context.Context, list query.List) (query.Page[T], error)
+	Create(ctx context.Context, entity *T) error
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Entity describes an entity type for generic tooling such as the admin UI.
+// Types are registered with RegisterEntity, by the repository or, when the
+// rules live in the service's input, by the service:
+//
+//	func init() {
+//		data.RegisterEntity(data.Entity[data.MyEntity]{Kind: "MyEntity", Store: data.NewCachedMyEntityRepository(), Spec: data.MyEntityList, Search: "name", Validate: validateMyEntity})
+//	}
+type Entity[T any] struct {
+	// Kind is the Datastore kind, also used as the entity's name
+	Kind  string
+	Store Store[T]
+	// Spec whitelists list filters and sorting, usually the entity's query.Spec
+	Spec query.Spec
+	// Search is the sortable string field of Spec matched by prefix when
+	// searching; empty disables search
+	Search string
+	// Validate checks an entity before Create and Update, returning an errs
+	// validation error. When nil, entities with a Validate() error method
+	// are checked with it.
+	Validate func(ctx context.Context, entity *T) error
+}
+
+// ReservedID is the entity ID taken by the create form of the admin UI,
+// /app/admin/<kind>/new; Create rejects it
+const ReservedID = "new"
+
+// reservedNames are taken by static admin routes such as /app/admin/audit
+var reservedNames = []string{"audit"}
+
+// EntityType is a registered Entity with its Go type erased. Values are *T.
+type EntityType interface {
+	Name() string
+	// Type is the entity's struct type
+	Type() reflect.Type
+	ListSpec() query.Spec
+	SearchField() string
+	New() any
+	Get(ctx context.Context, id string) (any, error)
+	// List returns one page of entities and the cursor of the next page
+	List(ctx context.Context, list query.List) ([]any, string, error)
+	Create(ctx context.Context, entity any) error
+	Update(ctx context.Context, entity any) error
+	Delete(ctx context.Context, id string) error
+}
+
+func (e Entity[T]) Name() string {
+	return e.Kind
+}
+
+func (e Entity[T]) Type() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (e Entity[T]) ListSpec() query.Spec {
+	return e.Spec
+}
+
+func (e Entity[T]) SearchField() string {
+	return e.Search
+}
+
+func (e Entity[T]) New() any {
+	return new(T)
+}
+
+func (e Entity[T]) Get(ctx context.Context, id string) (any, error) {
+	entity, err := e.Store.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (e Entity[T]) List(ctx context.Context, list query.List) ([]any, string, error) {
+	page, err := e.Store.List(ctx, list)
+	if err != nil {
+		return nil, "", err
+	}
+	items := make([]any, len(page.Items))
+	for i := range page.Items {
+		items[i] = &page.Items[i]
+	}
+	return items, page.NextCursor, nil
+}
+
+func (e Entity[T]) Create(ctx context.Context, entity any) error {
+	typed, err := e.cast(entity)
+	if err != nil {
+		return err
+	}
+	if entityID(typed) == ReservedID {
+		return errs.Validation("invalid "+e.Kind, errs.FieldError{Field: "id", Message: fmt.Sprintf("%q is reserved", ReservedID)})
+	}
+	if err := e.validate(ctx, typed); err != nil {
+		return err
+	}
+	return e.Store.Create(ctx, typed)
+}
+
+func (e Entity[T]) Update(ctx context.Context, entity any) error {
+	typed, err := e.cast(entity)
+	if err != nil {
+		return err
+	}
+	if err := e.validate(ctx, typed); err != nil {
+		return err
+	}
+	return e.Store.Update(ctx, typed)
+}
+
+func (e Entity[T]) Delete(ctx context.Context, id string) error {
+	return e.Store.Delete(ctx, id)
+}
+
+func (e Entity[T]) validate(ctx context.Context, entity *T) error {
+	if e.Validate != nil {
+		return e.Validate(ctx, entity)
+	}
+	if v, ok := any(entity).(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func (e Entity[T]) cast(entity any) (*T, error) {
+	typed, ok := entity.(*T)
+	if !ok {
+		return nil, fmt.Errorf("%s: got %T, want %s", e.Kind, entity, reflect.TypeOf(typed))
+	}
+	return typed, nil
+}
+
+// EntityRegistry holds the registered entity types by name
+type EntityRegistry struct {
+	mu       sync.RWMutex
+	entities map[string]EntityType
+}
+
+// NewEntityRegistry creates an empty registry
+func NewEntityRegistry() *EntityRegistry {
+	return &EntityRegistry{entities: make(map[string]EntityType)}
+}
+
+var defaultEntities = NewEntityRegistry()
+
+// DefaultEntityRegistry returns the registry used by RegisterEntity and the admin UI
+func DefaultEntityRegistry() *EntityRegistry {
+	return defaultEntities
+}
+
+// RegisterEntity adds an entity type to the default registry
+func RegisterEntity(t EntityType) {
+	defaultEntities.Register(t)
+}
+
+// Register adds t, replacing a type registered under the same name. It
+// panics when the name is empty or taken by an admin route.
+func (r *EntityRegistry) Register(t EntityType) {
+	if t.Name() == "" || slices.Contains(reservedNames, t.Name()) {
+		panic(fmt.Sprintf("data: entity name %q is reserved", t.Name()))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entities[t.Name()] = t
+}
+
+// Lookup returns the entity type registered under name
+func (r *EntityRegistry) Lookup(name string) (EntityType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.entities[name]
+	return t, ok
+}
+
+// All returns the registered entity types sorted by name
+func (r *EntityRegistry) All() []EntityType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]EntityType, 0, len(r.entities))
+	for _, t := range r.entities {
+		all = append(all, t)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name() < all[j].Name()
+	})
+	return all
+}
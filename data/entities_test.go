@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/errs"
+)
+
+type testWidget struct {
+	ID   string
+	Name string
+}
+
+// memoryStore is an in-memory Store for tests
+type memoryStore struct {
+	items map[string]testWidget
+}
+
+func (s *memoryStore) GetByID(ctx context.Context, id string) (*testWidget, error) {
+	w := s.items[id]
+	return &w, nil
+}
+
+func (s *memoryStore) List(ctx context.Context, list query.List) (query.Page[testWidget], error) {
+	page := query.Page[testWidget]{NextCursor: "next"}
+	for _, w := range s.items {
+		page.Items = append(page.Items, w)
+	}
+	return page, nil
+}
+
+func (s *memoryStore) Create(ctx context.Context, w *testWidget) error {
+	s.items[w.ID] = *w
+	return nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, w *testWidget) error {
+	s.items[w.ID] = *w
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id string) error {
+	delete(s.items, id)
+	return nil
+}
+
+func TestEntity(t *testing.T) {
+	store := &memoryStore{items: map[string]testWidget{}}
+	var entity EntityType = Entity[testWidget]{Kind: "Widget", Store: store}
+	ctx := context.Background()
+
+	if entity.Type() != reflect.TypeOf(testWidget{}) {
+		t.Errorf("Type() = %v, want testWidget", entity.Type())
+	}
+
+	created := entity.New().(*testWidget)
+	created.ID = "w1"
+	created.Name = "Sprocket"
+	if err := entity.Create(ctx, created); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := entity.Create(ctx, &struct{}{}); err == nil {
+		t.Error("Create() with the wrong type should fail")
+	}
+
+	got, err := entity.Get(ctx, "w1")
+	if err != nil || got.(*testWidget).Name != "Sprocket" {
+		t.Errorf("Get() = %v, %v", got, err)
+	}
+
+	items, next, err := entity.List(ctx, query.List{})
+	if err != nil || len(items) != 1 || next != "next" {
+		t.Errorf("List() = %v, %q, %v", items, next, err)
+	}
+
+	if err := entity.Delete(ctx, "w1"); err != nil || len(store.items) != 0 {
+		t.Errorf("Delete() error = %v, items = %v", err, store.items)
+	}
+}
+
+func TestEntityRegistry(t *testing.T) {
+	r := NewEntityRegistry()
+	r.Register(Entity[testWidget]{Kind: "Widget"})
+	r.Register(Entity[testWidget]{Kind: "Gadget"})
+
+	if _, ok := r.Lookup("Widget"); !ok {
+		t.Error("Lookup(Widget) not found")
+	}
+	if _, ok := r.Lookup("Unknown"); ok {
+		t.Error("Lookup(Unknown) should not be found")
+	}
+	all := r.All()
+	if len(all) != 2 || all[0].Name() != "Gadget" || all[1].Name() != "Widget" {
+		t.Errorf("All() = %v, want Gadget, Widget", all)
+	}
+
+	for _, name := range []string{"", "audit"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) should panic", name)
+				}
+			}()
+			r.Register(Entity[testWidget]{Kind: name})
+		}()
+	}
+}
+
+// checkedWidget validates itself
+type checkedWidget struct {
+	ID   string
+	Name string
+}
+
+func (w *checkedWidget) Validate() error {
+	if w.Name == "" {
+		return errs.Validation("invalid widget", errs.FieldError{Field: "name", Message: "is required"})
+	}
+	return nil
+}
+
+func TestEntity_Validate(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{items: map[string]testWidget{}}
+	var entity EntityType = Entity[testWidget]{Kind: "Widget", Store: store, Validate: func(ctx context.Context, w *testWidget) error {
+		if w.Name == "" {
+			return errs.Validation("invalid widget", errs.FieldError{Field: "name", Message: "is required"})
+		}
+		return nil
+	}}
+
+	if err := entity.Create(ctx, &testWidget{ID: "w1"}); !errs.Is(err, errs.KindValidation) {
+		t.Errorf("Create() without name error = %v, want validation error", err)
+	}
+	if err := entity.Create(ctx, &testWidget{ID: ReservedID, Name: "Sprocket"}); !errs.Is(err, errs.KindValidation) {
+		t.Errorf("Create() with reserved id error = %v, want validation error", err)
+	}
+	if err := entity.Create(ctx, &testWidget{ID: "w1", Name: "Sprocket"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := entity.Update(ctx, &testWidget{ID: "w1"}); !errs.Is(err, errs.KindValidation) {
+		t.Errorf("Update() without name error = %v, want validation error", err)
+	}
+	if store.items["w1"].Name != "Sprocket" {
+		t.Errorf("invalid writes reached the store: %v", store.items)
+	}
+
+	var checked EntityType = Entity[checkedWidget]{Kind: "Checked"}
+	if err := checked.Update(ctx, &checkedWidget{ID: "c1"}); !errs.Is(err, errs.KindValidation) {
+		t.Errorf("Update() error = %v, want the entity's own validation error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/errs"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Variants of boolean flags
+const (
+	FlagOn  = "on"
+	FlagOff = "off"
+)
+
+// flagKeyPattern is the format of flag keys, e.g. "new-dashboard"
+var flagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Flag is a feature flag stored under the "Flag" kind. A flag serves one of
+// its variants to each user: the variant of the first matching rule, or
+// DefaultVariant.
+type Flag struct {
+	ID string `json:"id"`
+	// Key is the unique name code refers to, e.g. "new-dashboard"
+	Key         string `json:"key"`
+	Description string `json:"description" datastore:",noindex"`
+	// Enabled turns the rules on; disabled flags serve DefaultVariant to everyone
+	Enabled bool `json:"enabled"`
+	// Variants lists the comma-separated variants of a multivariate flag;
+	// empty for boolean flags, whose variants are "on" and "off"
+	Variants string `json:"variants" datastore:",noindex"`
+	// DefaultVariant is served when the flag is disabled or no rule
+	// matches, "off" when empty
+	DefaultVariant string `json:"default_variant" datastore:",noindex"`
+	// Rules is a JSON array of FlagRule, evaluated in order
+	Rules     string    `json:"rules" datastore:",noindex"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FlagRule serves Variant to the users meeting all of its conditions. Each
+// list condition matches when the user matches any entry; empty lists are
+// no condition.
+type FlagRule struct {
+	UserIDs []string `json:"user_ids,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+	// EmailDomains match the domain of the user's email, e.g. "example.com"
+	EmailDomains []string `json:"email_domains,omitempty"`
+	// Percent limits the rule to a share of users, 0 to 100, picked by a
+	// stable hash of flag key and user ID; nil means every user. All rules
+	// of a flag hash a user to the same bucket, so a rule of 20 followed by
+	// one of 50 splits users 20/30.
+	Percent *float64 `json:"percent,omitempty"`
+	Variant string   `json:"variant"`
+}
+
+// SetKey copies the key name into ID when loaded by query.GetPage
+func (e *Flag) SetKey(key *datastore.Key) {
+	e.ID = key.Name
+}
+
+// VariantList returns the flag's variants, "on" and "off" for boolean flags
+func (e *Flag) VariantList() []string {
+	var variants []string
+	for _, v := range strings.Split(e.Variants, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			variants = append(variants, v)
+		}
+	}
+	if len(variants) == 0 {
+		return []string{FlagOn, FlagOff}
+	}
+	return variants
+}
+
+// Default returns DefaultVariant, "off" when empty
+func (e *Flag) Default() string {
+	if e.DefaultVariant == "" {
+		return FlagOff
+	}
+	return e.DefaultVariant
+}
+
+// ParseRules decodes Rules
+func (e *Flag) ParseRules() ([]FlagRule, error) {
+	if strings.TrimSpace(e.Rules) == "" {
+		return nil, nil
+	}
+	var rules []FlagRule
+	if err := json.Unmarshal([]byte(e.Rules), &rules); err != nil {
+		return nil, fmt.Errorf("rules are not a JSON array of rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Validate checks the key, the variants and the rules, reporting problems
+// per field
+func (e *Flag) Validate() error {
+	var problems []errs.FieldError
+	if !flagKeyPattern.MatchString(e.Key) {
+		problems = append(problems, errs.FieldError{Field: "key", Message: "must be lowercase letters, digits, '.', '_' and '-'"})
+	}
+	variants := e.VariantList()
+	if !slices.Contains(variants, e.Default()) {
+		problems = append(problems, errs.FieldError{Field: "default_variant", Message: fmt.Sprintf("must be one of %s", strings.Join(variants, ", "))})
+	}
+	rules, err := e.ParseRules()
+	if err != nil {
+		problems = append(problems, errs.FieldError{Field: "rules", Message: err.Error()})
+	}
+	for i, rule := range rules {
+		switch {
+		case !slices.Contains(variants, rule.Variant):
+			problems = append(problems, errs.FieldError{Field: "rules", Message: fmt.Sprintf("rule %d: variant must be one of %s", i+1, strings.Join(variants, ", "))})
+		case rule.Percent != nil && (*rule.Percent < 0 || *rule.Percent > 100):
+			problems = append(problems, errs.FieldError{Field: "rules", Message: fmt.Sprintf("rule %d: percent must be between 0 and 100", i+1)})
+		}
+	}
+	if len(problems) > 0 {
+		return errs.Validation("invalid flag", problems...)
+	}
+	return nil
+}
+
+// FlagList whitelists the fields flags can be filtered and sorted by. The
+// enabled filter with each sort needs its composite index in index.yaml.
+var FlagList = query.Spec{
+	Fields: map[string]query.Field{
+		"key":        {Property: "Key", Ops: []query.Op{query.Eq}, Sortable: true},
+		"enabled":    {Property: "Enabled", Type: query.Bool, Ops: []query.Op{query.Eq}},
+		"updated_at": {Property: "UpdatedAt", Type: query.Time, Sortable: true},
+	},
+	DefaultSort: "key",
+}
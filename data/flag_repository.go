@@ -0,0 +1,126 @@
+package data
+
+import (
+	"context"
+
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/errs"
+
+	"cloud.google.com/go/datastore"
+	"github.com/rs/zerolog/log"
+)
+
+type FlagRepository struct {
+	*BaseRepository
+}
+
+func init() {
+	// flags are managed in the admin UI
+	RegisterEntity(Entity[Flag]{Kind: "Flag", Store: NewFlagRepository(), Spec: FlagList, Search: "key"})
+}
+
+func NewFlagRepository() *FlagRepository {
+	return &FlagRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetByID retrieves a flag by its ID
+func (r *FlagRepository) GetByID(ctx context.Context, id string) (*Flag, error) {
+	if id == "" {
+		return nil, datastore.ErrNoSuchEntity
+	}
+	key := datastore.NameKey("Flag", id, nil)
+	flag := &Flag{}
+	if err := r.Client().Get(ctx, key, flag); err != nil {
+		if !IsNotFound(err) {
+			log.Error().Err(err).Msgf("failed to get Flag by id: %s", id)
+		}
+		return nil, err
+	}
+	flag.ID = id
+	return flag, nil
+}
+
+// Create stores a new flag after validating it
+func (r *FlagRepository) Create(ctx context.Context, flag *Flag) error {
+	if err := r.check(ctx, flag); err != nil {
+		return err
+	}
+	key := datastore.NameKey("Flag", flag.ID, nil)
+	log.Debug().Msgf("Creating Flag with id: %s", flag.ID)
+	if _, err := r.Client().Put(ctx, key, flag); err != nil {
+		log.Error().Err(err).Msg("failed to create Flag")
+		return err
+	}
+	return nil
+}
+
+// Update replaces an existing flag after validating it
+func (r *FlagRepository) Update(ctx context.Context, flag *Flag) error {
+	if err := r.check(ctx, flag); err != nil {
+		return err
+	}
+	key := datastore.NameKey("Flag", flag.ID, nil)
+	log.Debug().Msgf("Updating Flag with id: %s", flag.ID)
+	if _, err := r.Client().Put(ctx, key, flag); err != nil {
+		log.Error().Err(err).Msg("failed to update Flag")
+		return err
+	}
+	return nil
+}
+
+// check validates flag and rejects a key used by another flag
+func (r *FlagRepository) check(ctx context.Context, flag *Flag) error {
+	if err := flag.Validate(); err != nil {
+		return err
+	}
+	q := datastore.NewQuery("Flag").FilterField("Key", "=", flag.Key).KeysOnly()
+	keys, err := r.Client().GetAll(ctx, q, nil)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to query Flag by key: %s", flag.Key)
+		return err
+	}
+	for _, key := range keys {
+		if key.Name != flag.ID {
+			return errs.Validation("invalid flag", errs.FieldError{Field: "key", Message: "is used by another flag"})
+		}
+	}
+	return nil
+}
+
+// Delete removes a flag
+func (r *FlagRepository) Delete(ctx context.Context, id string) error {
+	key := datastore.NameKey("Flag", id, nil)
+	log.Debug().Msgf("Deleting Flag with id: %s", id)
+	if err := r.Client().Delete(ctx, key); err != nil {
+		log.Error().Err(err).Msg("failed to delete Flag")
+		return err
+	}
+	return nil
+}
+
+// List retrieves one page of flags
+func (r *FlagRepository) List(ctx context.Context, list query.List) (query.Page[Flag], error) {
+	q := datastore.NewQuery("Flag")
+	page, err := query.GetPage[Flag](ctx, r.Client(), q, list)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list Flag")
+		return page, err
+	}
+	return page, nil
+}
+
+// All retrieves every flag, for the in-memory flag cache
+func (r *FlagRepository) All(ctx context.Context) ([]*Flag, error) {
+	var flags []*Flag
+	keys, err := r.Client().GetAll(ctx, datastore.NewQuery("Flag"), &flags)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load all Flags")
+		return nil, err
+	}
+	for i, key := range keys {
+		flags[i].ID = key.Name
+	}
+	return flags, nil
+}
@@ -0,0 +1,91 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	"runtime-dynamics/errs"
+)
+
+func TestFlag_VariantList(t *testing.T) {
+	tests := []struct {
+		variants string
+		want     []string
+	}{
+		{variants: "", want: []string{"on", "off"}},
+		{variants: " , ", want: []string{"on", "off"}},
+		{variants: "classic, one-page,", want: []string{"classic", "one-page"}},
+	}
+
+	for _, tt := range tests {
+		flag := &Flag{Variants: tt.variants}
+		if got := flag.VariantList(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("VariantList(%q) = %v, want %v", tt.variants, got, tt.want)
+		}
+	}
+}
+
+func TestFlag_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       Flag
+		wantFields []string
+	}{
+		{
+			name: "boolean flag",
+			flag: Flag{Key: "new-dashboard", Rules: `[{"user_ids": ["u1"], "variant": "on"}, {"percent": 12.5, "variant": "on"}]`},
+		},
+		{
+			name: "multivariate flag",
+			flag: Flag{Key: "checkout.v2", Variants: "classic,one-page", DefaultVariant: "classic", Rules: `[{"roles": ["beta"], "variant": "one-page"}]`},
+		},
+		{
+			name:       "invalid key",
+			flag:       Flag{Key: "New Dashboard"},
+			wantFields: []string{"key"},
+		},
+		{
+			name:       "default not a variant",
+			flag:       Flag{Key: "checkout", Variants: "classic,one-page"},
+			wantFields: []string{"default_variant"},
+		},
+		{
+			name:       "rules not JSON",
+			flag:       Flag{Key: "beta", Rules: `{"variant": "on"}`},
+			wantFields: []string{"rules"},
+		},
+		{
+			name:       "unknown rule variant",
+			flag:       Flag{Key: "beta", Rules: `[{"variant": "yes"}]`},
+			wantFields: []string{"rules"},
+		},
+		{
+			name:       "percent out of range",
+			flag:       Flag{Key: "beta", Rules: `[{"percent": 120, "variant": "on"}]`},
+			wantFields: []string{"rules"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flag.Validate()
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			typed, ok := errs.As(err)
+			if !ok || typed.Kind != errs.KindValidation {
+				t.Fatalf("Validate() error = %v, want validation error", err)
+			}
+			var fields []string
+			for _, f := range typed.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("Validate() fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
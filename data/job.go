@@ -0,0 +1,56 @@
+package data
+
+import (
+	"time"
+
+	"runtime-dynamics/data/query"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Job states
+const (
+	// JobQueued jobs run once RunAt has passed
+	JobQueued = "queued"
+	// JobRunning jobs are claimed by a worker until RunAt, when the claim
+	// expires and another worker may take over
+	JobRunning = "running"
+	// JobSucceeded jobs are done
+	JobSucceeded = "succeeded"
+	// JobDead jobs failed permanently or ran out of attempts: the dead letters
+	JobDead = "dead"
+)
+
+// Job is a unit of background work stored under the "Job" kind
+type Job struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Payload is the JSON encoded job argument
+	Payload     string    `json:"payload" datastore:",noindex"`
+	State       string    `json:"state"`
+	Attempts    int64     `json:"attempts"`
+	MaxAttempts int64     `json:"max_attempts"`
+	RunAt       time.Time `json:"run_at"`
+	LastError   string    `json:"last_error" datastore:",noindex"`
+	// ExpireAt lets a Datastore TTL policy delete succeeded jobs
+	ExpireAt  time.Time `json:"expire_at" datastore:",noindex"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetKey copies the key name into ID when loaded by query.GetPage
+func (e *Job) SetKey(key *datastore.Key) {
+	e.ID = key.Name
+}
+
+// JobList whitelists the fields jobs can be filtered and sorted by
+var JobList = query.Spec{
+	Fields: map[string]query.Field{
+		"type":       {Property: "Type", Ops: []query.Op{query.Eq}, Sortable: true},
+		"state":      {Property: "State", Ops: []query.Op{query.Eq, query.In}},
+		"run_at":     {Property: "RunAt", Type: query.Time, Ops: []query.Op{query.Gte, query.Lt}, Sortable: true},
+		"created_at": {Property: "CreatedAt", Type: query.Time, Ops: []query.Op{query.Gte, query.Lt}, Sortable: true},
+		"updated_at": {Property: "UpdatedAt", Type: query.Time, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
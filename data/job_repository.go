@@ -0,0 +1,140 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"runtime-dynamics/data/query"
+
+	"cloud.google.com/go/datastore"
+	"github.com/rs/zerolog/log"
+)
+
+type JobRepository struct {
+	*BaseRepository
+}
+
+func init() {
+	// list jobs in the admin UI, where dead jobs can be inspected and requeued
+	RegisterEntity(Entity[Job]{Kind: "Job", Store: NewJobRepository(), Spec: JobList, Search: "type"})
+}
+
+func NewJobRepository() *JobRepository {
+	return &JobRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetByID retrieves a job by its ID
+func (r *JobRepository) GetByID(ctx context.Context, id string) (*Job, error) {
+	if id == "" {
+		return nil, datastore.ErrNoSuchEntity
+	}
+	key := datastore.NameKey("Job", id, nil)
+	job := &Job{}
+	if err := r.Client().Get(ctx, key, job); err != nil {
+		if !IsNotFound(err) {
+			log.Error().Err(err).Msgf("failed to get Job by id: %s", id)
+		}
+		return nil, err
+	}
+	job.ID = id
+	return job, nil
+}
+
+// Create stores a new job
+func (r *JobRepository) Create(ctx context.Context, job *Job) error {
+	key := datastore.NameKey("Job", job.ID, nil)
+	log.Debug().Msgf("Creating Job with id: %s", job.ID)
+	if _, err := r.Client().Put(ctx, key, job); err != nil {
+		log.Error().Err(err).Msg("failed to create Job")
+		return err
+	}
+	return nil
+}
+
+// Update replaces an existing job
+func (r *JobRepository) Update(ctx context.Context, job *Job) error {
+	key := datastore.NameKey("Job", job.ID, nil)
+	log.Debug().Msgf("Updating Job with id: %s", job.ID)
+	if _, err := r.Client().Put(ctx, key, job); err != nil {
+		log.Error().Err(err).Msg("failed to update Job")
+		return err
+	}
+	return nil
+}
+
+// Delete removes a job
+func (r *JobRepository) Delete(ctx context.Context, id string) error {
+	key := datastore.NameKey("Job", id, nil)
+	log.Debug().Msgf("Deleting Job with id: %s", id)
+	if err := r.Client().Delete(ctx, key); err != nil {
+		log.Error().Err(err).Msg("failed to delete Job")
+		return err
+	}
+	return nil
+}
+
+// List retrieves one page of jobs
+func (r *JobRepository) List(ctx context.Context, list query.List) (query.Page[Job], error) {
+	q := datastore.NewQuery("Job")
+	page, err := query.GetPage[Job](ctx, r.Client(), q, list)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list Job")
+		return page, err
+	}
+	return page, nil
+}
+
+// Due returns up to limit jobs in state whose RunAt has passed, oldest
+// first. It needs the composite index on State and RunAt in index.yaml.
+func (r *JobRepository) Due(ctx context.Context, state string, now time.Time, limit int) ([]*Job, error) {
+	q := datastore.NewQuery("Job").
+		FilterField("State", "=", state).
+		FilterField("RunAt", "<=", now).
+		Order("RunAt").
+		Limit(limit)
+	var jobs []*Job
+	keys, err := r.Client().GetAll(ctx, q, &jobs)
+	if err != nil {
+		log.Error().Err(err).Str("state", state).Msg("failed to query due Jobs")
+		return nil, err
+	}
+	for i, key := range keys {
+		jobs[i].ID = key.Name
+	}
+	return jobs, nil
+}
+
+// Claim marks a due queued job, or a running job whose claim expired, as
+// running until now+lease and counts the attempt. It returns nil when the
+// job is no longer due, typically because another worker claimed it first.
+func (r *JobRepository) Claim(ctx context.Context, id string, now time.Time, lease time.Duration) (*Job, error) {
+	key := datastore.NameKey("Job", id, nil)
+	var claimed *Job
+	_, err := r.Client().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		claimed = nil
+		job := &Job{}
+		if err := tx.Get(key, job); err != nil {
+			return err
+		}
+		if (job.State != JobQueued && job.State != JobRunning) || job.RunAt.After(now) {
+			return nil
+		}
+		job.ID = id
+		job.State = JobRunning
+		job.Attempts++
+		job.RunAt = now.Add(lease)
+		job.UpdatedAt = now
+		if _, err := tx.Put(key, job); err != nil {
+			return err
+		}
+		claimed = job
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to claim Job: %s", id)
+		return nil, err
+	}
+	return claimed, nil
+}
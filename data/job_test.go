@@ -0,0 +1,54 @@
+package data
+
+import (
+	"net/url"
+	"testing"
+
+	"runtime-dynamics/data/query"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestJob_SetKey(t *testing.T) {
+	job := &Job{}
+	job.SetKey(datastore.NameKey("Job", "abc", nil))
+	if job.ID != "abc" {
+		t.Errorf("SetKey() ID = %v, want %v", job.ID, "abc")
+	}
+}
+
+func TestJobList(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{
+			name:  "default sort",
+			query: "",
+		},
+		{
+			name:  "dead letters",
+			query: "filter[state]=dead",
+		},
+		{
+			name:  "due by run time",
+			query: "filter[run_at][lt]=2026-01-01T00:00:00Z&sort=run_at",
+		},
+		{
+			name:    "payload is not indexed",
+			query:   "filter[payload]=x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			_, err := query.Parse(values, JobList)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package data
+
+import "time"
+
+// Lease records who claimed the latest run of a scheduled task, stored
+// under the "Lease" kind with the task name as key
+type Lease struct {
+	Name string `json:"name" datastore:"-"`
+	// Holder identifies the instance that claimed the run
+	Holder string `json:"holder"`
+	// Run is the scheduled time of the claimed run
+	Run time.Time `json:"run"`
+	// ExpiresAt is when the holder's claim on the run ends
+	ExpiresAt time.Time `json:"expires_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
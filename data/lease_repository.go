@@ -0,0 +1,65 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/rs/zerolog/log"
+)
+
+type LeaseRepository struct {
+	*BaseRepository
+}
+
+func NewLeaseRepository() *LeaseRepository {
+	return &LeaseRepository{
+		BaseRepository: NewBaseRepository(),
+	}
+}
+
+// GetByName retrieves the lease of a scheduled task
+func (r *LeaseRepository) GetByName(ctx context.Context, name string) (*Lease, error) {
+	key := datastore.NameKey("Lease", name, nil)
+	lease := &Lease{}
+	if err := r.Client().Get(ctx, key, lease); err != nil {
+		if !IsNotFound(err) {
+			log.Error().Err(err).Msgf("failed to get Lease by name: %s", name)
+		}
+		return nil, err
+	}
+	lease.Name = name
+	return lease, nil
+}
+
+// ClaimRun claims the run of task name scheduled at run for holder until
+// expiresAt. It reports false when that run, or a later one, was already
+// claimed, so each run is claimed by exactly one instance.
+func (r *LeaseRepository) ClaimRun(ctx context.Context, name string, holder string, run time.Time, expiresAt time.Time) (bool, error) {
+	key := datastore.NameKey("Lease", name, nil)
+	claimed := false
+	_, err := r.Client().RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		claimed = false
+		lease := &Lease{}
+		if err := tx.Get(key, lease); err != nil && !IsNotFound(err) {
+			return err
+		}
+		if !lease.Run.Before(run) {
+			return nil
+		}
+		lease.Holder = holder
+		lease.Run = run
+		lease.ExpiresAt = expiresAt
+		lease.UpdatedAt = time.Now()
+		if _, err := tx.Put(key, lease); err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to claim Lease: %s", name)
+		return false, err
+	}
+	return claimed, nil
+}
@@ -0,0 +1,108 @@
+package data
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"runtime-dynamics/metrics"
+	"runtime-dynamics/tracing"
+
+	"cloud.google.com/go/datastore/apiv1/datastorepb"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// clientOptions instrument every Datastore call of every repository, so
+// repositories need no metrics or tracing code of their own
+func clientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(observeCall)),
+	}
+}
+
+// observeCall traces a Datastore RPC and records its operation, entity
+// kind, result and latency
+func observeCall(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	operation, kind := operationName(method), requestKind(req)
+	ctx, span := tracing.Start(ctx, "datastore."+operation,
+		attribute.String("db.system", "datastore"),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.collection.name", kind),
+	)
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	metrics.ObserveDataOperation(operation, kind, err, time.Since(start))
+	tracing.End(span, &err)
+	return err
+}
+
+// operationName turns /google.datastore.v1.Datastore/RunQuery into run_query
+func operationName(method string) string {
+	var b strings.Builder
+	for i, r := range path.Base(method) {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// requestKind returns the entity kind a request reads or writes, "mixed"
+// when it touches several and "" when it names none (transactions, GQL)
+func requestKind(req any) string {
+	var keys []*datastorepb.Key
+	switch req := req.(type) {
+	case *datastorepb.LookupRequest:
+		keys = req.GetKeys()
+	case *datastorepb.RunQueryRequest:
+		return queryKind(req.GetQuery())
+	case *datastorepb.RunAggregationQueryRequest:
+		return queryKind(req.GetAggregationQuery().GetNestedQuery())
+	case *datastorepb.CommitRequest:
+		for _, m := range req.GetMutations() {
+			switch {
+			case m.GetInsert() != nil:
+				keys = append(keys, m.GetInsert().GetKey())
+			case m.GetUpdate() != nil:
+				keys = append(keys, m.GetUpdate().GetKey())
+			case m.GetUpsert() != nil:
+				keys = append(keys, m.GetUpsert().GetKey())
+			case m.GetDelete() != nil:
+				keys = append(keys, m.GetDelete())
+			}
+		}
+	case *datastorepb.AllocateIdsRequest:
+		keys = req.GetKeys()
+	case *datastorepb.ReserveIdsRequest:
+		keys = req.GetKeys()
+	}
+
+	kind := ""
+	for _, key := range keys {
+		elements := key.GetPath()
+		if len(elements) == 0 {
+			continue
+		}
+		k := elements[len(elements)-1].GetKind()
+		if kind != "" && k != kind {
+			return "mixed"
+		}
+		kind = k
+	}
+	return kind
+}
+
+func queryKind(q *datastorepb.Query) string {
+	if kinds := q.GetKind(); len(kinds) > 0 {
+		return kinds[0].GetName()
+	}
+	return ""
+}
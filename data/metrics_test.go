@@ -0,0 +1,80 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"runtime-dynamics/metrics"
+
+	"cloud.google.com/go/datastore/apiv1/datastorepb"
+	"google.golang.org/grpc"
+)
+
+func pbKey(kinds ...string) *datastorepb.Key {
+	key := &datastorepb.Key{}
+	for _, kind := range kinds {
+		key.Path = append(key.Path, &datastorepb.Key_PathElement{Kind: kind})
+	}
+	return key
+}
+
+func TestOperationName(t *testing.T) {
+	tests := map[string]string{
+		"/google.datastore.v1.Datastore/Lookup":           "lookup",
+		"/google.datastore.v1.Datastore/RunQuery":         "run_query",
+		"/google.datastore.v1.Datastore/BeginTransaction": "begin_transaction",
+	}
+	for method, want := range tests {
+		if got := operationName(method); got != want {
+			t.Errorf("operationName(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestRequestKind(t *testing.T) {
+	tests := []struct {
+		name string
+		req  any
+		want string
+	}{
+		{name: "lookup", req: &datastorepb.LookupRequest{Keys: []*datastorepb.Key{pbKey("Job")}}, want: "Job"},
+		{name: "child key", req: &datastorepb.LookupRequest{Keys: []*datastorepb.Key{pbKey("User", "Note")}}, want: "Note"},
+		{name: "query", req: &datastorepb.RunQueryRequest{QueryType: &datastorepb.RunQueryRequest_Query{Query: &datastorepb.Query{Kind: []*datastorepb.KindExpression{{Name: "Lease"}}}}}, want: "Lease"},
+		{name: "commit", req: &datastorepb.CommitRequest{Mutations: []*datastorepb.Mutation{
+			{Operation: &datastorepb.Mutation_Upsert{Upsert: &datastorepb.Entity{Key: pbKey("Job")}}},
+			{Operation: &datastorepb.Mutation_Delete{Delete: pbKey("Job")}},
+		}}, want: "Job"},
+		{name: "mixed commit", req: &datastorepb.CommitRequest{Mutations: []*datastorepb.Mutation{
+			{Operation: &datastorepb.Mutation_Upsert{Upsert: &datastorepb.Entity{Key: pbKey("Job")}}},
+			{Operation: &datastorepb.Mutation_Delete{Delete: pbKey("Lease")}},
+		}}, want: "mixed"},
+		{name: "transaction", req: &datastorepb.BeginTransactionRequest{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestKind(tt.req); got != tt.want {
+				t.Errorf("requestKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveCall(t *testing.T) {
+	failing := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return errors.New("unavailable")
+	}
+	req := &datastorepb.LookupRequest{Keys: []*datastorepb.Key{pbKey("MetricsTest")}}
+	if err := observeCall(context.Background(), "/google.datastore.v1.Datastore/Lookup", req, nil, nil, failing); err == nil {
+		t.Error("observeCall() should return the invoker error")
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	want := `datastore_operations_total{kind="MetricsTest",operation="lookup",result="error"} 1`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("metrics output is missing %s", want)
+	}
+}
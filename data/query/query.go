@@ -0,0 +1,371 @@
+// Package query parses list requests (?limit=&cursor=&sort=&filter[field][op]=)
+// against a per-entity whitelist and runs them as paginated Datastore queries.
+package query
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"runtime-dynamics/errs"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// DefaultLimit is the page size when neither the request nor the Spec sets one
+	DefaultLimit = 20
+	// MaxLimit is the largest page size when the Spec does not set one
+	MaxLimit = 100
+)
+
+// Op is a filter operator as written in query strings
+type Op string
+
+const (
+	Eq  Op = "eq"
+	Ne  Op = "ne"
+	Gt  Op = "gt"
+	Gte Op = "gte"
+	Lt  Op = "lt"
+	Lte Op = "lte"
+	In  Op = "in"
+	Nin Op = "nin"
+)
+
+// operators maps query string operators to Datastore FilterField operators
+var operators = map[Op]string{
+	Eq:  "=",
+	Ne:  "!=",
+	Gt:  ">",
+	Gte: ">=",
+	Lt:  "<",
+	Lte: "<=",
+	In:  "in",
+	Nin: "not-in",
+}
+
+// Type is the type filter values are converted to
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Float
+	Bool
+	Time
+)
+
+// Field whitelists one client-visible field of an entity
+type Field struct {
+	// Property is the Datastore property name, e.g. "CreatedAt"
+	Property string
+	Type     Type
+	// Ops lists the allowed filter operators; none means the field cannot be filtered
+	Ops []Op
+	// Sortable allows ordering by the field
+	Sortable bool
+}
+
+// Spec whitelists the fields clients may filter and sort an entity's list by,
+// keyed by the name clients use (usually the json tag):
+//
+//	var MyEntityList = query.Spec{
+//		Fields: map[string]query.Field{
+//			"status":     {Property: "Status", Ops: []query.Op{query.Eq, query.In}},
+//			"created_at": {Property: "CreatedAt", Type: query.Time, Ops: []query.Op{query.Gte, query.Lt}, Sortable: true},
+//		},
+//		DefaultSort: "-created_at",
+//	}
+type Spec struct {
+	Fields map[string]Field
+	// DefaultSort is used when the request has no sort, e.g. "-created_at"
+	DefaultSort  string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Order sorts by one field
+type Order struct {
+	Field    string
+	Property string
+	Desc     bool
+}
+
+// Filter restricts results by one field
+type Filter struct {
+	Field    string
+	Property string
+	Op       Op
+	Value    interface{}
+}
+
+// List is a parsed and validated list request
+type List struct {
+	Limit   int
+	Cursor  string
+	Sort    []Order
+	Filters []Filter
+}
+
+// Params documents the plain list parameters, e.g. as the Request of an
+// OpenAPI operation. Filters use filter[field][op]=value and are listed in
+// the operation description.
+type Params struct {
+	Limit  int    `form:"limit" doc:"Page size"`
+	Cursor string `form:"cursor" doc:"next_cursor of the previous page"`
+	Sort   string `form:"sort" doc:"Comma-separated fields, prefix with - for descending"`
+}
+
+// Page is one page of results. It is the JSON envelope of list endpoints:
+// pass it to renderFinalContent with an empty key.
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// NextCursor fetches the following page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Parse reads limit, cursor, sort and filter[field][op] values. Unknown
+// fields, operators that are not whitelisted and malformed values are
+// reported together as an errs validation error.
+func Parse(values url.Values, spec Spec) (List, error) {
+	var list List
+	var problems []errs.FieldError
+
+	list.Limit = spec.DefaultLimit
+	if list.Limit <= 0 {
+		list.Limit = DefaultLimit
+	}
+	maxLimit := spec.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = MaxLimit
+	}
+	if raw := values.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || n < 1:
+			problems = append(problems, errs.FieldError{Field: "limit", Message: "must be a positive number"})
+		case n > maxLimit:
+			problems = append(problems, errs.FieldError{Field: "limit", Message: fmt.Sprintf("must be at most %d", maxLimit)})
+		default:
+			list.Limit = n
+		}
+	}
+
+	if list.Cursor = values.Get("cursor"); list.Cursor != "" {
+		if _, err := datastore.DecodeCursor(list.Cursor); err != nil {
+			problems = append(problems, errs.FieldError{Field: "cursor", Message: "is invalid"})
+		}
+	}
+
+	sortValue := values.Get("sort")
+	if sortValue == "" {
+		sortValue = spec.DefaultSort
+	}
+	for _, item := range strings.Split(sortValue, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name := strings.TrimPrefix(item, "-")
+		field, ok := spec.Fields[name]
+		if !ok || !field.Sortable {
+			problems = append(problems, errs.FieldError{Field: "sort", Message: fmt.Sprintf("cannot sort by %q", name)})
+			continue
+		}
+		list.Sort = append(list.Sort, Order{Field: name, Property: field.Property, Desc: strings.HasPrefix(item, "-")})
+	}
+
+	// sort keys so filters and errors come out in a stable order
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		filter, problem := parseFilter(key, values.Get(key), spec)
+		if problem != nil {
+			problems = append(problems, *problem)
+			continue
+		}
+		list.Filters = append(list.Filters, filter)
+	}
+
+	if len(problems) > 0 {
+		return list, errs.Validation("invalid list query", problems...)
+	}
+	return list, nil
+}
+
+// parseFilter parses filter[field][op]=value; filter[field]=value means eq
+func parseFilter(key string, raw string, spec Spec) (Filter, *errs.FieldError) {
+	name, op, ok := splitFilterKey(key)
+	if !ok {
+		return Filter{}, &errs.FieldError{Field: key, Message: "must look like filter[field][op]"}
+	}
+	field, known := spec.Fields[name]
+	if !known || len(field.Ops) == 0 {
+		return Filter{}, &errs.FieldError{Field: key, Message: fmt.Sprintf("cannot filter by %q", name)}
+	}
+	if !allowed(field.Ops, op) {
+		return Filter{}, &errs.FieldError{Field: key, Message: fmt.Sprintf("operator %q is not allowed", op)}
+	}
+
+	var value interface{}
+	if op == In || op == Nin {
+		var items []interface{}
+		for _, item := range strings.Split(raw, ",") {
+			v, err := convert(strings.TrimSpace(item), field.Type)
+			if err != nil {
+				return Filter{}, &errs.FieldError{Field: key, Message: err.Error()}
+			}
+			items = append(items, v)
+		}
+		value = items
+	} else {
+		v, err := convert(raw, field.Type)
+		if err != nil {
+			return Filter{}, &errs.FieldError{Field: key, Message: err.Error()}
+		}
+		value = v
+	}
+	return Filter{Field: name, Property: field.Property, Op: op, Value: value}, nil
+}
+
+// splitFilterKey splits "filter[status][in]" into "status" and "in"
+func splitFilterKey(key string) (string, Op, bool) {
+	rest := strings.TrimPrefix(key, "filter[")
+	name, rest, ok := strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return name, Eq, true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+		return "", "", false
+	}
+	op := Op(rest[1 : len(rest)-1])
+	if _, known := operators[op]; !known {
+		return "", "", false
+	}
+	return name, op, true
+}
+
+func allowed(ops []Op, op Op) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// convert parses a filter value as t
+func convert(raw string, t Type) (interface{}, error) {
+	switch t {
+	case Int:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a whole number")
+		}
+		return n, nil
+	case Float:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return n, nil
+	case Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	case Time:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse("2006-01-02", raw); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("must be a date (2006-01-02) or RFC 3339 time")
+	default:
+		return raw, nil
+	}
+}
+
+// Apply adds the list's filters, sort orders and cursor to q. The limit is
+// applied by GetPage.
+func (l List) Apply(q *datastore.Query) (*datastore.Query, error) {
+	for _, f := range l.Filters {
+		q = q.FilterField(f.Property, operators[f.Op], f.Value)
+	}
+	for _, o := range l.Sort {
+		if o.Desc {
+			q = q.Order("-" + o.Property)
+		} else {
+			q = q.Order(o.Property)
+		}
+	}
+	if l.Cursor != "" {
+		cursor, err := datastore.DecodeCursor(l.Cursor)
+		if err != nil {
+			return nil, errs.Validation("invalid list query", errs.FieldError{Field: "cursor", Message: "is invalid"}).WithCause(err)
+		}
+		q = q.Start(cursor)
+	}
+	return q, nil
+}
+
+// KeySetter is implemented by entities that copy their key into a field,
+// typically ID, when loaded by GetPage
+type KeySetter interface {
+	SetKey(key *datastore.Key)
+}
+
+// GetPage runs q with the list applied and returns one page. q holds the
+// filters the caller always applies, e.g. the owner of the entities.
+func GetPage[T any](ctx context.Context, client *datastore.Client, q *datastore.Query, list List) (Page[T], error) {
+	page := Page[T]{Items: []T{}}
+	q, err := list.Apply(q)
+	if err != nil {
+		return page, err
+	}
+	limit := list.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	// fetch one extra entity to learn whether another page exists
+	it := client.Run(ctx, q.Limit(limit+1))
+	var cursor datastore.Cursor
+	for {
+		var item T
+		key, err := it.Next(&item)
+		if err == iterator.Done {
+			return page, nil
+		}
+		if err != nil {
+			return page, err
+		}
+		if len(page.Items) == limit {
+			page.NextCursor = cursor.String()
+			return page, nil
+		}
+		if setter, ok := any(&item).(KeySetter); ok {
+			setter.SetKey(key)
+		}
+		page.Items = append(page.Items, item)
+		if cursor, err = it.Cursor(); err != nil {
+			return page, err
+		}
+	}
+}
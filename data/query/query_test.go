@@ -0,0 +1,129 @@
+package query
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"runtime-dynamics/errs"
+
+	"cloud.google.com/go/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+var itemList = Spec{
+	Fields: map[string]Field{
+		"status":     {Property: "Status", Ops: []Op{Eq, Ne, In, Nin}},
+		"score":      {Property: "Score", Type: Int, Ops: []Op{Gte, Lt}, Sortable: true},
+		"created_at": {Property: "CreatedAt", Type: Time, Ops: []Op{Gte, Lt}, Sortable: true},
+		"archived":   {Property: "Archived", Type: Bool, Ops: []Op{Eq}},
+		"name":       {Property: "Name", Sortable: true},
+	},
+	DefaultSort: "-created_at",
+	MaxLimit:    50,
+}
+
+func fieldErrors(err error) []errs.FieldError {
+	e, ok := errs.As(err)
+	if !ok {
+		return nil
+	}
+	return e.Fields
+}
+
+func TestParse(t *testing.T) {
+	cursor := datastore.Cursor{}.String()
+
+	tests := []struct {
+		name           string
+		query          string
+		expected       List
+		expectedFields []errs.FieldError
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			expected: List{
+				Limit: DefaultLimit,
+				Sort:  []Order{{Field: "created_at", Property: "CreatedAt", Desc: true}},
+			},
+		},
+		{
+			name:  "limit cursor and sort",
+			query: "limit=10&cursor=" + cursor + "&sort=name,-score",
+			expected: List{
+				Limit:  10,
+				Cursor: cursor,
+				Sort: []Order{
+					{Field: "name", Property: "Name"},
+					{Field: "score", Property: "Score", Desc: true},
+				},
+			},
+		},
+		{
+			name:  "filters",
+			query: "sort=name&filter[status][in]=active,pending&filter[score][gte]=10&filter[archived]=false&filter[created_at][lt]=2025-02-01",
+			expected: List{
+				Limit: DefaultLimit,
+				Sort:  []Order{{Field: "name", Property: "Name"}},
+				Filters: []Filter{
+					{Field: "archived", Property: "Archived", Op: Eq, Value: false},
+					{Field: "created_at", Property: "CreatedAt", Op: Lt, Value: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+					{Field: "score", Property: "Score", Op: Gte, Value: int64(10)},
+					{Field: "status", Property: "Status", Op: In, Value: []interface{}{"active", "pending"}},
+				},
+			},
+		},
+		{
+			name:  "invalid values",
+			query: "limit=500&cursor=%21%21&sort=status&filter[name][eq]=x&filter[score][eq]=1&filter[score][gte]=high&filter[status][like]=a",
+			expectedFields: []errs.FieldError{
+				{Field: "limit", Message: "must be at most 50"},
+				{Field: "cursor", Message: "is invalid"},
+				{Field: "sort", Message: `cannot sort by "status"`},
+				{Field: "filter[name][eq]", Message: `cannot filter by "name"`},
+				{Field: "filter[score][eq]", Message: `operator "eq" is not allowed`},
+				{Field: "filter[score][gte]", Message: "must be a whole number"},
+				{Field: "filter[status][like]", Message: "must look like filter[field][op]"},
+			},
+		},
+		{
+			name:  "negative limit and unknown field",
+			query: "limit=-1&filter[secret]=1",
+			expectedFields: []errs.FieldError{
+				{Field: "limit", Message: "must be a positive number"},
+				{Field: "filter[secret]", Message: `cannot filter by "secret"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			list, err := Parse(values, itemList)
+			if tt.expectedFields != nil {
+				assert.True(t, errs.Is(err, errs.KindValidation))
+				assert.Equal(t, tt.expectedFields, fieldErrors(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, list)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	list, err := Parse(url.Values{"filter[status][nin]": {"deleted"}}, itemList)
+	assert.NoError(t, err)
+
+	q, err := list.Apply(datastore.NewQuery("Item"))
+	assert.NoError(t, err)
+	assert.NotNil(t, q)
+
+	_, err = List{Cursor: "!!"}.Apply(datastore.NewQuery("Item"))
+	assert.True(t, errs.Is(err, errs.KindValidation))
+}
+
+// GetPage needs a Datastore client and is covered by integration tests. They
+// should verify that NextCursor is empty on the last page and that passing it
+// back as cursor returns the following page.
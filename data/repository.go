@@ -0,0 +1,30 @@
+package data
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"cloud.google.com/go/datastore"
+)
+
+// BaseRepository provides the datastore client to entity repositories
+type BaseRepository struct{}
+
+// NewBaseRepository creates a base repository using the shared client
+func NewBaseRepository() *BaseRepository {
+	return &BaseRepository{}
+}
+
+// Client returns the shared datastore client
+func (r *BaseRepository) Client() *datastore.Client {
+	return Cli()
+}
+
+// NewID returns a random 32 character hex ID for a new entity
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
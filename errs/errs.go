@@ -0,0 +1,168 @@
+// Package errs defines the typed domain errors returned by services.
+//
+// Handlers map an error's Kind to an HTTP status. Message is safe to show to
+// users; the wrapped cause is only ever logged.
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Kind classifies a domain error
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindRateLimited
+)
+
+// Status returns the HTTP status code for the kind
+func (k Kind) Status() int {
+	switch k {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Code returns a stable machine-readable name for the kind
+func (k Kind) Code() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation_failed"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindRateLimited:
+		return "rate_limited"
+	default:
+		return "internal"
+	}
+}
+
+// FieldError describes why a single input field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a user-facing message and an optional cause
+type Error struct {
+	Kind    Kind
+	Message string
+	// Fields holds per-field details of validation errors
+	Fields []FieldError
+	// RetryAfter is set on rate limited errors
+	RetryAfter time.Duration
+	// Err is the underlying cause, logged but never sent to clients
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithCause returns a copy of e wrapping err
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// NotFound reports a missing entity
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// Conflict reports a request that clashes with the current state, e.g. a duplicate
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Validation reports invalid input with optional per-field details
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+// Unauthorized reports a missing or invalid identity
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+// Forbidden reports an identity that may not perform the action
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// RateLimited reports too many requests; retryAfter may be zero when unknown
+func RateLimited(message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindRateLimited, Message: message, RetryAfter: retryAfter}
+}
+
+// Internal wraps an unexpected failure. message is what users see, err is logged.
+func Internal(err error, message string) *Error {
+	return &Error{Kind: KindInternal, Message: message, Err: err}
+}
+
+// As returns the *Error in err's chain. Datastore "no such entity" errors are
+// reported as NotFound without a message so repositories can return them
+// unchanged and handlers supply the wording.
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return &Error{Kind: KindNotFound, Err: err}, true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of err, KindInternal for untyped errors
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// Is reports whether err is a domain error of the given kind
+func Is(err error, kind Kind) bool {
+	if err == nil {
+		return false
+	}
+	return KindOf(err) == kind
+}
@@ -0,0 +1,79 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKinds(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            *Error
+		expectedKind   Kind
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"not found", NotFound("character not found"), KindNotFound, http.StatusNotFound, "not_found"},
+		{"conflict", Conflict("name already taken"), KindConflict, http.StatusConflict, "conflict"},
+		{"validation", Validation("invalid input"), KindValidation, http.StatusUnprocessableEntity, "validation_failed"},
+		{"unauthorized", Unauthorized("sign in required"), KindUnauthorized, http.StatusUnauthorized, "unauthorized"},
+		{"forbidden", Forbidden("not your character"), KindForbidden, http.StatusForbidden, "forbidden"},
+		{"rate limited", RateLimited("slow down", time.Minute), KindRateLimited, http.StatusTooManyRequests, "rate_limited"},
+		{"internal", Internal(errors.New("db down"), "failed to save"), KindInternal, http.StatusInternalServerError, "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedKind, tt.err.Kind)
+			assert.Equal(t, tt.expectedStatus, tt.err.Kind.Status())
+			assert.Equal(t, tt.expectedCode, tt.err.Kind.Code())
+			assert.True(t, Is(tt.err, tt.expectedKind))
+		})
+	}
+}
+
+func TestError_WrapsCause(t *testing.T) {
+	cause := errors.New("datastore put failed: connection timeout")
+	err := Internal(cause, "failed to save entity")
+
+	assert.Equal(t, "failed to save entity: datastore put failed: connection timeout", err.Error())
+	assert.True(t, errors.Is(err, cause))
+
+	conflict := Conflict("already exists").WithCause(cause)
+	assert.True(t, errors.Is(conflict, cause))
+	assert.Equal(t, "already exists", conflict.Message)
+}
+
+func TestAs(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedOK   bool
+		expectedKind Kind
+	}{
+		{"typed error", Forbidden("no"), true, KindForbidden},
+		{"wrapped typed error", fmt.Errorf("update: %w", Validation("bad", FieldError{Field: "name", Message: "required"})), true, KindValidation},
+		{"datastore not found", datastore.ErrNoSuchEntity, true, KindNotFound},
+		{"plain error", errors.New("boom"), false, KindInternal},
+		{"nil", nil, false, KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := As(tt.err)
+			assert.Equal(t, tt.expectedOK, ok)
+			if ok {
+				assert.Equal(t, tt.expectedKind, e.Kind)
+			}
+			assert.Equal(t, tt.expectedKind, KindOf(tt.err))
+		})
+	}
+
+	assert.False(t, Is(nil, KindInternal))
+}
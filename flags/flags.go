@@ -0,0 +1,288 @@
+// Package flags evaluates feature flags for the user of a request. Flags
+// are data.Flag entities, managed at /app/admin/Flag and cached in memory:
+//
+//	if flags.On(ctx, "new-dashboard") {
+//		// new code path
+//	}
+//
+//	switch flags.Variant(ctx, "checkout") {
+//	case "one-page":
+//	}
+//
+// The user comes from auth.UserFrom(ctx), so the same calls work in
+// services, handlers and templ components, whose ctx is the request context.
+// Flags that do not exist, and flags not loaded yet, are off.
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/config"
+	"runtime-dynamics/data"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultRefresh is how often flags are reloaded without FLAGS_REFRESH
+const DefaultRefresh = 30 * time.Second
+
+// Store loads all flags; data.FlagRepository implements it
+type Store interface {
+	All(ctx context.Context) ([]*data.Flag, error)
+}
+
+// flag is a data.Flag with its rules decoded
+type flag struct {
+	key            string
+	enabled        bool
+	defaultVariant string
+	rules          []data.FlagRule
+}
+
+// Set is an in-memory copy of the stored flags, reloaded periodically
+type Set struct {
+	store   Store
+	refresh time.Duration
+
+	mu        sync.RWMutex
+	flags     map[string]*flag
+	overrides map[string]string
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// NewSet creates a set loading flags from store every refresh interval,
+// DefaultRefresh when zero. It holds no flags until Refresh or Start.
+func NewSet(store Store, refresh time.Duration) *Set {
+	if refresh <= 0 {
+		refresh = DefaultRefresh
+	}
+	return &Set{store: store, refresh: refresh, flags: map[string]*flag{}}
+}
+
+// Refresh reloads all flags. Flags with invalid rules are logged and serve
+// their default variant.
+func (s *Set) Refresh(ctx context.Context) error {
+	stored, err := s.store.All(ctx)
+	if err != nil {
+		return err
+	}
+	flags := make(map[string]*flag, len(stored))
+	for _, f := range stored {
+		compiled := &flag{key: f.Key, enabled: f.Enabled, defaultVariant: f.Default()}
+		if err := f.Validate(); err != nil {
+			log.Warn().Err(err).Str("flag", f.Key).Msg("invalid flag serves its default variant")
+		} else if compiled.enabled {
+			compiled.rules, _ = f.ParseRules()
+		}
+		flags[f.Key] = compiled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags = flags
+	return nil
+}
+
+// Start loads the flags and reloads them in the background until Stop is
+// called or ctx is done. A failed load is logged; the flags are then off
+// until a later reload succeeds.
+func (s *Set) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	if err := s.Refresh(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to load feature flags")
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+					log.Error().Err(err).Msg("failed to reload feature flags")
+				}
+			}
+		}
+	}()
+	log.Info().Dur("refresh", s.refresh).Msg("Feature flags loaded")
+}
+
+// Stop ends the background reloads
+func (s *Set) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Override serves variant for key to everyone regardless of the stored
+// flag, e.g. in tests; an empty variant removes the override
+func (s *Set) Override(key string, variant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if variant == "" {
+		delete(s.overrides, key)
+		return
+	}
+	if s.overrides == nil {
+		s.overrides = map[string]string{}
+	}
+	s.overrides[key] = variant
+}
+
+// Variant returns the variant of flag key served to the user of ctx, "off"
+// for unknown flags
+func (s *Set) Variant(ctx context.Context, key string) string {
+	return s.Evaluate(key, auth.UserFrom(ctx))
+}
+
+// On reports whether the user of ctx gets a variant of flag key other than
+// "off". Use it for boolean flags.
+func (s *Set) On(ctx context.Context, key string) bool {
+	return s.Variant(ctx, key) != data.FlagOff
+}
+
+// Evaluate returns the variant of flag key for user, which is nil for
+// anonymous users
+func (s *Set) Evaluate(key string, user *auth.User) string {
+	s.mu.RLock()
+	f, ok := s.flags[key]
+	override, overridden := s.overrides[key]
+	s.mu.RUnlock()
+	switch {
+	case overridden:
+		return override
+	case !ok:
+		return data.FlagOff
+	case !f.enabled:
+		return f.defaultVariant
+	}
+
+	for _, rule := range f.rules {
+		if matches(rule, key, user) {
+			return rule.Variant
+		}
+	}
+	return f.defaultVariant
+}
+
+// Evaluations returns the variant of every known flag for the user of ctx,
+// e.g. to pass flags on to client-side code
+func (s *Set) Evaluations(ctx context.Context) map[string]string {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.flags)+len(s.overrides))
+	for key := range s.flags {
+		keys = append(keys, key)
+	}
+	for key := range s.overrides {
+		keys = append(keys, key)
+	}
+	s.mu.RUnlock()
+
+	user := auth.UserFrom(ctx)
+	variants := make(map[string]string, len(keys))
+	for _, key := range keys {
+		variants[key] = s.Evaluate(key, user)
+	}
+	return variants
+}
+
+// matches reports whether user meets all conditions of rule. Anonymous
+// users only match rules without user conditions and without a percentage
+// below 100, as they have no ID to hash.
+func matches(rule data.FlagRule, key string, user *auth.User) bool {
+	if user == nil {
+		return len(rule.UserIDs) == 0 && len(rule.Roles) == 0 && len(rule.EmailDomains) == 0 &&
+			(rule.Percent == nil || *rule.Percent >= 100)
+	}
+	if len(rule.UserIDs) > 0 && !slices.Contains(rule.UserIDs, user.ID) {
+		return false
+	}
+	if len(rule.Roles) > 0 && !slices.ContainsFunc(rule.Roles, user.HasRole) {
+		return false
+	}
+	if len(rule.EmailDomains) > 0 && !slices.ContainsFunc(rule.EmailDomains, func(domain string) bool {
+		return strings.EqualFold(strings.TrimPrefix(domain, "@"), emailDomain(user.Email))
+	}) {
+		return false
+	}
+	if rule.Percent != nil {
+		return float64(Bucket(key, user.ID)) < *rule.Percent*100
+	}
+	return true
+}
+
+func emailDomain(email string) string {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return ""
+	}
+	return domain
+}
+
+// Bucket places a user in one of 10000 buckets for flag key. The bucket is
+// stable, so a user keeps their variant while a rollout grows, and differs
+// between flags, so rollouts are independent.
+func Bucket(key string, userID string) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	return int(h.Sum64() % 10000)
+}
+
+var (
+	defaultSet  *Set
+	defaultLock = new(sync.RWMutex)
+)
+
+// Default returns the set started by cmd/main.go, backed by
+// data.FlagRepository and reloaded every FLAGS_REFRESH
+func Default() *Set {
+	defaultLock.RLock()
+	s := defaultSet
+	defaultLock.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	if defaultSet == nil {
+		var refresh time.Duration
+		if cfg := config.Get(); cfg != nil {
+			refresh = cfg.FlagsRefresh
+		}
+		defaultSet = NewSet(data.NewFlagRepository(), refresh)
+	}
+	return defaultSet
+}
+
+// SetDefault replaces the default set, e.g. in tests
+func SetDefault(s *Set) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultSet = s
+}
+
+// On reports whether the user of ctx gets flag key, see Set.On
+func On(ctx context.Context, key string) bool {
+	return Default().On(ctx, key)
+}
+
+// Variant returns the variant of flag key for the user of ctx, see Set.Variant
+func Variant(ctx context.Context, key string) string {
+	return Default().Variant(ctx, key)
+}
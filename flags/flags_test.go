@@ -0,0 +1,168 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/data"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is an in-memory Store
+type memoryStore struct {
+	flags []*data.Flag
+	err   error
+	loads atomic.Int32
+}
+
+func (s *memoryStore) All(ctx context.Context) ([]*data.Flag, error) {
+	s.loads.Add(1)
+	return s.flags, s.err
+}
+
+func loadedSet(t *testing.T, flags ...*data.Flag) *Set {
+	t.Helper()
+	s := NewSet(&memoryStore{flags: flags}, 0)
+	assert.NoError(t, s.Refresh(context.Background()))
+	return s
+}
+
+func TestSet_Evaluate(t *testing.T) {
+	s := loadedSet(t,
+		&data.Flag{Key: "beta", Enabled: true, Rules: `[
+			{"user_ids": ["u1"], "variant": "on"},
+			{"roles": ["admin"], "variant": "on"},
+			{"email_domains": ["@Example.com"], "variant": "on"}
+		]`},
+		&data.Flag{Key: "everyone", Enabled: true, Rules: `[{"variant": "on"}]`},
+		&data.Flag{Key: "disabled", Enabled: false, DefaultVariant: "on", Rules: `[{"user_ids": ["u1"], "variant": "off"}]`},
+		&data.Flag{Key: "checkout", Enabled: true, Variants: "classic, one-page", DefaultVariant: "classic", Rules: `[
+			{"user_ids": ["u2"], "variant": "one-page"}
+		]`},
+		&data.Flag{Key: "broken", Enabled: true, Rules: `{"variant": "on"}`},
+		&data.Flag{Key: "combined", Enabled: true, Rules: `[{"roles": ["editor"], "email_domains": ["example.com"], "variant": "on"}]`},
+	)
+
+	tests := []struct {
+		name     string
+		key      string
+		user     *auth.User
+		expected string
+	}{
+		{name: "unknown flag", key: "missing", user: &auth.User{ID: "u1"}, expected: "off"},
+		{name: "user id", key: "beta", user: &auth.User{ID: "u1"}, expected: "on"},
+		{name: "role", key: "beta", user: &auth.User{ID: "u9", Roles: []string{"admin"}}, expected: "on"},
+		{name: "email domain", key: "beta", user: &auth.User{ID: "u9", Email: "ada@example.COM"}, expected: "on"},
+		{name: "subdomain is another domain", key: "beta", user: &auth.User{ID: "u9", Email: "ada@mail.example.com"}, expected: "off"},
+		{name: "no rule matches", key: "beta", user: &auth.User{ID: "u9"}, expected: "off"},
+		{name: "anonymous", key: "beta", user: nil, expected: "off"},
+		{name: "anonymous without conditions", key: "everyone", user: nil, expected: "on"},
+		{name: "disabled serves default", key: "disabled", user: &auth.User{ID: "u1"}, expected: "on"},
+		{name: "multivariate default", key: "checkout", user: &auth.User{ID: "u1"}, expected: "classic"},
+		{name: "multivariate rule", key: "checkout", user: &auth.User{ID: "u2"}, expected: "one-page"},
+		{name: "invalid rules serve default", key: "broken", user: &auth.User{ID: "u1"}, expected: "off"},
+		{name: "all conditions must match", key: "combined", user: &auth.User{ID: "u1", Email: "a@example.com"}, expected: "off"},
+		{name: "all conditions match", key: "combined", user: &auth.User{ID: "u1", Email: "a@example.com", Roles: []string{"editor"}}, expected: "on"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, s.Evaluate(tt.key, tt.user))
+			assert.Equal(t, tt.expected, s.Variant(auth.WithUser(context.Background(), tt.user), tt.key))
+		})
+	}
+}
+
+func TestSet_PercentRollout(t *testing.T) {
+	s := loadedSet(t, &data.Flag{Key: "rollout", Enabled: true, Variants: "a,b,control", DefaultVariant: "control", Rules: `[
+		{"percent": 20, "variant": "a"},
+		{"percent": 50, "variant": "b"}
+	]`})
+
+	counts := map[string]int{}
+	for i := range 10000 {
+		user := &auth.User{ID: fmt.Sprintf("user-%d", i)}
+		variant := s.Evaluate("rollout", user)
+		counts[variant]++
+		// stable for the same user
+		assert.Equal(t, variant, s.Evaluate("rollout", user))
+	}
+	assert.InDelta(t, 2000, counts["a"], 250)
+	assert.InDelta(t, 3000, counts["b"], 250)
+	assert.InDelta(t, 5000, counts["control"], 250)
+
+	// anonymous users have no bucket
+	assert.Equal(t, "control", s.Evaluate("rollout", nil))
+}
+
+func TestBucket(t *testing.T) {
+	assert.Equal(t, Bucket("flag", "u1"), Bucket("flag", "u1"))
+	assert.NotEqual(t, Bucket("flag-a", "u1"), Bucket("flag-b", "u1"))
+	assert.NotEqual(t, Bucket("ab", "c"), Bucket("a", "bc"))
+	for i := range 1000 {
+		b := Bucket("flag", fmt.Sprint(i))
+		assert.True(t, b >= 0 && b < 10000)
+	}
+}
+
+func TestSet_Override(t *testing.T) {
+	s := loadedSet(t, &data.Flag{Key: "beta", Enabled: true})
+	ctx := context.Background()
+	assert.False(t, s.On(ctx, "beta"))
+
+	s.Override("beta", "on")
+	s.Override("unstored", "on")
+	assert.True(t, s.On(ctx, "beta"))
+	assert.Equal(t, map[string]string{"beta": "on", "unstored": "on"}, s.Evaluations(ctx))
+
+	s.Override("beta", "")
+	assert.False(t, s.On(ctx, "beta"))
+}
+
+func TestSet_Refresh(t *testing.T) {
+	store := &memoryStore{flags: []*data.Flag{{Key: "beta", Enabled: true, Rules: `[{"variant": "on"}]`}}}
+	s := NewSet(store, 0)
+	ctx := context.Background()
+	assert.False(t, s.On(ctx, "beta"), "flags are off before loading")
+
+	assert.NoError(t, s.Refresh(ctx))
+	assert.True(t, s.On(ctx, "beta"))
+
+	// a failed reload keeps the loaded flags
+	store.err = errors.New("datastore unavailable")
+	assert.Error(t, s.Refresh(ctx))
+	assert.True(t, s.On(ctx, "beta"))
+
+	store.err = nil
+	store.flags = nil
+	assert.NoError(t, s.Refresh(ctx))
+	assert.False(t, s.On(ctx, "beta"))
+}
+
+func TestSet_Start(t *testing.T) {
+	store := &memoryStore{flags: []*data.Flag{{Key: "beta", Enabled: true, Rules: `[{"variant": "on"}]`}}}
+	s := NewSet(store, 10*time.Millisecond)
+	s.Start(context.Background())
+	assert.True(t, s.On(context.Background(), "beta"), "Start loads the flags before returning")
+
+	assert.Eventually(t, func() bool { return store.loads.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+}
+
+func TestDefault(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+
+	s := loadedSet(t, &data.Flag{Key: "beta", Enabled: true, Rules: `[{"roles": ["admin"], "variant": "on"}]`})
+	SetDefault(s)
+	ctx := auth.WithUser(context.Background(), &auth.User{ID: "u1", Roles: []string{"admin"}})
+	assert.True(t, On(ctx, "beta"))
+	assert.Equal(t, "on", Variant(ctx, "beta"))
+	assert.False(t, On(context.Background(), "beta"))
+}
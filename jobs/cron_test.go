@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "too few fields", expr: "0 0 * *"},
+		{name: "minute out of range", expr: "60 * * * *"},
+		{name: "day of month zero", expr: "0 0 0 * *"},
+		{name: "unknown month name", expr: "0 0 1 foo *"},
+		{name: "reversed range", expr: "0 10-2 * * *"},
+		{name: "zero step", expr: "*/0 * * * *"},
+		{name: "unknown descriptor", expr: "@fortnightly"},
+		{name: "invalid every", expr: "@every soon"},
+		{name: "every below a second", expr: "@every 10ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// a Wednesday
+	from := time.Date(2026, 3, 4, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", from: from, expected: time.Date(2026, 3, 4, 10, 18, 0, 0, time.UTC)},
+		{name: "step", expr: "*/15 * * * *", from: from, expected: time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)},
+		{name: "hourly", expr: "@hourly", from: from, expected: time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{name: "daily rolls over to tomorrow", expr: "@daily", from: from, expected: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{name: "list and range", expr: "0 9-17/4 * * *", from: from, expected: time.Date(2026, 3, 4, 13, 0, 0, 0, time.UTC)},
+		{name: "weekday names", expr: "30 8 * * mon,fri", from: from, expected: time.Date(2026, 3, 6, 8, 30, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 0 * * 7", from: from, expected: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{name: "month names", expr: "0 0 1 jun *", from: from, expected: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month or weekday", expr: "0 0 10 * sat", from: from, expected: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", expr: "0 0 29 2 *", from: from, expected: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "never", expr: "0 0 30 2 *", from: from, expected: time.Time{}},
+		{name: "every duration", expr: "@every 10m", from: from, expected: time.Date(2026, 3, 4, 10, 20, 0, 0, time.UTC)},
+		{name: "exact match is not next", expr: "0 * * * *", from: time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC), expected: time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{
+			name:     "local time zone",
+			expr:     "0 2 * * *",
+			from:     time.Date(2026, 3, 4, 10, 0, 0, 0, berlin),
+			expected: time.Date(2026, 3, 5, 2, 0, 0, 0, berlin),
+		},
+		{
+			name:     "skips the hour lost to daylight saving time",
+			expr:     "30 2 * * *",
+			from:     time.Date(2026, 3, 28, 12, 0, 0, 0, berlin),
+			expected: time.Date(2026, 3, 30, 2, 30, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, tt.expected.Equal(cron.Next(tt.from)), "Next() = %v, want %v", cron.Next(tt.from), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package jobs runs background work outside of requests. Job types are
+// registered once with a typed handler and enqueued from services:
+//
+//	var SendWelcome = jobs.Register("send-welcome", func(ctx context.Context, p WelcomePayload) error {
+//		return mailer.Send(ctx, p.Email)
+//	}, jobs.Options{MaxAttempts: 5})
+//
+//	// in a service
+//	_, err := SendWelcome.Enqueue(ctx, WelcomePayload{Email: user.Email})
+//
+// Jobs are stored through the data layer, so they survive restarts and are
+// shared by all instances. Failed jobs are retried with exponential backoff
+// and end up in the dead state once they run out of attempts.
+//
+// Periodic tasks run on cron schedules with Schedule; a lease lets one
+// instance execute each run.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"runtime-dynamics/data"
+)
+
+const (
+	// DefaultMaxAttempts is the number of runs before a job is dead
+	DefaultMaxAttempts = 5
+	// DefaultTimeout bounds a single run
+	DefaultTimeout = 5 * time.Minute
+	// DefaultBackoff is the delay before the first retry; it doubles with
+	// every further attempt
+	DefaultBackoff = 30 * time.Second
+	// MaxBackoff caps the delay between retries
+	MaxBackoff = time.Hour
+	// SucceededRetention is how long succeeded jobs are kept, via ExpireAt
+	SucceededRetention = 7 * 24 * time.Hour
+)
+
+// Store persists jobs; data.JobRepository implements it
+type Store interface {
+	Create(ctx context.Context, job *data.Job) error
+	Update(ctx context.Context, job *data.Job) error
+	Due(ctx context.Context, state string, now time.Time, limit int) ([]*data.Job, error)
+	Claim(ctx context.Context, id string, now time.Time, lease time.Duration) (*data.Job, error)
+}
+
+// Options configure a job type. Zero values use the defaults.
+type Options struct {
+	MaxAttempts int
+	Timeout     time.Duration
+	Backoff     time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultMaxAttempts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultBackoff
+	}
+	return o
+}
+
+// delay returns the wait before the retry following attempt
+func (o Options) delay(attempt int64) time.Duration {
+	d := o.Backoff
+	for i := int64(1); i < attempt && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, MaxBackoff)
+}
+
+// permanentError marks errors that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so the job is moved to the dead state without
+// further attempts, e.g. when its payload can never succeed
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// handler runs a job's JSON payload
+type handler struct {
+	run  func(ctx context.Context, payload []byte) error
+	opts Options
+}
+
+// Type is a registered job type used to enqueue jobs with payload T
+type Type[T any] struct {
+	name  string
+	queue *Queue
+	opts  Options
+}
+
+// Name returns the job type name stored in data.Job.Type
+func (t *Type[T]) Name() string {
+	return t.name
+}
+
+// Enqueue stores a job that runs payload as soon as a worker is free
+func (t *Type[T]) Enqueue(ctx context.Context, payload T) (*data.Job, error) {
+	return t.EnqueueAt(ctx, payload, time.Time{})
+}
+
+// EnqueueAt stores a job that runs payload once runAt has passed
+func (t *Type[T]) EnqueueAt(ctx context.Context, payload T, runAt time.Time) (*data.Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s payload: %w", t.name, err)
+	}
+	return t.queue.enqueue(ctx, t.name, encoded, t.opts, runAt)
+}
+
+// Register adds a job type to the default queue. Call it from a package
+// level variable or init function, before the queue starts; registering a
+// name twice panics.
+func Register[T any](name string, run func(ctx context.Context, payload T) error, opts Options) *Type[T] {
+	return RegisterOn(Default(), name, run, opts)
+}
+
+// RegisterOn adds a job type to q
+func RegisterOn[T any](q *Queue, name string, run func(ctx context.Context, payload T) error, opts Options) *Type[T] {
+	opts = opts.withDefaults()
+	q.register(name, handler{
+		run: func(ctx context.Context, raw []byte) error {
+			var payload T
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				return Permanent(fmt.Errorf("decoding %s payload: %w", name, err))
+			}
+			return run(ctx, payload)
+		},
+		opts: opts,
+	})
+	return &Type[T]{name: name, queue: q, opts: opts}
+}
+
+var (
+	defaultQueue     *Queue
+	defaultQueueOnce sync.Once
+)
+
+// Default returns the queue started by cmd/main.go, backed by
+// data.JobRepository
+func Default() *Queue {
+	defaultQueueOnce.Do(func() {
+		defaultQueue = NewQueue(data.NewJobRepository(), QueueOptions{})
+	})
+	return defaultQueue
+}
@@ -0,0 +1,298 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/data"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultConcurrency is the number of jobs run at once per instance
+	DefaultConcurrency = 4
+	// DefaultPollInterval is the time between checks for due jobs. Jobs
+	// enqueued on the same instance start without waiting for it.
+	DefaultPollInterval = 5 * time.Second
+
+	// leaseMargin extends a claim beyond the job timeout so a slow save does
+	// not let another worker take over a job that finished
+	leaseMargin = time.Minute
+	// saveTimeout bounds storing the result of a run
+	saveTimeout = 10 * time.Second
+	// requeueTimeout bounds how long Stop waits for canceled runs to queue
+	// their jobs again. It is short to stay within the platform's grace
+	// period after SIGTERM; jobs not saved by then, e.g. of handlers
+	// ignoring cancellation, run again once their claim expires.
+	requeueTimeout = 2 * time.Second
+)
+
+// QueueOptions configure a Queue. Zero values use the defaults.
+type QueueOptions struct {
+	// Concurrency defaults to JOBS_CONCURRENCY, then DefaultConcurrency
+	Concurrency  int
+	PollInterval time.Duration
+}
+
+// Queue stores jobs and runs them on a pool of workers
+type Queue struct {
+	store Store
+	opts  QueueOptions
+	// now is replaced in tests
+	now func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]handler
+
+	wake       chan struct{}
+	running    sync.WaitGroup
+	pollDone   chan struct{}
+	cancelPoll context.CancelFunc
+	cancelRuns context.CancelFunc
+}
+
+// NewQueue creates a queue storing jobs in store
+func NewQueue(store Store, opts QueueOptions) *Queue {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	return &Queue{
+		store:    store,
+		opts:     opts,
+		now:      time.Now,
+		handlers: make(map[string]handler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+func (q *Queue) register(name string, h handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.handlers[name]; ok {
+		panic(fmt.Sprintf("jobs: job type %q registered twice", name))
+	}
+	q.handlers[name] = h
+}
+
+func (q *Queue) handler(name string) (handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	h, ok := q.handlers[name]
+	return h, ok
+}
+
+// lease is how long a claim on a job of type name lasts
+func (q *Queue) lease(name string) time.Duration {
+	if h, ok := q.handler(name); ok {
+		return h.opts.Timeout + leaseMargin
+	}
+	return DefaultTimeout + leaseMargin
+}
+
+func (q *Queue) enqueue(ctx context.Context, name string, payload []byte, opts Options, runAt time.Time) (*data.Job, error) {
+	now := q.now()
+	if runAt.Before(now) {
+		runAt = now
+	}
+	job := &data.Job{
+		ID:          data.NewID(),
+		Type:        name,
+		Payload:     string(payload),
+		State:       data.JobQueued,
+		MaxAttempts: int64(opts.MaxAttempts),
+		RunAt:       runAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := q.store.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	log.Debug().Str("job_id", job.ID).Str("job_type", name).Msg("job enqueued")
+	q.notify()
+	return job, nil
+}
+
+// notify wakes the poller without waiting for the next interval
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs due jobs in the background until Stop is called or ctx is done
+func (q *Queue) Start(ctx context.Context) {
+	if q.opts.Concurrency <= 0 {
+		q.opts.Concurrency = DefaultConcurrency
+		if cfg := config.Get(); cfg != nil && cfg.JobConcurrency > 0 {
+			q.opts.Concurrency = cfg.JobConcurrency
+		}
+	}
+	pollCtx, cancelPoll := context.WithCancel(ctx)
+	// runs outlive ctx so Stop can let them finish
+	runCtx, cancelRuns := context.WithCancel(context.WithoutCancel(ctx))
+	q.pollDone = make(chan struct{})
+	q.cancelPoll = cancelPoll
+	q.cancelRuns = cancelRuns
+
+	go func() {
+		defer close(q.pollDone)
+		q.poll(pollCtx, runCtx)
+	}()
+	log.Info().Int("concurrency", q.opts.Concurrency).Msg("Job workers started")
+}
+
+// Stop stops claiming jobs and waits for running ones until ctx is done.
+// Jobs still running then are canceled, and Stop waits up to
+// requeueTimeout more for them to be queued again.
+func (q *Queue) Stop(ctx context.Context) error {
+	if q.cancelPoll == nil {
+		return nil
+	}
+	q.cancelPoll()
+	<-q.pollDone
+
+	done := make(chan struct{})
+	go func() {
+		q.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		q.cancelRuns()
+		log.Info().Msg("Job workers stopped")
+		return nil
+	case <-ctx.Done():
+		q.cancelRuns()
+		timer := time.NewTimer(requeueTimeout)
+		defer timer.Stop()
+		select {
+		case <-done:
+			log.Warn().Msg("Job workers stopped, interrupted jobs queued again")
+		case <-timer.C:
+			log.Warn().Msg("Job workers stopped with jobs still running")
+		}
+		return ctx.Err()
+	}
+}
+
+// poll claims due jobs whenever a worker is free
+func (q *Queue) poll(ctx context.Context, runCtx context.Context) {
+	slots := make(chan struct{}, q.opts.Concurrency)
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.dispatch(ctx, runCtx, slots)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// dispatch starts due jobs in the free slots: queued jobs first, then
+// running jobs whose claim expired because their worker died
+func (q *Queue) dispatch(ctx context.Context, runCtx context.Context, slots chan struct{}) {
+	free := cap(slots) - len(slots)
+	now := q.now()
+	for _, state := range []string{data.JobQueued, data.JobRunning} {
+		if free == 0 || ctx.Err() != nil {
+			return
+		}
+		due, err := q.store.Due(ctx, state, now, free)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Msg("failed to load due jobs")
+			}
+			return
+		}
+		for _, job := range due {
+			claimed, err := q.store.Claim(ctx, job.ID, now, q.lease(job.Type))
+			if err != nil || claimed == nil {
+				continue
+			}
+			if state == data.JobRunning {
+				log.Warn().Str("job_id", job.ID).Str("job_type", job.Type).Msg("reclaimed job whose worker stopped")
+			}
+			slots <- struct{}{}
+			free--
+			q.running.Add(1)
+			go func() {
+				defer q.running.Done()
+				q.run(runCtx, claimed)
+				<-slots
+				q.notify()
+			}()
+			if free == 0 {
+				return
+			}
+		}
+	}
+}
+
+// run executes a claimed job and stores the outcome
+func (q *Queue) run(ctx context.Context, job *data.Job) {
+	logger := log.With().Str("job_id", job.ID).Str("job_type", job.Type).Int64("attempt", job.Attempts).Logger()
+
+	opts := Options{}.withDefaults()
+	h, ok := q.handler(job.Type)
+	var err error
+	if ok {
+		opts = h.opts
+		runCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		err = safeRun(runCtx, h.run, []byte(job.Payload))
+		cancel()
+	} else {
+		err = fmt.Errorf("no handler registered for job type %q", job.Type)
+	}
+
+	now := q.now()
+	job.UpdatedAt = now
+	switch {
+	case err == nil:
+		job.State = data.JobSucceeded
+		job.LastError = ""
+		job.ExpireAt = now.Add(SucceededRetention)
+		logger.Debug().Msg("job succeeded")
+	case ctx.Err() != nil:
+		// interrupted by shutdown: run again without using up an attempt
+		job.State = data.JobQueued
+		job.Attempts--
+		job.RunAt = now
+		job.LastError = "interrupted by shutdown"
+		logger.Warn().Msg("job interrupted by shutdown, queued again")
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		job.State = data.JobDead
+		job.LastError = err.Error()
+		logger.Error().Err(err).Msg("job failed permanently, moved to dead letters")
+	default:
+		job.State = data.JobQueued
+		job.RunAt = now.Add(opts.delay(job.Attempts))
+		job.LastError = err.Error()
+		logger.Warn().Err(err).Time("retry_at", job.RunAt).Msg("job failed, will retry")
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+	defer cancel()
+	if err := q.store.Update(saveCtx, job); err != nil {
+		// the claim expires and the job runs again
+		logger.Error().Err(err).Msg("failed to save job result")
+	}
+}
+
+// safeRun turns a panicking handler into a failed attempt
+func safeRun(ctx context.Context, run func(context.Context, []byte) error, payload []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return run(ctx, payload)
+}
@@ -0,0 +1,307 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"runtime-dynamics/data"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is an in-memory Store
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]data.Job
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{jobs: map[string]data.Job{}}
+}
+
+func (s *memoryStore) Create(ctx context.Context, job *data.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, job *data.Job) error {
+	return s.Create(ctx, job)
+}
+
+func (s *memoryStore) Due(ctx context.Context, state string, now time.Time, limit int) ([]*data.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*data.Job
+	for _, job := range s.jobs {
+		if job.State == state && !job.RunAt.After(now) {
+			job := job
+			due = append(due, &job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *memoryStore) Claim(ctx context.Context, id string, now time.Time, lease time.Duration) (*data.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || (job.State != data.JobQueued && job.State != data.JobRunning) || job.RunAt.After(now) {
+		return nil, nil
+	}
+	job.State = data.JobRunning
+	job.Attempts++
+	job.RunAt = now.Add(lease)
+	s.jobs[id] = job
+	return &job, nil
+}
+
+func (s *memoryStore) get(id string) data.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+func TestQueue_RunsEnqueuedJobs(t *testing.T) {
+	store := newMemoryStore()
+	q := NewQueue(store, QueueOptions{PollInterval: time.Hour})
+	received := make(chan greeting, 1)
+	greet := RegisterOn(q, "greet", func(ctx context.Context, p greeting) error {
+		received <- p
+		return nil
+	}, Options{})
+
+	q.Start(context.Background())
+	job, err := greet.Enqueue(context.Background(), greeting{Name: "Ann"})
+	assert.NoError(t, err)
+
+	select {
+	case p := <-received:
+		assert.Equal(t, "Ann", p.Name)
+	case <-time.After(time.Second):
+		t.Fatal("job did not run")
+	}
+	assert.NoError(t, q.Stop(context.Background()))
+
+	saved := store.get(job.ID)
+	assert.Equal(t, data.JobSucceeded, saved.State)
+	assert.Equal(t, int64(1), saved.Attempts)
+	assert.Equal(t, int64(DefaultMaxAttempts), saved.MaxAttempts)
+	assert.False(t, saved.ExpireAt.IsZero())
+}
+
+func TestQueue_EnqueueAtWaitsForRunAt(t *testing.T) {
+	store := newMemoryStore()
+	q := NewQueue(store, QueueOptions{})
+	later := RegisterOn(q, "later", func(ctx context.Context, p greeting) error { return nil }, Options{})
+
+	runAt := time.Now().Add(time.Hour)
+	job, err := later.EnqueueAt(context.Background(), greeting{}, runAt)
+	assert.NoError(t, err)
+
+	due, _ := store.Due(context.Background(), data.JobQueued, time.Now(), 10)
+	assert.Empty(t, due)
+	assert.True(t, store.get(job.ID).RunAt.Equal(runAt))
+}
+
+func TestQueue_RunOutcome(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	failure := errors.New("smtp unavailable")
+
+	tests := []struct {
+		name          string
+		jobType       string
+		run           func(ctx context.Context, p greeting) error
+		attempts      int64
+		payload       string
+		expectedState string
+		expectedRunAt time.Time
+		expectedError string
+	}{
+		{
+			name:          "first failure retries after the backoff",
+			jobType:       "test",
+			run:           func(ctx context.Context, p greeting) error { return failure },
+			attempts:      1,
+			expectedState: data.JobQueued,
+			expectedRunAt: now.Add(time.Second),
+			expectedError: "smtp unavailable",
+		},
+		{
+			name:          "later failures back off exponentially",
+			jobType:       "test",
+			run:           func(ctx context.Context, p greeting) error { return failure },
+			attempts:      2,
+			expectedState: data.JobQueued,
+			expectedRunAt: now.Add(2 * time.Second),
+			expectedError: "smtp unavailable",
+		},
+		{
+			name:          "last attempt moves the job to dead letters",
+			jobType:       "test",
+			run:           func(ctx context.Context, p greeting) error { return failure },
+			attempts:      3,
+			expectedState: data.JobDead,
+			expectedError: "smtp unavailable",
+		},
+		{
+			name:          "permanent errors are not retried",
+			jobType:       "test",
+			run:           func(ctx context.Context, p greeting) error { return Permanent(failure) },
+			attempts:      1,
+			expectedState: data.JobDead,
+			expectedError: "smtp unavailable",
+		},
+		{
+			name:          "invalid payloads are not retried",
+			jobType:       "test",
+			run:           func(ctx context.Context, p greeting) error { return nil },
+			attempts:      1,
+			payload:       `{"name":`,
+			expectedState: data.JobDead,
+			expectedError: "decoding test payload: unexpected end of JSON input",
+		},
+		{
+			name:          "panics fail the attempt",
+			jobType:       "test",
+			run:           func(ctx context.Context, p greeting) error { panic("boom") },
+			attempts:      1,
+			expectedState: data.JobQueued,
+			expectedRunAt: now.Add(time.Second),
+			expectedError: "job panicked: boom",
+		},
+		{
+			name:          "unknown job types are retried",
+			jobType:       "unknown",
+			attempts:      1,
+			expectedState: data.JobQueued,
+			expectedRunAt: now.Add(DefaultBackoff),
+			expectedError: `no handler registered for job type "unknown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			q := NewQueue(store, QueueOptions{})
+			q.now = func() time.Time { return now }
+			if tt.run != nil {
+				RegisterOn(q, "test", tt.run, Options{MaxAttempts: 3, Backoff: time.Second})
+			}
+			payload := tt.payload
+			if payload == "" {
+				payload = `{"name":"Ann"}`
+			}
+			job := &data.Job{ID: "j1", Type: tt.jobType, Payload: payload, State: data.JobRunning, Attempts: tt.attempts, MaxAttempts: 3}
+
+			q.run(context.Background(), job)
+
+			saved := store.get("j1")
+			assert.Equal(t, tt.expectedState, saved.State)
+			assert.Equal(t, tt.expectedError, saved.LastError)
+			if !tt.expectedRunAt.IsZero() {
+				assert.Equal(t, tt.expectedRunAt, saved.RunAt)
+			}
+		})
+	}
+}
+
+func TestQueue_ReclaimsExpiredJobs(t *testing.T) {
+	store := newMemoryStore()
+	q := NewQueue(store, QueueOptions{PollInterval: time.Hour})
+	ran := make(chan struct{}, 1)
+	RegisterOn(q, "test", func(ctx context.Context, p greeting) error {
+		ran <- struct{}{}
+		return nil
+	}, Options{})
+	// claimed by a worker that died before saving the result
+	store.Create(context.Background(), &data.Job{ID: "j1", Type: "test", Payload: "{}", State: data.JobRunning, Attempts: 1, MaxAttempts: 5, RunAt: time.Now().Add(-time.Minute)})
+
+	q.Start(context.Background())
+	defer q.Stop(context.Background())
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("expired job was not reclaimed")
+	}
+	assert.Eventually(t, func() bool {
+		job := store.get("j1")
+		return job.State == data.JobSucceeded && job.Attempts == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestQueue_StopRequeuesInterruptedJobs(t *testing.T) {
+	store := newMemoryStore()
+	q := NewQueue(store, QueueOptions{PollInterval: time.Hour})
+	started := make(chan struct{})
+	slow := RegisterOn(q, "slow", func(ctx context.Context, p greeting) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, Options{})
+
+	q.Start(context.Background())
+	job, _ := slow.Enqueue(context.Background(), greeting{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Stop(ctx), context.DeadlineExceeded)
+
+	// queued again by the time Stop returns, before the process exits
+	saved := store.get(job.ID)
+	assert.Equal(t, data.JobQueued, saved.State)
+	assert.Equal(t, int64(0), saved.Attempts)
+	assert.Equal(t, "interrupted by shutdown", saved.LastError)
+}
+
+func TestRegisterOn_DuplicatePanics(t *testing.T) {
+	q := NewQueue(newMemoryStore(), QueueOptions{})
+	RegisterOn(q, "test", func(ctx context.Context, p greeting) error { return nil }, Options{})
+
+	assert.Panics(t, func() {
+		RegisterOn(q, "test", func(ctx context.Context, p greeting) error { return nil }, Options{})
+	})
+}
+
+func TestOptions_Delay(t *testing.T) {
+	opts := Options{Backoff: time.Minute}
+
+	tests := []struct {
+		attempt  int64
+		expected time.Duration
+	}{
+		{attempt: 1, expected: time.Minute},
+		{attempt: 2, expected: 2 * time.Minute},
+		{attempt: 4, expected: 8 * time.Minute},
+		{attempt: 10, expected: MaxBackoff},
+		{attempt: 1000, expected: MaxBackoff},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, opts.delay(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	err := Permanent(errors.New("bad address"))
+
+	assert.True(t, IsPermanent(err))
+	assert.True(t, IsPermanent(errors.Join(errors.New("context"), err)))
+	assert.False(t, IsPermanent(errors.New("timeout")))
+	assert.Equal(t, "bad address", err.Error())
+}
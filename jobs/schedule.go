@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"runtime-dynamics/data"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTaskTimeout bounds a scheduled run
+const DefaultTaskTimeout = 10 * time.Minute
+
+// LeaseStore records which instance claimed each scheduled run;
+// data.LeaseRepository implements it
+type LeaseStore interface {
+	ClaimRun(ctx context.Context, name string, holder string, run time.Time, expiresAt time.Time) (bool, error)
+}
+
+// TaskOptions configure a scheduled task. Zero values use the defaults.
+type TaskOptions struct {
+	Timeout time.Duration
+	// Location is the time zone of the cron expression, UTC by default
+	Location *time.Location
+}
+
+// task is a registered scheduled task
+type task struct {
+	name string
+	expr string
+	cron Cron
+	run  func(ctx context.Context) error
+	opts TaskOptions
+}
+
+// Scheduler runs tasks on cron schedules. Every instance runs the
+// scheduler, and the lease store lets exactly one of them execute each run.
+type Scheduler struct {
+	leases LeaseStore
+	// holder identifies this instance in leases
+	holder string
+	// now is replaced in tests
+	now func() time.Time
+
+	mu    sync.Mutex
+	tasks []*task
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// NewScheduler creates a scheduler claiming runs in leases
+func NewScheduler(leases LeaseStore) *Scheduler {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return &Scheduler{
+		leases: leases,
+		holder: hex.EncodeToString(b),
+		now:    time.Now,
+	}
+}
+
+var (
+	defaultScheduler     *Scheduler
+	defaultSchedulerOnce sync.Once
+)
+
+// DefaultScheduler returns the scheduler started by cmd/main.go, backed by
+// data.LeaseRepository
+func DefaultScheduler() *Scheduler {
+	defaultSchedulerOnce.Do(func() {
+		defaultScheduler = NewScheduler(data.NewLeaseRepository())
+	})
+	return defaultScheduler
+}
+
+// Schedule adds a task to the default scheduler. It panics when the cron
+// expression is invalid or the name is taken, so call it from an init
+// function where mistakes surface at startup.
+func Schedule(name string, expr string, run func(ctx context.Context) error, opts TaskOptions) {
+	if err := DefaultScheduler().Add(name, expr, run, opts); err != nil {
+		panic(err)
+	}
+}
+
+// Add registers a task run on the cron expression expr. Tasks added after
+// Start are not run.
+func (s *Scheduler) Add(name string, expr string, run func(ctx context.Context) error, opts TaskOptions) error {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", name, err)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTaskTimeout
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tasks {
+		if t.name == name {
+			return fmt.Errorf("task %s scheduled twice", name)
+		}
+	}
+	s.tasks = append(s.tasks, &task{name: name, expr: expr, cron: cron, run: run, opts: opts})
+	return nil
+}
+
+// Start runs the tasks until Stop is called or ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, t)
+		}()
+	}
+	log.Info().Int("tasks", len(s.tasks)).Msg("Scheduler started")
+}
+
+// Stop cancels running tasks and waits for them to return until ctx is done
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Info().Msg("Scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		log.Warn().Msg("Scheduler stopped with tasks still running")
+		return ctx.Err()
+	}
+}
+
+// loop waits for each run of t and executes it when this instance wins the
+// claim. A run that overlaps the next scheduled time skips that time.
+func (s *Scheduler) loop(ctx context.Context, t *task) {
+	for {
+		next := t.cron.Next(s.now().In(t.opts.Location))
+		if next.IsZero() {
+			log.Warn().Str("task", t.name).Str("cron", t.expr).Msg("scheduled task never runs")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.runOnce(ctx, t, next)
+	}
+}
+
+// runOnce claims the run of t scheduled at run and executes it
+func (s *Scheduler) runOnce(ctx context.Context, t *task, run time.Time) {
+	logger := log.With().Str("task", t.name).Time("run", run).Logger()
+
+	claimed, err := s.leases.ClaimRun(ctx, t.name, s.holder, run, run.Add(t.opts.Timeout))
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Error().Err(err).Msg("failed to claim scheduled run")
+		}
+		return
+	}
+	if !claimed {
+		logger.Debug().Msg("scheduled run claimed by another instance")
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, t.opts.Timeout)
+	defer cancel()
+	started := s.now()
+	if err := safeRun(runCtx, func(ctx context.Context, _ []byte) error { return t.run(ctx) }, nil); err != nil {
+		logger.Error().Err(err).Dur("duration", s.now().Sub(started)).Msg("scheduled task failed")
+		return
+	}
+	logger.Info().Dur("duration", s.now().Sub(started)).Msg("scheduled task finished")
+}
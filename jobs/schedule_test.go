@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryLeases is an in-memory LeaseStore
+type memoryLeases struct {
+	mu   sync.Mutex
+	runs map[string]time.Time
+	err  error
+}
+
+func newMemoryLeases() *memoryLeases {
+	return &memoryLeases{runs: map[string]time.Time{}}
+}
+
+func (l *memoryLeases) ClaimRun(ctx context.Context, name string, holder string, run time.Time, expiresAt time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false, l.err
+	}
+	if !l.runs[name].Before(run) {
+		return false, nil
+	}
+	l.runs[name] = run
+	return true, nil
+}
+
+func TestScheduler_OneInstanceRunsEachRun(t *testing.T) {
+	leases := newMemoryLeases()
+	var mu sync.Mutex
+	runs := map[string]int{}
+	newInstance := func(name string) *Scheduler {
+		s := NewScheduler(leases)
+		assert.NoError(t, s.Add("cleanup", "@hourly", func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[name]++
+			return nil
+		}, TaskOptions{}))
+		return s
+	}
+	first, second := newInstance("first"), newInstance("second")
+	run := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	first.runOnce(context.Background(), first.tasks[0], run)
+	second.runOnce(context.Background(), second.tasks[0], run)
+	second.runOnce(context.Background(), second.tasks[0], run.Add(time.Hour))
+	first.runOnce(context.Background(), first.tasks[0], run.Add(time.Hour))
+
+	assert.Equal(t, map[string]int{"first": 1, "second": 1}, runs)
+}
+
+func TestScheduler_RunOnceSurvivesFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		claimErr error
+		run      func(ctx context.Context) error
+		expected int
+	}{
+		{name: "claim fails", claimErr: errors.New("datastore unavailable"), run: func(ctx context.Context) error { return nil }, expected: 0},
+		{name: "task fails", run: func(ctx context.Context) error { return errors.New("boom") }, expected: 1},
+		{name: "task panics", run: func(ctx context.Context) error { panic("boom") }, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases := newMemoryLeases()
+			leases.err = tt.claimErr
+			s := NewScheduler(leases)
+			calls := 0
+			assert.NoError(t, s.Add("task", "@daily", func(ctx context.Context) error {
+				calls++
+				return tt.run(ctx)
+			}, TaskOptions{}))
+
+			assert.NotPanics(t, func() {
+				s.runOnce(context.Background(), s.tasks[0], time.Now())
+			})
+			assert.Equal(t, tt.expected, calls)
+		})
+	}
+}
+
+func TestScheduler_StopCancelsRunningTasks(t *testing.T) {
+	s := NewScheduler(newMemoryLeases())
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	assert.NoError(t, s.Add("slow", "@every 1s", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	}, TaskOptions{}))
+
+	s.Start(context.Background())
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("task did not run")
+	}
+
+	assert.NoError(t, s.Stop(context.Background()))
+	select {
+	case <-canceled:
+	default:
+		t.Fatal("task context was not canceled")
+	}
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := NewScheduler(newMemoryLeases())
+	noop := func(ctx context.Context) error { return nil }
+
+	assert.NoError(t, s.Add("digest", "0 7 * * mon-fri", noop, TaskOptions{}))
+	assert.Error(t, s.Add("digest", "@daily", noop, TaskOptions{}))
+	assert.Error(t, s.Add("broken", "61 * * * *", noop, TaskOptions{}))
+	assert.Equal(t, DefaultTaskTimeout, s.tasks[0].opts.Timeout)
+	assert.Equal(t, time.UTC, s.tasks[0].opts.Location)
+}
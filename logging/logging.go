@@ -0,0 +1,171 @@
+// Package logging configures the global zerolog logger from config: the
+// output format (console, JSON or Google Cloud Logging JSON), the level,
+// per-package levels and redaction of secrets.
+//
+// Packages keep logging through github.com/rs/zerolog/log. Per-package
+// levels are matched against the package that wrote the event, e.g.
+// LOG_LEVELS=jobs=debug,web/realtime=warn.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/tracing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Output formats
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+	// FormatGCP is JSON with the severity and trace fields of Google Cloud
+	// Logging, for Cloud Run and other GCP runtimes
+	FormatGCP = "gcp"
+)
+
+// Options configure the logger. Setup derives them from config.
+type Options struct {
+	Format string
+	Level  zerolog.Level
+	// PackageLevels override Level for packages of this module, keyed by
+	// path relative to the module, e.g. "web/realtime"
+	PackageLevels map[string]zerolog.Level
+	// RedactFields are added to the default sensitive field names
+	RedactFields []string
+	// Secrets are values replaced wherever they appear in an event
+	Secrets []string
+	// ProjectID qualifies trace IDs in the gcp format
+	ProjectID string
+}
+
+// OptionsFrom returns the logging options of cfg. Without configuration,
+// e.g. before it is loaded, it logs to the console at info level, or debug
+// level when DEBUG is set.
+func OptionsFrom(cfg *config.AppConfig) Options {
+	opts := Options{Format: FormatConsole, Level: zerolog.InfoLevel}
+	if os.Getenv("DEBUG") != "" {
+		opts.Level = zerolog.DebugLevel
+	}
+	if cfg == nil {
+		return opts
+	}
+	if cfg.LogFormat != "" {
+		opts.Format = cfg.LogFormat
+	}
+	if level, err := zerolog.ParseLevel(cfg.LogLevel); err == nil && cfg.LogLevel != "" {
+		opts.Level = level
+	}
+	opts.PackageLevels = make(map[string]zerolog.Level, len(cfg.LogLevels))
+	for pkg, name := range cfg.LogLevels {
+		if level, err := zerolog.ParseLevel(name); err == nil {
+			opts.PackageLevels[pkg] = level
+		}
+	}
+	opts.RedactFields = cfg.LogRedactFields
+	opts.Secrets = []string{cfg.FirebaseAPIKey, cfg.SMTPPassword}
+	opts.ProjectID = cfg.GoogleProjectID
+	return opts
+}
+
+// Setup replaces the global logger with one writing to w as configured by
+// cfg. It is called once with a nil cfg at startup and again after the
+// configuration is loaded.
+func Setup(w io.Writer, cfg *config.AppConfig) error {
+	logger, minLevel, err := New(w, OptionsFrom(cfg))
+	if err != nil {
+		return err
+	}
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	zerolog.SetGlobalLevel(minLevel)
+	log.Logger = logger
+	return nil
+}
+
+// New creates a logger writing to w. minLevel is the lowest level any
+// package logs at, which the global level must not exceed.
+func New(w io.Writer, opts Options) (logger zerolog.Logger, minLevel zerolog.Level, err error) {
+	var out io.Writer
+	switch opts.Format {
+	case FormatConsole:
+		out = zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339}
+	case FormatJSON, FormatGCP:
+		out = w
+	default:
+		return logger, minLevel, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+	out = newRedactWriter(out, opts)
+
+	logger = zerolog.New(out).With().Timestamp().Logger().Hook(tracing.LogHook{})
+	minLevel = opts.Level
+	if len(opts.PackageLevels) > 0 {
+		for _, level := range opts.PackageLevels {
+			minLevel = min(minLevel, level)
+		}
+		logger = logger.Hook(packageLevels{fallback: opts.Level, levels: opts.PackageLevels})
+	}
+	return logger.Level(minLevel), minLevel, nil
+}
+
+// modulePrefix is the import path prefix of this module's packages
+var modulePrefix = strings.TrimSuffix(reflect.TypeOf(packageLevels{}).PkgPath(), "logging")
+
+// packageLevels discards events below the level of the package logging them
+type packageLevels struct {
+	fallback zerolog.Level
+	levels   map[string]zerolog.Level
+}
+
+// Run implements zerolog.Hook
+func (h packageLevels) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level < h.levelOf(callerPackage()) {
+		e.Discard()
+	}
+}
+
+// levelOf returns the level of the most specific configured package
+// containing pkg
+func (h packageLevels) levelOf(pkg string) zerolog.Level {
+	best, level := -1, h.fallback
+	for prefix, l := range h.levels {
+		if (pkg == prefix || strings.HasPrefix(pkg, prefix+"/")) && len(prefix) > best {
+			best, level = len(prefix), l
+		}
+	}
+	return level
+}
+
+// callerPackage returns the module-relative package of the first caller
+// outside zerolog and this package
+func callerPackage() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		pkg := packageOf(frame.Function)
+		if !strings.HasPrefix(pkg, "github.com/rs/zerolog") && pkg != modulePrefix+"logging" {
+			return strings.TrimPrefix(pkg, modulePrefix)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// packageOf returns the import path of a function name such as
+// runtime-dynamics/jobs.(*Queue).run
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
@@ -0,0 +1,165 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/tracing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNew_Formats(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		wantContains []string
+	}{
+		{name: "json", format: FormatJSON, wantContains: []string{`{"level":"warn","user":"ada","time":`, `"message":"slow query"}`}},
+		{name: "gcp", format: FormatGCP, wantContains: []string{`"severity":"WARNING"`, `"message":"slow query"`}},
+		{name: "console", format: FormatConsole, wantContains: []string{"WRN", "slow query", "user=", "ada"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, _, err := New(&buf, Options{Format: tt.format, Level: zerolog.InfoLevel})
+			assert.NoError(t, err)
+			logger.Warn().Str("user", "ada").Msg("slow query")
+			for _, want := range tt.wantContains {
+				assert.Contains(t, buf.String(), want)
+			}
+		})
+	}
+
+	_, _, err := New(&bytes.Buffer{}, Options{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestNew_GCPTrace(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	defer otel.SetTracerProvider(previous)
+
+	var buf bytes.Buffer
+	logger, _, _ := New(&buf, Options{Format: FormatGCP, Level: zerolog.InfoLevel, ProjectID: "acme"})
+	ctx, span := tracing.Start(context.Background(), "request")
+	defer span.End()
+	logger.Info().Ctx(ctx).Msg("traced")
+
+	assert.Contains(t, buf.String(), `"logging.googleapis.com/trace":"projects/acme/traces/`+tracing.TraceID(ctx)+`"`)
+	assert.Contains(t, buf.String(), `"logging.googleapis.com/spanId":"`+span.SpanContext().SpanID().String()+`"`)
+}
+
+func TestNew_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _, _ := New(&buf, Options{
+		Format:       FormatJSON,
+		Level:        zerolog.InfoLevel,
+		RedactFields: []string{"ssn"},
+		Secrets:      []string{"AIzaSecretKey123", "abc"},
+	})
+	logger.Info().
+		Str("password", "hunter2").
+		Str("X-Api-Key", "k-123").
+		Str("user_ssn", "123-45-6789").
+		Interface("request", map[string]any{"headers": map[string]any{"Authorization": "Bearer abc.def"}, "path": "/"}).
+		Err(errors.New("firebase rejected key AIzaSecretKey123")).
+		Msg("login with Bearer eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl for abc")
+
+	out := buf.String()
+	for _, leaked := range []string{"hunter2", "k-123", "123-45-6789", "Bearer abc.def", "AIzaSecretKey123", "eyJhbGciOi"} {
+		assert.NotContains(t, out, leaked)
+	}
+	assert.Contains(t, out, `"password":"[REDACTED]"`)
+	assert.Contains(t, out, `"path":"/"`)
+	assert.Contains(t, out, "for abc", "short secrets are not redacted")
+}
+
+func TestPackageLevels(t *testing.T) {
+	h := packageLevels{
+		fallback: zerolog.InfoLevel,
+		levels:   map[string]zerolog.Level{"web": zerolog.WarnLevel, "web/realtime": zerolog.DebugLevel, "jobs": zerolog.ErrorLevel},
+	}
+	tests := map[string]zerolog.Level{
+		"web":             zerolog.WarnLevel,
+		"web/app":         zerolog.WarnLevel,
+		"web/realtime":    zerolog.DebugLevel,
+		"webhooks":        zerolog.InfoLevel,
+		"jobs":            zerolog.ErrorLevel,
+		"data":            zerolog.InfoLevel,
+		"github.com/x/yz": zerolog.InfoLevel,
+	}
+	for pkg, want := range tests {
+		assert.Equal(t, want, h.levelOf(pkg), pkg)
+	}
+}
+
+func TestPackageOf(t *testing.T) {
+	tests := map[string]string{
+		"runtime-dynamics/jobs.(*Queue).run":       "runtime-dynamics/jobs",
+		"runtime-dynamics/web/realtime.Publish":    "runtime-dynamics/web/realtime",
+		"main.main":                                "main",
+		"github.com/rs/zerolog.(*Event).msg.func1": "github.com/rs/zerolog",
+	}
+	for function, want := range tests {
+		assert.Equal(t, want, packageOf(function), function)
+	}
+	assert.True(t, strings.HasSuffix(modulePrefix, "/"))
+}
+
+func TestOptionsFrom(t *testing.T) {
+	opts := OptionsFrom(&config.AppConfig{
+		LogFormat:      FormatGCP,
+		LogLevel:       "warn",
+		LogLevels:      map[string]string{"jobs": "debug"},
+		FirebaseAPIKey: "firebase-key",
+	})
+	assert.Equal(t, FormatGCP, opts.Format)
+	assert.Equal(t, zerolog.WarnLevel, opts.Level)
+	assert.Equal(t, map[string]zerolog.Level{"jobs": zerolog.DebugLevel}, opts.PackageLevels)
+	assert.Contains(t, opts.Secrets, "firebase-key")
+
+	_, minLevel, err := New(&bytes.Buffer{}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, zerolog.DebugLevel, minLevel)
+}
+
+func TestIsSensitive(t *testing.T) {
+	os.Setenv("LOG_REDACT_FIELDS", "ssn")
+	defer os.Unsetenv("LOG_REDACT_FIELDS")
+	assert.NoError(t, config.LoadConfig())
+
+	tests := map[string]bool{
+		"password":      true,
+		"X-Api-Key":     true,
+		"refresh_token": true,
+		"user_ssn":      true,
+		"title":         false,
+		"email":         false,
+	}
+	for field, want := range tests {
+		assert.Equal(t, want, IsSensitive(field), field)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	value := map[string]any{
+		"name":    "Ada",
+		"profile": map[string]any{"refresh_token": "r1", "bio": "Bearer abc123"},
+		"keys":    []any{map[string]any{"private_key": "pk"}},
+	}
+	assert.Equal(t, map[string]any{
+		"name":    "Ada",
+		"profile": map[string]any{"refresh_token": Redacted, "bio": "Bearer " + Redacted},
+		"keys":    []any{map[string]any{"private_key": Redacted}},
+	}, Redact(value))
+}
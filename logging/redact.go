@@ -0,0 +1,228 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"runtime-dynamics/config"
+)
+
+// Redacted replaces sensitive values in log output
+const Redacted = "[REDACTED]"
+
+// sensitiveFields are matched against field names with case, "_", "-" and
+// "." ignored, so api_key, apiKey and X-Api-Key are all redacted
+var sensitiveFields = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "apikey", "privatekey", "credential"}
+
+var (
+	// credentialPattern matches Authorization header values
+	credentialPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[a-z0-9._~+/=-]+`)
+	// jwtPattern matches JSON Web Tokens such as Firebase ID tokens
+	jwtPattern = regexp.MustCompile(`eyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]*`)
+)
+
+// gcpSeverity maps zerolog levels to Cloud Logging severities
+var gcpSeverity = map[string]string{
+	"trace": "DEBUG",
+	"debug": "DEBUG",
+	"info":  "INFO",
+	"warn":  "WARNING",
+	"error": "ERROR",
+	"fatal": "CRITICAL",
+	"panic": "ALERT",
+}
+
+// redactWriter rewrites each JSON event before passing it on: sensitive
+// fields and known secrets are redacted and, for the gcp format, fields are
+// renamed for Cloud Logging
+type redactWriter struct {
+	out       io.Writer
+	fields    []string
+	secrets   []string
+	gcp       bool
+	projectID string
+}
+
+func newRedactWriter(out io.Writer, opts Options) *redactWriter {
+	w := &redactWriter{out: out, gcp: opts.Format == FormatGCP, projectID: opts.ProjectID}
+	w.fields = append(w.fields, sensitiveFields...)
+	for _, field := range opts.RedactFields {
+		if field = normalizeField(field); field != "" {
+			w.fields = append(w.fields, field)
+		}
+	}
+	for _, secret := range opts.Secrets {
+		// very short values would redact unrelated text
+		if len(secret) >= 6 {
+			w.secrets = append(w.secrets, secret)
+		}
+	}
+	return w
+}
+
+// Write implements io.Writer. zerolog writes one event per call.
+func (w *redactWriter) Write(p []byte) (int, error) {
+	out, err := w.rewrite(p)
+	if err != nil {
+		// not a JSON object; still keep secrets out of the output
+		out = []byte(w.redactString(string(p)))
+	}
+	if _, err := w.out.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rewrite redacts a JSON event, keeping the order of its fields
+func (w *redactWriter) rewrite(p []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	first := true
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		if w.sensitive(key) {
+			value = Redacted
+		} else {
+			value = w.redactValue(value)
+		}
+		if w.gcp {
+			key, value = w.gcpField(key, value)
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		enc.Encode(key)
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(value); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// gcpField renames the level and trace fields for Cloud Logging
+func (w *redactWriter) gcpField(key string, value any) (string, any) {
+	switch key {
+	case "level":
+		if s, ok := value.(string); ok && gcpSeverity[s] != "" {
+			return "severity", gcpSeverity[s]
+		}
+		return "severity", value
+	case "trace_id":
+		if w.projectID != "" {
+			return "logging.googleapis.com/trace", "projects/" + w.projectID + "/traces/" + toString(value)
+		}
+		return "logging.googleapis.com/trace", value
+	case "span_id":
+		return "logging.googleapis.com/spanId", value
+	}
+	return key, value
+}
+
+// redactValue redacts strings, and sensitive fields of nested objects
+func (w *redactWriter) redactValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return w.redactString(v)
+	case map[string]any:
+		for key, nested := range v {
+			if w.sensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = w.redactValue(nested)
+			}
+		}
+	case []any:
+		for i, nested := range v {
+			v[i] = w.redactValue(nested)
+		}
+	}
+	return value
+}
+
+// redactString removes known secrets, credentials and tokens from s
+func (w *redactWriter) redactString(s string) string {
+	for _, secret := range w.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	s = credentialPattern.ReplaceAllString(s, "$1 "+Redacted)
+	return jwtPattern.ReplaceAllString(s, Redacted)
+}
+
+func (w *redactWriter) sensitive(key string) bool {
+	return matchesField(key, w.fields)
+}
+
+// Redact applies the rules of the log output to a decoded JSON value:
+// sensitive fields of nested objects, and credentials, tokens and the
+// configured secrets in strings, are replaced by Redacted. Maps and slices
+// are changed in place.
+func Redact(value any) any {
+	return newRedactWriter(nil, OptionsFrom(config.Get())).redactValue(value)
+}
+
+// IsSensitive reports whether values of the named field are redacted from
+// logs, by the built-in names or LOG_REDACT_FIELDS. Other records of field
+// values, such as audit diffs, use it to apply the same rules.
+func IsSensitive(field string) bool {
+	if matchesField(field, sensitiveFields) {
+		return true
+	}
+	if cfg := config.Get(); cfg != nil {
+		for _, extra := range cfg.LogRedactFields {
+			if extra = normalizeField(extra); extra != "" && matchesField(field, []string{extra}) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesField reports whether the normalized key contains one of fields
+func matchesField(key string, fields []string) bool {
+	key = normalizeField(key)
+	for _, field := range fields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeField lowercases a field name and drops separators
+func normalizeField(name string) string {
+	return strings.NewReplacer("_", "", "-", "", ".", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func toString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FileMailer writes every message as an .eml file, which most mail clients
+// open, instead of delivering it. It is the default in development.
+type FileMailer struct {
+	Dir string
+}
+
+// NewFileMailer creates a mailer writing to dir, created on first use
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{Dir: dir}
+}
+
+// Send writes msg to a new file in the directory
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := prepare(msg)
+	if err != nil {
+		return permanentError{err: err}
+	}
+	now := time.Now()
+	raw, err := encode(msg, now)
+	if err != nil {
+		return permanentError{err: err}
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("creating mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102-150405.000000"), fileSlug(msg.Subject))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+	log.Info().Strs("to", msg.To).Str("subject", msg.Subject).Str("file", path).Msg("email written to file")
+	return nil
+}
+
+// fileSlug turns a subject into a short file name part
+func fileSlug(subject string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(subject) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		return "email"
+	}
+	return slug
+}
+
+// MemoryMailer keeps sent messages in memory for tests
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryMailer creates an empty MemoryMailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send records msg after applying the same defaults and checks as the
+// other mailers
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := prepare(msg)
+	if err != nil {
+		return permanentError{err: err}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Reset forgets the sent messages
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
@@ -0,0 +1,214 @@
+// Package mail sends transactional email. Bodies are templ components
+// rendered to HTML, with a plaintext alternative derived from the HTML
+// unless one is given:
+//
+//	msg, err := mail.Compose(ctx, mail.Message{
+//		To:      []string{user.Email},
+//		Subject: "Confirm your email",
+//	}, emails.Confirm(user.Name, confirmURL))
+//	if err == nil {
+//		err = mail.Enqueue(ctx, msg)
+//	}
+//
+// The transport is chosen by MAIL_TRANSPORT: smtp for production, file to
+// write .eml files in development and memory for tests.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"strings"
+	"sync"
+
+	"runtime-dynamics/config"
+
+	"github.com/a-h/templ"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultFrom is the sender when neither the message nor MAIL_FROM sets one
+const DefaultFrom = "no-reply@localhost"
+
+// Message is an email. Addresses may include a display name,
+// e.g. "Ada Lovelace <ada@example.com>".
+type Message struct {
+	// From defaults to MAIL_FROM
+	From    string   `json:"from,omitempty"`
+	To      []string `json:"to"`
+	Cc      []string `json:"cc,omitempty"`
+	Bcc     []string `json:"bcc,omitempty"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html,omitempty"`
+	Text    string   `json:"text,omitempty"`
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Compose renders body into the HTML part of msg. The plaintext part is
+// derived from the HTML unless msg.Text is set.
+func Compose(ctx context.Context, msg Message, body templ.Component) (Message, error) {
+	var buf bytes.Buffer
+	if err := body.Render(ctx, &buf); err != nil {
+		return msg, fmt.Errorf("rendering email %q: %w", msg.Subject, err)
+	}
+	msg.HTML = buf.String()
+	if msg.Text == "" {
+		msg.Text = PlainText(msg.HTML)
+	}
+	return msg, nil
+}
+
+// prepare applies the default sender and validates msg; every Mailer calls
+// it before delivery
+func prepare(msg Message) (Message, error) {
+	if msg.From == "" {
+		msg.From = DefaultFrom
+		if cfg := config.Get(); cfg != nil && cfg.MailFrom != "" {
+			msg.From = cfg.MailFrom
+		}
+	}
+	if msg.Text == "" && msg.HTML != "" {
+		msg.Text = PlainText(msg.HTML)
+	}
+	return msg, msg.Validate()
+}
+
+// Validate checks that msg has parseable addresses, at least one recipient,
+// a single-line subject and a body
+func (m Message) Validate() error {
+	var problems []string
+	if _, err := netmail.ParseAddress(m.From); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid sender %q", m.From))
+	}
+	if m.ReplyTo != "" {
+		if _, err := netmail.ParseAddress(m.ReplyTo); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid reply-to address %q", m.ReplyTo))
+		}
+	}
+	recipients := 0
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, addr := range list {
+			if _, err := netmail.ParseAddress(addr); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid recipient %q", addr))
+			}
+			recipients++
+		}
+	}
+	if recipients == 0 {
+		problems = append(problems, "no recipients")
+	}
+	if strings.TrimSpace(m.Subject) == "" {
+		problems = append(problems, "no subject")
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		problems = append(problems, "subject contains a line break")
+	}
+	if m.HTML == "" && m.Text == "" {
+		problems = append(problems, "no body")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid email: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// recipients returns the bare addresses of all recipients, Bcc included
+func (m Message) recipients() []string {
+	var addrs []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, addr := range list {
+			if a, err := parseAddress(addr); err == nil {
+				addrs = append(addrs, a)
+			}
+		}
+	}
+	return addrs
+}
+
+// parseAddress returns the bare address of addr
+func parseAddress(addr string) (string, error) {
+	a, err := netmail.ParseAddress(addr)
+	if err != nil {
+		return "", err
+	}
+	return a.Address, nil
+}
+
+var (
+	defaultMailer Mailer
+	defaultLock   = new(sync.RWMutex)
+)
+
+// Default returns the mailer configured by MAIL_TRANSPORT, created on first
+// use. Without loaded configuration it is a MemoryMailer.
+func Default() Mailer {
+	defaultLock.RLock()
+	m := defaultMailer
+	defaultLock.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	if defaultMailer == nil {
+		defaultMailer = FromConfig(config.Get())
+	}
+	return defaultMailer
+}
+
+// SetDefault replaces the default mailer, e.g. with a MemoryMailer in tests
+func SetDefault(m Mailer) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultMailer = m
+}
+
+// FromConfig creates the mailer selected by cfg
+func FromConfig(cfg *config.AppConfig) Mailer {
+	if cfg == nil {
+		return NewMemoryMailer()
+	}
+	switch cfg.MailTransport {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+	case "memory":
+		return NewMemoryMailer()
+	default:
+		log.Info().Str("dir", cfg.MailDir).Msg("Emails are written to files, set SMTP_HOST to deliver them")
+		return NewFileMailer(cfg.MailDir)
+	}
+}
+
+// Send delivers msg now with the default mailer. Prefer Enqueue in request
+// handlers so a slow or failing mail server does not affect the response.
+func Send(ctx context.Context, msg Message) error {
+	return Default().Send(ctx, msg)
+}
+
+// permanentError marks delivery failures that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// IsPermanent reports whether a Send error will recur on retry, such as an
+// invalid message or a rejected recipient
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
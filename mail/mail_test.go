@@ -0,0 +1,190 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"mime"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func validMessage() Message {
+	return Message{
+		From:    "App <app@example.com>",
+		To:      []string{"Ada <ada@example.com>"},
+		Subject: "Hello",
+		HTML:    "<p>Hi Ada</p>",
+	}
+}
+
+func TestMessage_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Message)
+		wantErr string
+	}{
+		{name: "valid", modify: func(m *Message) {}},
+		{name: "bcc only", modify: func(m *Message) { m.To = nil; m.Bcc = []string{"b@example.com"} }},
+		{name: "no recipients", modify: func(m *Message) { m.To = nil }, wantErr: "no recipients"},
+		{name: "bad recipient", modify: func(m *Message) { m.To = []string{"not an address"} }, wantErr: "invalid recipient"},
+		{name: "bad sender", modify: func(m *Message) { m.From = "nobody" }, wantErr: "invalid sender"},
+		{name: "bad reply-to", modify: func(m *Message) { m.ReplyTo = "x" }, wantErr: "invalid reply-to"},
+		{name: "no subject", modify: func(m *Message) { m.Subject = " " }, wantErr: "no subject"},
+		{name: "header injection", modify: func(m *Message) { m.Subject = "Hi\r\nBcc: x@example.com" }, wantErr: "line break"},
+		{name: "no body", modify: func(m *Message) { m.HTML = "" }, wantErr: "no body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := validMessage()
+			tt.modify(&msg)
+			err := msg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompose(t *testing.T) {
+	body := templ.Raw(`<h1>Welcome</h1><p>Open <a href="https://example.com/app">the app</a>.</p>`)
+
+	msg, err := Compose(context.Background(), Message{Subject: "Hi"}, body)
+	assert.NoError(t, err)
+	assert.Contains(t, msg.HTML, "<h1>Welcome</h1>")
+	assert.Equal(t, "Welcome\n\nOpen the app (https://example.com/app).", msg.Text)
+
+	msg, err = Compose(context.Background(), Message{Subject: "Hi", Text: "custom"}, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", msg.Text)
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "hidden elements and whitespace",
+			html: "<html><head><title>T</title><style>p{}</style></head><body>\n  <p>Hello\n   world</p></body></html>",
+			want: "Hello world",
+		},
+		{
+			name: "lists and breaks",
+			html: "<p>Steps:</p><ul>\n<li>One</li>\n<li> Two </li></ul>Line<br>break",
+			want: "Steps:\n\n- One\n- Two\n\nLine\nbreak",
+		},
+		{
+			name: "links",
+			html: `<a href="https://x.test">https://x.test</a> <a href="#top">top</a> <a href="mailto:a@b.c">mail</a> <a href="/a">A</a>`,
+			want: "https://x.test top mail A (/a)",
+		},
+		{
+			name: "images and entities",
+			html: `<img src="logo.png" alt="Acme"> &amp; friends`,
+			want: "Acme & friends",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PlainText(tt.html))
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	msg := validMessage()
+	msg.Subject = "Grüße"
+	msg.Cc = []string{"cc@example.com"}
+	msg.Bcc = []string{"secret@example.com"}
+	msg.ReplyTo = "support@example.com"
+	msg.Text = "Hi Ada"
+
+	raw, err := encode(msg, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret@example.com")
+
+	parsed, err := netmail.ReadMessage(strings.NewReader(string(raw)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Grüße", subject)
+	assert.Equal(t, `"App" <app@example.com>`, parsed.Header.Get("From"))
+	assert.Equal(t, `"Ada" <ada@example.com>`, parsed.Header.Get("To"))
+	assert.Equal(t, "<cc@example.com>", parsed.Header.Get("Cc"))
+	assert.Equal(t, "<support@example.com>", parsed.Header.Get("Reply-To"))
+	assert.Equal(t, "Thu, 02 Jan 2025 03:04:05 +0000", parsed.Header.Get("Date"))
+	assert.True(t, strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>"))
+	assert.Contains(t, parsed.Header.Get("Content-Type"), "multipart/alternative")
+
+	body, _ := io.ReadAll(parsed.Body)
+	plain := strings.Index(string(body), "text/plain")
+	html := strings.Index(string(body), "text/html")
+	assert.True(t, plain >= 0 && html > plain, "plain text part before HTML part")
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+	msg := validMessage()
+	msg.From = ""
+
+	assert.NoError(t, m.Send(context.Background(), msg))
+	sent := m.Messages()
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, DefaultFrom, sent[0].From)
+		assert.Equal(t, "Hi Ada", sent[0].Text)
+	}
+
+	err := m.Send(context.Background(), Message{Subject: "x"})
+	assert.True(t, IsPermanent(err))
+	assert.Len(t, m.Messages(), 1)
+
+	m.Reset()
+	assert.Empty(t, m.Messages())
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := NewFileMailer(dir)
+	msg := validMessage()
+	msg.Subject = "Reset your password!"
+
+	assert.NoError(t, m.Send(context.Background(), msg))
+	files, err := os.ReadDir(dir)
+	if !assert.NoError(t, err) || !assert.Len(t, files, 1) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(files[0].Name(), "-reset-your-password.eml"), files[0].Name())
+	raw, _ := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	assert.Contains(t, string(raw), "Subject: Reset your password!")
+}
+
+func TestPreviews(t *testing.T) {
+	RegisterPreview("test-preview", func(ctx context.Context) (Message, error) {
+		return Compose(ctx, Message{To: []string{"a@example.com"}, Subject: "Preview"}, templ.Raw("<p>Body</p>"))
+	})
+
+	assert.Contains(t, Previews(), "test-preview")
+	msg, err := RenderPreview(context.Background(), "test-preview")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultFrom, msg.From)
+	assert.Equal(t, "Body", msg.Text)
+
+	_, err = RenderPreview(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNoPreview)
+}
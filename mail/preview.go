@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrNoPreview is returned by RenderPreview for unknown names
+var ErrNoPreview = errors.New("no such email preview")
+
+// Preview builds an example message, shown by the development preview at
+// /app/dev/mail
+type Preview func(ctx context.Context) (Message, error)
+
+var (
+	previews     = make(map[string]Preview)
+	previewsLock = new(sync.RWMutex)
+)
+
+// RegisterPreview adds an email to the development preview. Register every
+// email template with sample data so its layout can be checked in a browser.
+func RegisterPreview(name string, preview Preview) {
+	previewsLock.Lock()
+	defer previewsLock.Unlock()
+	previews[name] = preview
+}
+
+// Previews returns the registered preview names, sorted
+func Previews() []string {
+	previewsLock.RLock()
+	defer previewsLock.RUnlock()
+	names := make([]string, 0, len(previews))
+	for name := range previews {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// RenderPreview builds the preview registered as name, with the same
+// defaults Send applies
+func RenderPreview(ctx context.Context, name string) (Message, error) {
+	previewsLock.RLock()
+	preview, ok := previews[name]
+	previewsLock.RUnlock()
+	if !ok {
+		return Message{}, ErrNoPreview
+	}
+	msg, err := preview(ctx)
+	if err != nil {
+		return msg, err
+	}
+	return prepare(msg)
+}
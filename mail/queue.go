@@ -0,0 +1,28 @@
+package mail
+
+import (
+	"context"
+	"time"
+
+	"runtime-dynamics/jobs"
+)
+
+// sendJob delivers enqueued messages with the default mailer
+var sendJob = jobs.Register("mail.send", func(ctx context.Context, msg Message) error {
+	err := Send(ctx, msg)
+	if IsPermanent(err) {
+		return jobs.Permanent(err)
+	}
+	return err
+}, jobs.Options{MaxAttempts: 8, Timeout: time.Minute})
+
+// Enqueue sends msg from the background job queue, retrying while the mail
+// server is unavailable. Invalid messages are rejected right away.
+func Enqueue(ctx context.Context, msg Message) error {
+	msg, err := prepare(msg)
+	if err != nil {
+		return err
+	}
+	_, err = sendJob.Enqueue(ctx, msg)
+	return err
+}
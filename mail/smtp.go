@@ -0,0 +1,131 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultSMTPTimeout bounds a delivery when the context has no deadline
+const DefaultSMTPTimeout = 30 * time.Second
+
+// SMTPMailer delivers messages through an SMTP server. Port 465 uses
+// implicit TLS; other ports upgrade with STARTTLS when the server offers it.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// TLSConfig overrides the TLS settings, e.g. in tests
+	TLSConfig *tls.Config
+}
+
+// NewSMTPMailer creates a mailer for the server at host:port. Credentials
+// are only sent over TLS or to localhost.
+func NewSMTPMailer(host string, port int, username string, password string) *SMTPMailer {
+	return &SMTPMailer{Host: host, Port: port, Username: username, Password: password}
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	if m.TLSConfig != nil {
+		return m.TLSConfig
+	}
+	return &tls.Config{ServerName: m.Host}
+}
+
+// Send delivers msg. Errors the server reports as permanent (5xx replies),
+// such as an unknown recipient, satisfy IsPermanent.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := prepare(msg)
+	if err != nil {
+		return permanentError{err: err}
+	}
+	raw, err := encode(msg, time.Now())
+	if err != nil {
+		return permanentError{err: err}
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultSMTPTimeout)
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	if m.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: m.tlsConfig()}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	conn.SetDeadline(deadline)
+	// closing the connection aborts the exchange when ctx is canceled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return smtpError("greeting", err)
+	}
+	defer client.Close()
+
+	if m.Port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(m.tlsConfig()); err != nil {
+				return smtpError("STARTTLS", err)
+			}
+		}
+	}
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return smtpError("AUTH", err)
+		}
+	}
+
+	from, _ := parseAddress(msg.From)
+	if err := client.Mail(from); err != nil {
+		return smtpError("MAIL FROM", err)
+	}
+	for _, rcpt := range msg.recipients() {
+		if err := client.Rcpt(rcpt); err != nil {
+			return smtpError("RCPT TO "+rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError("DATA", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return smtpError("DATA", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("DATA", err)
+	}
+	if err := client.Quit(); err != nil {
+		// the message was accepted; a failed QUIT does not undo that
+		log.Debug().Err(err).Str("host", m.Host).Msg("SMTP QUIT failed")
+	}
+	log.Debug().Strs("to", msg.To).Str("subject", msg.Subject).Msg("email sent")
+	return nil
+}
+
+// smtpError wraps err from step, marking 5xx replies as permanent
+func smtpError(step string, err error) error {
+	err = fmt.Errorf("SMTP %s: %w", step, err)
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return permanentError{err: err}
+	}
+	return err
+}
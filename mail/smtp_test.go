@@ -0,0 +1,128 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTP is a minimal SMTP server recording one session
+type fakeSMTP struct {
+	addr string
+	// rcptReply answers RCPT TO, "250 OK" when empty
+	rcptReply string
+
+	done chan struct{}
+	from string
+	rcpt []string
+	data string
+}
+
+func startFakeSMTP(t *testing.T, rcptReply string) *fakeSMTP {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	s := &fakeSMTP{addr: l.Addr().String(), rcptReply: rcptReply, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(textproto.NewConn(conn))
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(c *textproto.Conn) {
+	c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			c.PrintfLine("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.from = line[len("MAIL FROM:"):]
+			c.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			if s.rcptReply != "" {
+				c.PrintfLine("%s", s.rcptReply)
+				continue
+			}
+			s.rcpt = append(s.rcpt, line[len("RCPT TO:"):])
+			c.PrintfLine("250 OK")
+		case cmd == "DATA":
+			c.PrintfLine("354 Go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			c.PrintfLine("250 Queued")
+		case cmd == "QUIT":
+			c.PrintfLine("221 Bye")
+			return
+		default:
+			c.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTP) mailer() *SMTPMailer {
+	host, port, _ := net.SplitHostPort(s.addr)
+	p, _ := strconv.Atoi(port)
+	return NewSMTPMailer(host, p, "", "")
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := startFakeSMTP(t, "")
+	msg := validMessage()
+	msg.Bcc = []string{"audit@example.com"}
+
+	err := server.mailer().Send(context.Background(), msg)
+	<-server.done
+
+	assert.NoError(t, err)
+	assert.Contains(t, server.from, "<app@example.com>")
+	assert.Equal(t, []string{"<ada@example.com>", "<audit@example.com>"}, server.rcpt)
+	assert.Contains(t, server.data, "Subject: Hello")
+	assert.NotContains(t, server.data, "audit@example.com")
+}
+
+func TestSMTPMailer_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		reply         string
+		wantPermanent bool
+	}{
+		{name: "unknown recipient", reply: "550 No such user", wantPermanent: true},
+		{name: "mailbox busy", reply: "450 Try again later", wantPermanent: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startFakeSMTP(t, tt.reply)
+			err := server.mailer().Send(context.Background(), validMessage())
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.wantPermanent, IsPermanent(err))
+			}
+		})
+	}
+}
+
+func TestSMTPMailer_InvalidMessage(t *testing.T) {
+	err := NewSMTPMailer("127.0.0.1", 1, "", "").Send(context.Background(), Message{})
+	assert.True(t, IsPermanent(err))
+}
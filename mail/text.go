@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	spaceRun    = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	blockBreaks = map[string]string{
+		"p": "\n\n", "h1": "\n\n", "h2": "\n\n", "h3": "\n\n", "h4": "\n\n", "h5": "\n\n", "h6": "\n\n",
+		"table": "\n\n", "ul": "\n\n", "ol": "\n\n", "blockquote": "\n\n",
+		"div": "\n", "tr": "\n", "li": "\n", "section": "\n", "header": "\n", "footer": "\n",
+	}
+	// hidden elements have no readable text
+	hidden = map[string]bool{"head": true, "title": true, "style": true, "script": true}
+)
+
+// PlainText converts an HTML email body to readable plain text: block
+// elements become line breaks, list items get a dash and link targets are
+// written after the link text.
+func PlainText(body string) string {
+	var out strings.Builder
+	var links []string
+	skip := 0
+
+	z := html.NewTokenizer(strings.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if hidden[token.Data] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			switch token.Data {
+			case "br":
+				out.WriteString("\n")
+			case "hr":
+				out.WriteString("\n\n----\n\n")
+			case "li":
+				out.WriteString("\n- ")
+			case "td", "th":
+				out.WriteString(" ")
+			case "img":
+				if alt := attr(token, "alt"); alt != "" {
+					out.WriteString(alt)
+				}
+			case "a":
+				links = append(links, attr(token, "href"))
+			default:
+				out.WriteString(blockBreaks[token.Data])
+			}
+		case html.EndTagToken:
+			if hidden[token.Data] {
+				skip = max(skip-1, 0)
+				continue
+			}
+			switch token.Data {
+			case "a":
+				if len(links) == 0 {
+					continue
+				}
+				href := links[len(links)-1]
+				links = links[:len(links)-1]
+				if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "mailto:") &&
+					!strings.HasSuffix(strings.TrimSpace(out.String()), href) {
+					out.WriteString(" (" + href + ")")
+				}
+			case "li":
+			default:
+				out.WriteString(blockBreaks[token.Data])
+			}
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			text := spaceRun.ReplaceAllString(token.Data, " ")
+			if current := out.String(); current == "" || strings.HasSuffix(current, " ") || strings.HasSuffix(current, "\n") {
+				text = strings.TrimLeft(text, " ")
+			}
+			out.WriteString(text)
+		}
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
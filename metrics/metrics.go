@@ -0,0 +1,137 @@
+// Package metrics collects the Prometheus metrics served at /metrics: HTTP
+// requests by route template, templ render durations, Datastore operations,
+// cache hits and Go runtime and process statistics.
+//
+// Add application metrics with Register:
+//
+//	var signups = prometheus.NewCounter(prometheus.CounterOpts{
+//		Name: "app_signups_total",
+//		Help: "Completed sign-ups.",
+//	})
+//
+//	func init() {
+//		metrics.Register(signups)
+//	}
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// UnmatchedRoute is the route label of requests that matched no route, so
+// scanners probing random paths do not create new series
+const UnmatchedRoute = "unmatched"
+
+// latencyBuckets cover fast cached responses up to slow Datastore queries
+var latencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status code.",
+		Buckets: latencyBuckets,
+	}, []string{"method", "route", "status"})
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests being served, including open event streams.",
+	})
+	renderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "templ_render_duration_seconds",
+		Help:    "Time to render templ pages and fragments by route template.",
+		Buckets: latencyBuckets,
+	}, []string{"route"})
+	dataOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "datastore_operations_total",
+		Help: "Datastore operations by operation, entity kind and result.",
+	}, []string{"operation", "kind", "result"})
+	dataDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "datastore_operation_duration_seconds",
+		Help:    "Datastore operation latency by operation and entity kind.",
+		Buckets: latencyBuckets,
+	}, []string{"operation", "kind"})
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Cache reads by key namespace and result.",
+	}, []string{"namespace", "result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, httpInFlight,
+		renderDuration,
+		dataOperations, dataDuration,
+		cacheRequests,
+	)
+}
+
+// Register adds application collectors to the /metrics output. It panics
+// when a collector with the same name is already registered.
+func Register(cs ...prometheus.Collector) {
+	registry.MustRegister(cs...)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// RequestStarted counts a request as in flight until the returned function
+// is called
+func RequestStarted() func() {
+	httpInFlight.Inc()
+	return httpInFlight.Dec
+}
+
+// ObserveRequest records a served request. route is the route template,
+// e.g. /app/admin/:entity, never the raw path.
+func ObserveRequest(method string, route string, status int, d time.Duration) {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(d.Seconds())
+}
+
+// ObserveRender records the time taken to render a templ component for route
+func ObserveRender(route string, d time.Duration) {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	renderDuration.WithLabelValues(route).Observe(d.Seconds())
+}
+
+// ObserveDataOperation records a Datastore operation on entities of kind;
+// result is "ok" or "error"
+func ObserveDataOperation(operation string, kind string, err error, d time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	dataOperations.WithLabelValues(operation, kind, result).Inc()
+	dataDuration.WithLabelValues(operation, kind).Observe(d.Seconds())
+}
+
+// ObserveCache records a cache read of a key in namespace, e.g. "entity"
+// or "fragment"; result is "hit" or "miss"
+func ObserveCache(namespace string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(namespace, result).Inc()
+}
@@ -2,6 +2,10 @@ package services
 
 import (
 	"context"
+
+	"runtime-dynamics/audit"
+
+	"github.com/rs/zerolog/log"
 )
 
 // Service is the base interface that all services should implement
@@ -25,3 +29,11 @@ func NewBaseService(ctx context.Context) *BaseService {
 func (s *BaseService) Context() context.Context {
 	return s.ctx
 }
+
+// RecordAudit appends entry to the audit log after a change was saved. The
+// change stands when recording fails, so the failure is logged, not returned.
+func (s *BaseService) RecordAudit(ctx context.Context, entry audit.Entry) {
+	if err := audit.Record(ctx, entry); err != nil {
+		log.Error().Ctx(ctx).Err(err).Str("action", entry.Action).Str("target_id", entry.TargetID).Msg("failed to record audit event")
+	}
+}
@@ -0,0 +1,135 @@
+// Package tracing records OpenTelemetry spans for requests, templ renders,
+// service calls and Datastore operations. Incoming W3C traceparent headers
+// continue the caller's trace, and logs written with Ctx(ctx) carry the
+// trace and span IDs.
+//
+// Services wrap each operation in a span:
+//
+//	func (s *NoteService) Create(input NoteInput) (_ *data.Note, err error) {
+//		ctx, span := tracing.Start(s.ctx, "NoteService.Create")
+//		defer tracing.End(span, &err)
+//		...
+//	}
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/errs"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is the service.name of exported spans unless
+// OTEL_SERVICE_NAME is set
+const ServiceName = "runtime-dynamics"
+
+// instrumentationName identifies the tracer of this application
+const instrumentationName = "runtime-dynamics"
+
+// Setup installs the W3C trace context propagator and, when
+// TRACING_EXPORTER is set, a tracer provider exporting spans over OTLP or
+// to stdout. Call the returned function on shutdown to flush buffered spans.
+func Setup(ctx context.Context, cfg *config.AppConfig, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	noop := func(context.Context) error { return nil }
+	if cfg == nil || cfg.TracingExporter == "" {
+		return noop, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.TracingExporter {
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.TracingEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.TracingEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		err = fmt.Errorf("unknown exporter %q", cfg.TracingExporter)
+	}
+	if err != nil {
+		return noop, fmt.Errorf("creating trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(ServiceName), semconv.ServiceVersion(version)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("creating trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// follow the caller's sampling decision, sample new traces by ratio
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the application tracer of the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start begins a span as a child of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records *errp on span and ends it. Typed client errors such as not
+// found or validation failures are recorded without failing the span.
+func End(span trace.Span, errp *error) {
+	if errp != nil && *errp != nil {
+		Fail(span, *errp)
+	}
+	span.End()
+}
+
+// Fail records err on span, marking the span failed unless err is a typed
+// client error
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	if typed, ok := errs.As(err); ok && typed.Kind != errs.KindInternal {
+		return
+	}
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceID returns the ID of the trace in ctx, or "" outside of a trace
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// LogHook adds trace_id and span_id to events logged with Ctx(ctx), e.g.
+// log.Info().Ctx(ctx).Msg("...")
+type LogHook struct{}
+
+// Run implements zerolog.Hook
+func (LogHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	sc := trace.SpanContextFromContext(e.GetCtx())
+	if !sc.IsValid() {
+		return
+	}
+	e.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+}
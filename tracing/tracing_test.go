@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/errs"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider keeping finished spans in memory
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+		wantEvents int
+	}{
+		{name: "success", err: nil, wantStatus: codes.Unset, wantEvents: 0},
+		{name: "internal error", err: errors.New("datastore unavailable"), wantStatus: codes.Error, wantEvents: 1},
+		{name: "client error", err: errs.NotFound("note not found"), wantStatus: codes.Unset, wantEvents: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := recordSpans(t)
+			_, span := Start(context.Background(), "NoteService.GetByID")
+			err := tt.err
+			End(span, &err)
+
+			spans := exporter.GetSpans()
+			if assert.Len(t, spans, 1) {
+				assert.Equal(t, "NoteService.GetByID", spans[0].Name)
+				assert.Equal(t, tt.wantStatus, spans[0].Status.Code)
+				assert.Len(t, spans[0].Events, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestLogHook(t *testing.T) {
+	recordSpans(t)
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Hook(LogHook{})
+
+	ctx, span := Start(context.Background(), "request")
+	defer span.End()
+	logger.Info().Ctx(ctx).Msg("in trace")
+	logger.Info().Msg("without context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"trace_id":"`+TraceID(ctx)+`"`)
+		assert.Contains(t, lines[0], `"span_id":"`+span.SpanContext().SpanID().String()+`"`)
+		assert.NotContains(t, lines[1], "trace_id")
+	}
+	assert.Empty(t, TraceID(context.Background()))
+}
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	tests := []struct {
+		name      string
+		cfg       *config.AppConfig
+		expectErr bool
+	}{
+		{name: "no config", cfg: nil},
+		{name: "disabled", cfg: &config.AppConfig{}},
+		{name: "stdout", cfg: &config.AppConfig{TracingExporter: "stdout", TracingSampleRatio: 1}},
+		{name: "otlp", cfg: &config.AppConfig{TracingExporter: "otlp", TracingEndpoint: "http://localhost:4318", TracingSampleRatio: 1}},
+		{name: "unknown", cfg: &config.AppConfig{TracingExporter: "zipkin"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tt.cfg, "test")
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, shutdown(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package layouts
+
+import (
+	"sync"
+
+	"github.com/a-h/templ"
+	"runtime-dynamics/config"
+)
+
+// NavItem is a link in the header navigation or footer
+type NavItem struct {
+	Label string
+	Href  string
+	// External opens the link in a new tab
+	External bool
+	// Primary renders the item as a call-to-action button
+	Primary bool
+}
+
+// FooterColumn is a titled group of footer links
+type FooterColumn struct {
+	Title string
+	Links []NavItem
+}
+
+// Site holds the branding and navigation shared by every page, so apps built
+// on this template can rebrand without editing the layout templ files
+type Site struct {
+	Name          string
+	Tagline       string
+	LogoURL       string
+	Nav           []NavItem
+	FooterColumns []FooterColumn
+	FooterLinks   []NavItem
+	Copyright     string
+}
+
+// Slots are optional components a page places into named regions of the layout
+type Slots struct {
+	// Head is rendered at the end of <head>, e.g. page specific scripts
+	Head templ.Component
+	// HeaderActions is rendered at the right of the header navigation
+	HeaderActions templ.Component
+	// Sidebar is rendered in an <aside> next to the main content
+	Sidebar templ.Component
+}
+
+var (
+	site     *Site
+	siteLock = new(sync.RWMutex)
+)
+
+// DefaultSite returns the template branding, using the configured site name,
+// tagline and logo when set
+func DefaultSite() Site {
+	s := Site{
+		Name:    "H.A.T. Stack App",
+		Tagline: "A modern Go web application built with HTMX, Alpine.js, and Templ.",
+		// link only to routes the template registers; apps add their own
+		// pages with SetSite
+		Nav: []NavItem{
+			{Label: "Home", Href: "/"},
+			{Label: "API Docs", Href: "/api/docs", Primary: true},
+		},
+		FooterColumns: []FooterColumn{
+			{Title: "Resources", Links: []NavItem{
+				{Label: "API Docs", Href: "/api/docs"},
+				{Label: "OpenAPI Spec", Href: "/api/openapi.json"},
+				{Label: "Sitemap", Href: "/sitemap.xml"},
+			}},
+			{Title: "Community", Links: []NavItem{
+				{Label: "GitHub", Href: "https://github.com", External: true},
+			}},
+		},
+		Copyright: "© 2025 Your Company. Built with the H.A.T. Stack.",
+	}
+	if cfg := config.Get(); cfg != nil {
+		if cfg.SiteName != "" {
+			s.Name = cfg.SiteName
+		}
+		if cfg.SiteTagline != "" {
+			s.Tagline = cfg.SiteTagline
+		}
+		s.LogoURL = cfg.SiteLogoURL
+	}
+	return s
+}
+
+// SetSite replaces the site branding used by all layouts
+func SetSite(s Site) {
+	siteLock.Lock()
+	defer siteLock.Unlock()
+	site = &s
+}
+
+// CurrentSite returns the branding set with SetSite, or DefaultSite
+func CurrentSite() Site {
+	siteLock.RLock()
+	defer siteLock.RUnlock()
+	if site == nil {
+		return DefaultSite()
+	}
+	return *site
+}
+
+// externalAttrs opens external links in a new tab
+func externalAttrs(item NavItem) templ.Attributes {
+	if !item.External {
+		return templ.Attributes{}
+	}
+	return templ.Attributes{"target": "_blank", "rel": "noopener noreferrer"}
+}
+
+// htmxConfig swaps successful responses and 422 form errors; other errors
+// are left to htmx's error events
+const htmxConfig = `{"responseHandling":[{"code":"204","swap":false},{"code":"[23]..","swap":true},{"code":"422","swap":true},{"code":"[45]..","swap":false,"error":true}]}`
@@ -0,0 +1,108 @@
+package layouts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/stretchr/testify/assert"
+	"runtime-dynamics/config"
+	"runtime-dynamics/web/app/seo"
+)
+
+func render(t *testing.T, c templ.Component) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := c.Render(context.Background(), &buf); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	return buf.String()
+}
+
+func text(s string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	})
+}
+
+func TestDefaultSite_FromConfig(t *testing.T) {
+	os.Setenv("SITE_NAME", "Acme")
+	os.Setenv("SITE_LOGO_URL", "/images/acme.png")
+	defer func() {
+		os.Unsetenv("SITE_NAME")
+		os.Unsetenv("SITE_LOGO_URL")
+		config.LoadConfig()
+	}()
+	assert.NoError(t, config.LoadConfig())
+
+	s := DefaultSite()
+	assert.Equal(t, "Acme", s.Name)
+	assert.Equal(t, "/images/acme.png", s.LogoURL)
+	assert.NotEmpty(t, s.Nav)
+}
+
+func TestSetSite(t *testing.T) {
+	defer func() {
+		siteLock.Lock()
+		site = nil
+		siteLock.Unlock()
+	}()
+
+	SetSite(Site{Name: "Branded"})
+	assert.Equal(t, "Branded", CurrentSite().Name)
+
+	body := render(t, Base(seo.Meta{Title: "Home"}))
+	assert.Contains(t, body, "Branded")
+	assert.NotContains(t, body, "Zero Sum Expanse")
+}
+
+func TestDocument_SiteChrome(t *testing.T) {
+	s := Site{
+		Name:    "Acme",
+		LogoURL: "/images/acme.png",
+		Nav:     []NavItem{{Label: "Pricing", Href: "/pricing"}, {Label: "Sign Up", Href: "/app/login", Primary: true}},
+		FooterColumns: []FooterColumn{
+			{Title: "Company", Links: []NavItem{{Label: "Blog", Href: "https://blog.example.com", External: true}}},
+		},
+		FooterLinks: []NavItem{{Label: "Privacy", Href: "/privacy"}},
+		Copyright:   "© Acme Inc.",
+	}
+
+	body := render(t, Document(seo.Meta{Title: "Pricing"}, s, Slots{}))
+
+	assert.Contains(t, body, `<img src="/images/acme.png" alt="Acme Logo"`)
+	assert.Contains(t, body, `href="/pricing"`)
+	assert.Contains(t, body, `href="/app/login"`)
+	assert.Contains(t, body, `<h4 class="font-bold mb-4 text-white">Company</h4>`)
+	assert.Contains(t, body, `target="_blank"`)
+	assert.Contains(t, body, `href="/privacy"`)
+	assert.Contains(t, body, "© Acme Inc.")
+	assert.NotContains(t, body, "<aside")
+	assert.Contains(t, body, `<meta name="htmx-config"`)
+}
+
+func TestDocument_Slots(t *testing.T) {
+	slots := Slots{
+		Head:          text(`<link rel="stylesheet" href="/css/page.css">`),
+		HeaderActions: text(`<a href="/app/login">Sign in</a>`),
+		Sidebar:       text(`<ul id="toc"></ul>`),
+	}
+
+	body := render(t, Document(seo.Meta{Title: "Docs"}, Site{Name: "Acme"}, slots))
+
+	head := body[:bytes.Index([]byte(body), []byte("</head>"))]
+	assert.Contains(t, head, `href="/css/page.css"`)
+	assert.Contains(t, body, `<a href="/app/login">Sign in</a>`)
+	assert.Contains(t, body, `<ul id="toc"></ul></aside>`)
+}
+
+func TestBaseWithUser(t *testing.T) {
+	body := render(t, BaseWithUser(seo.Meta{Title: "Dashboard"}, "user@example.com"))
+
+	assert.Contains(t, body, "user@example.com")
+	assert.Contains(t, body, `hx-post="/api/auth/logout"`)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"runtime-dynamics/errs"
+	"runtime-dynamics/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireUser rejects anonymous requests with a 401 problem. Use it with
+// versionGroup.With, after middleware.Authenticate has run.
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if middleware.GetUser(c) == nil {
+			renderError(c, errs.Unauthorized("sign in to continue"), http.StatusUnauthorized, "anonymous request rejected")
+			c.Abort()
+		}
+	}
+}
+
+// RejectCrossSite rejects requests a browser sent from another site with a
+// 403 problem, so a signed-in user's cookies cannot be used to write from
+// elsewhere. Clients sending neither Sec-Fetch-Site nor Origin pass, as
+// non-browser clients do not.
+func RejectCrossSite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if middleware.CrossSite(c.Request) {
+			renderError(c, errs.Forbidden("cross-origin requests are not allowed"), http.StatusForbidden, "cross-origin request rejected")
+			c.Abort()
+		}
+	}
+}
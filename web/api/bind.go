@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/web/validate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bind decodes the request's path params, query string and JSON body into a
+// new T and validates its binding tags. On failure it writes a problem+json
+// response listing the invalid fields and returns false.
+//
+//	input, ok := Bind[CreateItemInput](c)
+//	if !ok {
+//		return
+//	}
+func Bind[T any](c *gin.Context) (*T, bool) {
+	v := new(T)
+	if err := validate.Request(c, v); err != nil {
+		renderError(c, err, http.StatusBadRequest, "invalid request")
+		return nil, false
+	}
+	return v, true
+}
+
+// BindList parses limit, cursor, sort and filter[field][op] query parameters
+// against spec, the entity's whitelist. On failure it writes a problem+json
+// response listing the invalid parameters and returns false.
+//
+//	list, ok := BindList(c, data.MyEntityList)
+//	if !ok {
+//		return
+//	}
+//	page, err := myService.List(list)
+//	renderFinalContent(c, page, "", err)
+func BindList(c *gin.Context, spec query.Spec) (query.List, bool) {
+	list, err := query.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		renderError(c, err, http.StatusBadRequest, "invalid list query")
+		return list, false
+	}
+	return list, true
+}
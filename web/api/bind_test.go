@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"runtime-dynamics/data/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type createItemInput struct {
+	Name  string `json:"name" binding:"required,min=3"`
+	Count int    `json:"count" binding:"gte=1"`
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid input",
+			body:           `{"name":"Widget","count":2}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"Widget","count":2}`,
+		},
+		{
+			name:           "invalid fields",
+			body:           `{"name":"ab","count":0}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"invalid input","instance":"/api/items","code":"validation_failed","errors":[{"field":"name","message":"must be at least 3 characters"},{"field":"count","message":"must be at least 1"}]}`,
+		},
+		{
+			name:           "malformed body",
+			body:           `{"name":`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"invalid request body","instance":"/api/items","code":"validation_failed"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/api/items", func(c *gin.Context) {
+				input, ok := Bind[createItemInput](c)
+				if !ok {
+					return
+				}
+				c.JSON(http.StatusOK, input)
+			})
+
+			req, _ := http.NewRequest("POST", "/api/items", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestBindList(t *testing.T) {
+	spec := query.Spec{Fields: map[string]query.Field{
+		"status": {Property: "Status", Ops: []query.Op{query.Eq}},
+	}}
+
+	router := gin.New()
+	router.GET("/api/items", func(c *gin.Context) {
+		list, ok := BindList(c, spec)
+		if !ok {
+			return
+		}
+		page := query.Page[string]{Items: []string{list.Filters[0].Value.(string)}, NextCursor: "abc"}
+		renderFinalContent(c, page, "", nil)
+	})
+
+	req, _ := http.NewRequest("GET", "/api/items?filter[status]=active", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"items":["active"],"next_cursor":"abc"}`, w.Body.String())
+
+	req, _ = http.NewRequest("GET", "/api/items?filter[owner]=me", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"invalid list query","instance":"/api/items","code":"validation_failed","errors":[{"field":"filter[owner]","message":"cannot filter by \"owner\""}]}`, w.Body.String())
+}
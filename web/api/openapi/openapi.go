@@ -0,0 +1,381 @@
+// Package openapi records documented API routes and serves an OpenAPI 3.1
+// document describing them, along with a self-contained docs viewer.
+package openapi
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/web/proxy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.1.0"
+
+// problemMediaType is the media type of documented error responses
+const problemMediaType = "application/problem+json"
+
+// Operation documents a single API route. Request and Response are example
+// values (usually zero values) whose types describe the input and output:
+//
+//	openapi.Operation{
+//		Summary:  "Create an item",
+//		Tags:     []string{"items"},
+//		Request:  CreateItemInput{},
+//		Response: Item{},
+//		Status:   http.StatusCreated,
+//		Errors:   []int{http.StatusConflict},
+//	}
+//
+// Request fields with a uri tag become path parameters, fields with a json
+// tag form the JSON body of POST, PUT and PATCH requests, and the remaining
+// fields with a form tag become query parameters. gin binding rules are
+// copied into the schemas.
+type Operation struct {
+	// Method and Path are set when the route is registered; Path uses gin
+	// syntax, e.g. /api/items/:id
+	Method string
+	Path   string
+
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Request     interface{}
+	Response    interface{}
+	// Status is the success status code, 200 when zero
+	Status int
+	// Errors lists documented error statuses. 500 is always documented, and
+	// 422 whenever the operation has a Request.
+	Errors     []int
+	Deprecated bool
+}
+
+// Info is the title and version shown in the document
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Registry collects documented operations
+type Registry struct {
+	mu         sync.RWMutex
+	operations map[string]Operation
+	info       Info
+	errorType  reflect.Type
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{operations: map[string]Operation{}, info: Info{Version: "1.0.0"}}
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry served by SpecHandler
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register documents op in the default registry
+func Register(op Operation) {
+	defaultRegistry.Register(op)
+}
+
+// Register documents op. Registering the same method and path again replaces it.
+func (r *Registry) Register(op Operation) {
+	op.Method = strings.ToUpper(op.Method)
+	r.mu.Lock()
+	r.operations[op.Method+" "+op.Path] = op
+	r.mu.Unlock()
+}
+
+// SetInfo sets the document title and version. An empty title falls back to
+// the configured site name.
+func (r *Registry) SetInfo(info Info) {
+	r.mu.Lock()
+	r.info = info
+	r.mu.Unlock()
+}
+
+// SetErrorType sets the body type of documented error responses
+func (r *Registry) SetErrorType(v interface{}) {
+	r.mu.Lock()
+	r.errorType = reflect.TypeOf(v)
+	r.mu.Unlock()
+}
+
+// Operations returns the registered operations sorted by path and method
+func (r *Registry) Operations() []Operation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ops := make([]Operation, 0, len(r.operations))
+	for _, op := range r.operations {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI    string                        `json:"openapi"`
+	Info       Info                          `json:"info"`
+	Servers    []Server                      `json:"servers,omitempty"`
+	Paths      map[string]map[string]*PathOp `json:"paths"`
+	Components Components                    `json:"components"`
+}
+
+// Server is a base URL the API is served from
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Components holds the reusable schemas referenced by operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathOp is an operation object of the document
+type PathOp struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of an operation
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a documented response for one status code
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Document builds the OpenAPI document for the registered operations.
+// serverURL is listed as the API server when not empty.
+func (r *Registry) Document(serverURL string) *Document {
+	r.mu.RLock()
+	info := r.info
+	errorType := r.errorType
+	r.mu.RUnlock()
+	if info.Title == "" {
+		info.Title = "API"
+		if cfg := config.Get(); cfg != nil && cfg.SiteName != "" {
+			info.Title = cfg.SiteName + " API"
+		}
+	}
+
+	s := newSchemas()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]map[string]*PathOp{},
+	}
+	if serverURL != "" {
+		doc.Servers = []Server{{URL: serverURL}}
+	}
+
+	var errorSchema *Schema
+	if errorType != nil {
+		errorSchema = s.of(errorType)
+	}
+
+	for _, op := range r.Operations() {
+		path := openAPIPath(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*PathOp{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = s.operation(op, errorSchema)
+	}
+	doc.Components.Schemas = s.components
+	return doc
+}
+
+// operation converts op into an operation object
+func (s *schemas) operation(op Operation, errorSchema *Schema) *PathOp {
+	result := &PathOp{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   map[string]*Response{},
+		Deprecated:  op.Deprecated,
+	}
+	if result.OperationID == "" {
+		result.OperationID = operationID(op.Method, op.Path)
+	}
+
+	result.Parameters, result.RequestBody = s.input(op)
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if op.Response != nil && status != http.StatusNoContent {
+		success.Content = map[string]MediaType{"application/json": {Schema: s.of(reflect.TypeOf(op.Response))}}
+	}
+	result.Responses[fmt.Sprint(status)] = success
+
+	errors := append([]int{}, op.Errors...)
+	if op.Request != nil {
+		errors = append(errors, http.StatusUnprocessableEntity)
+	}
+	errors = append(errors, http.StatusInternalServerError)
+	for _, code := range errors {
+		response := &Response{Description: http.StatusText(code)}
+		if errorSchema != nil {
+			response.Content = map[string]MediaType{problemMediaType: {Schema: errorSchema}}
+		}
+		result.Responses[fmt.Sprint(code)] = response
+	}
+	return result
+}
+
+// input splits op.Request into parameters and a JSON request body
+func (s *schemas) input(op Operation) ([]Parameter, *RequestBody) {
+	var params []Parameter
+	documented := map[string]bool{}
+	var body *Schema
+
+	if op.Request != nil {
+		t := reflect.TypeOf(op.Request)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		hasBody := op.Method == http.MethodPost || op.Method == http.MethodPut || op.Method == http.MethodPatch
+		if t.Kind() == reflect.Struct {
+			body = &Schema{Type: "object", Properties: map[string]*Schema{}}
+			for _, f := range fields(t) {
+				schema := s.of(f.Type)
+				required := applyRules(schema, f)
+				description := f.Tag.Get("doc")
+				if name := tagName(f, "uri"); name != "" && name != "-" {
+					params = append(params, Parameter{Name: name, In: "path", Description: description, Required: true, Schema: schema})
+					documented[name] = true
+					continue
+				}
+				if name, ok := jsonName(f); hasBody && ok && f.Tag.Get("json") != "" {
+					schema.Description = description
+					body.Properties[name] = schema
+					if required {
+						body.Required = append(body.Required, name)
+					}
+					continue
+				}
+				if name := tagName(f, "form"); name != "" && name != "-" {
+					params = append(params, Parameter{Name: name, In: "query", Description: description, Required: required, Schema: schema})
+				}
+			}
+		} else if hasBody {
+			body = s.of(t)
+		}
+	}
+
+	// path segments not covered by the request type are plain strings
+	for _, name := range pathParams(op.Path) {
+		if !documented[name] {
+			params = append([]Parameter{{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}}, params...)
+		}
+	}
+
+	if body == nil || (body.Type == "object" && body.Properties != nil && len(body.Properties) == 0) {
+		return params, nil
+	}
+	return params, &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: body}},
+	}
+}
+
+// pathParams returns the parameter names of a gin route path
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// openAPIPath converts gin path syntax (/items/:id) to OpenAPI syntax (/items/{id})
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationID derives an id such as getApiItemsById from the method and path
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			b.WriteString("By")
+			segment = segment[1:]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// SpecHandler serves the default registry's document as JSON
+func SpecHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, defaultRegistry.Document(proxy.BaseURL(c)))
+}
+
+//go:embed docs.html
+var docsPage []byte
+
+// DocsHandler serves the docs viewer. The page inlines its styles and script
+// and loads the document from openapi.json, so it works without network access.
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
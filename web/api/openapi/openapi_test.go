@@ -0,0 +1,190 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type problem struct {
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type tag struct {
+	Name string `json:"name"`
+}
+
+type item struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Count     int64             `json:"count"`
+	Tags      []tag             `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	secret    string
+	Internal  string `json:"-"`
+}
+
+type updateItemInput struct {
+	ID     string `uri:"id" binding:"required" doc:"Item ID"`
+	DryRun bool   `form:"dry_run"`
+	Name   string `json:"name" binding:"required,min=3,max=80"`
+	Email  string `json:"email" binding:"omitempty,email"`
+	Status string `json:"status" binding:"omitempty,oneof=draft published"`
+	Count  int    `json:"count" binding:"gte=1"`
+	Tags   []tag  `json:"tags" binding:"max=5"`
+}
+
+type listItemsInput struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Status string `form:"status" json:"status" binding:"omitempty,oneof=draft published"`
+}
+
+func document(t *testing.T, r *Registry) map[string]interface{} {
+	t.Helper()
+	raw, err := json.Marshal(r.Document("https://example.com"))
+	assert.NoError(t, err)
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(raw, &doc))
+	return doc
+}
+
+func TestDocument(t *testing.T) {
+	r := NewRegistry()
+	r.SetInfo(Info{Title: "Items API", Version: "2.0.0"})
+	r.SetErrorType(problem{})
+	r.Register(Operation{
+		Method:   http.MethodPut,
+		Path:     "/api/items/:id",
+		Summary:  "Update an item",
+		Tags:     []string{"items"},
+		Request:  updateItemInput{},
+		Response: item{},
+		Errors:   []int{http.StatusNotFound},
+	})
+	r.Register(Operation{
+		Method:   http.MethodGet,
+		Path:     "/api/items",
+		Request:  listItemsInput{},
+		Response: []item{},
+	})
+	r.Register(Operation{Method: http.MethodDelete, Path: "/api/items/:id", Status: http.StatusNoContent, Deprecated: true})
+
+	doc := r.Document("https://example.com")
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "Items API", doc.Info.Title)
+	assert.Equal(t, []Server{{URL: "https://example.com"}}, doc.Servers)
+
+	put := doc.Paths["/api/items/{id}"]["put"]
+	assert.Equal(t, "putApiItemsById", put.OperationID)
+	assert.Equal(t, []Parameter{
+		{Name: "id", In: "path", Description: "Item ID", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "dry_run", In: "query", Schema: &Schema{Type: "boolean"}},
+	}, put.Parameters)
+
+	body := put.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, []string{"name"}, body.Required)
+	assert.Equal(t, 3, *body.Properties["name"].MinLength)
+	assert.Equal(t, 80, *body.Properties["name"].MaxLength)
+	assert.Equal(t, "email", body.Properties["email"].Format)
+	assert.Equal(t, []interface{}{"draft", "published"}, body.Properties["status"].Enum)
+	assert.Equal(t, 1.0, *body.Properties["count"].Minimum)
+	assert.Equal(t, 5, *body.Properties["tags"].MaxItems)
+	assert.Equal(t, "#/components/schemas/tag", body.Properties["tags"].Items.Ref)
+
+	assert.Equal(t, "#/components/schemas/item", put.Responses["200"].Content["application/json"].Schema.Ref)
+	for _, code := range []string{"404", "422", "500"} {
+		assert.Equal(t, "#/components/schemas/problem", put.Responses[code].Content["application/problem+json"].Schema.Ref, code)
+	}
+
+	list := doc.Paths["/api/items"]["get"]
+	assert.Nil(t, list.RequestBody)
+	assert.Len(t, list.Parameters, 2)
+	assert.Equal(t, "limit", list.Parameters[0].Name)
+	assert.Equal(t, 100.0, *list.Parameters[0].Schema.Maximum)
+	assert.Equal(t, "status", list.Parameters[1].Name)
+	assert.Equal(t, "query", list.Parameters[1].In)
+	assert.Equal(t, "array", list.Responses["200"].Content["application/json"].Schema.Type)
+
+	del := doc.Paths["/api/items/{id}"]["delete"]
+	assert.True(t, del.Deprecated)
+	assert.Nil(t, del.Responses["204"].Content)
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}}, del.Parameters)
+	assert.NotContains(t, del.Responses, "422")
+
+	schema := doc.Components.Schemas["item"]
+	assert.Equal(t, "date-time", schema.Properties["created_at"].Format)
+	assert.Equal(t, "int64", schema.Properties["count"].Format)
+	assert.Equal(t, "string", schema.Properties["labels"].AdditionalProperties.Type)
+	assert.NotContains(t, schema.Properties, "secret")
+	assert.NotContains(t, schema.Properties, "Internal")
+}
+
+func TestDocument_JSON(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Operation{Method: http.MethodGet, Path: "/api/ping"})
+
+	doc := document(t, r)
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Equal(t, "API", doc["info"].(map[string]interface{})["title"])
+	responses := doc["paths"].(map[string]interface{})["/api/ping"].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})
+	assert.Contains(t, responses, "200")
+	assert.Contains(t, responses, "500")
+}
+
+func TestOperationID(t *testing.T) {
+	assert.Equal(t, "getApiItems", operationID("GET", "/api/items"))
+	assert.Equal(t, "postApiItemsByIdArchive", operationID("POST", "/api/items/:id/archive"))
+	assert.Equal(t, "getApiDataTransitByPath", operationID("GET", "/api/data-transit/*path"))
+	assert.Equal(t, "/api/files/{path}", openAPIPath("/api/files/*path"))
+}
+
+func TestHandlers(t *testing.T) {
+	router := gin.New()
+	router.GET("/api/openapi.json", SpecHandler)
+	router.GET("/api/docs", DocsHandler)
+
+	req, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	req.Host = "api.example.com"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"openapi":"3.1.0"`)
+	assert.Contains(t, w.Body.String(), `"url":"http://api.example.com"`)
+
+	req, _ = http.NewRequest("GET", "/api/docs", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, strings.Contains(w.Body.String(), `fetch("openapi.json"`))
+	assert.NotContains(t, w.Body.String(), "https://")
+}
+
+type page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func TestDocument_GenericComponentNames(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Operation{Method: http.MethodGet, Path: "/api/items", Response: page[item]{}})
+
+	doc := r.Document("")
+	assert.Equal(t, "#/components/schemas/page_item", doc.Paths["/api/items"]["get"].Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Contains(t, doc.Components.Schemas, "page_item")
+	assert.Contains(t, doc.Components.Schemas, "item")
+	assert.Equal(t, "Page_Item", typeName("Page[runtime-dynamics/data.Item]"))
+	assert.Equal(t, "Pair_string_Item", typeName("Pair[string,runtime-dynamics/data.Item]"))
+}
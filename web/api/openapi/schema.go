@@ -0,0 +1,288 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema (draft 2020-12) object as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	byteSliceType = reflect.TypeOf([]byte{})
+)
+
+// schemas builds schemas for Go types, collecting named structs as
+// reusable components
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// of returns the schema for t. Named structs become a $ref to a component.
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawJSONType:
+		return &Schema{}
+	case byteSliceType:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		format := ""
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			format = "int64"
+		} else if t.Kind() == reflect.Int32 || t.Kind() == reflect.Uint32 {
+			format = "int32"
+		}
+		return &Schema{Type: "integer", Format: format}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	default:
+		// interface{} and anything else accept any value
+		return &Schema{}
+	}
+}
+
+// component registers the named struct t and returns its component name
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := typeName(t.Name())
+	if _, taken := s.components[name]; taken {
+		name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + name
+	}
+	s.names[t] = name
+	// reserve the name before building so recursive types terminate
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t)
+	return name
+}
+
+// typeName turns a Go type name into a component name. Generic type
+// arguments lose their package path: Page[example.com/data.Item] becomes Page_Item.
+func typeName(name string) string {
+	base, args, generic := strings.Cut(name, "[")
+	if !generic {
+		return name
+	}
+	parts := []string{base}
+	for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+		if i := strings.LastIndexAny(arg, "./"); i >= 0 {
+			arg = arg[i+1:]
+		}
+		parts = append(parts, strings.Trim(arg, "[]*"))
+	}
+	return strings.Join(parts, "_")
+}
+
+// object builds an inline object schema from t's exported fields
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, f := range fields(t) {
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+		prop := s.of(f.Type)
+		required := applyRules(prop, f)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			if prop.Ref != "" {
+				// siblings of $ref are allowed in 3.1
+				prop = &Schema{Ref: prop.Ref, Description: doc}
+			} else {
+				prop.Description = doc
+			}
+		}
+		schema.Properties[name] = prop
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// fields returns t's exported fields with embedded structs flattened
+func fields(t reflect.Type) []reflect.StructField {
+	var result []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				result = append(result, fields(ft)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// jsonName returns the JSON property name of f and false when f is not serialized
+func jsonName(f reflect.StructField) (string, bool) {
+	name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		return f.Name, true
+	}
+	return name, true
+}
+
+// tagName returns the value of f's tag without options
+func tagName(f reflect.StructField, tag string) string {
+	return strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+}
+
+// applyRules copies gin binding rules onto schema and reports whether the
+// field is required
+func applyRules(schema *Schema, f reflect.StructField) bool {
+	required := false
+	if schema.Ref != "" {
+		// constraints of a referenced component cannot be narrowed here
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+			if rule == "required" {
+				required = true
+			}
+		}
+		return required
+	}
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		if rule == "dive" {
+			// later rules apply to elements
+			break
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "http_url":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, v))
+			}
+		case "min", "gte":
+			setBound(schema, param, true, false)
+		case "max", "lte":
+			setBound(schema, param, false, false)
+		case "gt":
+			setBound(schema, param, true, true)
+		case "lt":
+			setBound(schema, param, false, true)
+		case "len":
+			setBound(schema, param, true, false)
+			setBound(schema, param, false, false)
+		}
+	}
+	return required
+}
+
+// setBound sets a length, item count or numeric bound depending on the schema type
+func setBound(schema *Schema, param string, lower bool, exclusive bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	i := int(n)
+	switch schema.Type {
+	case "string":
+		if lower {
+			schema.MinLength = &i
+		} else {
+			schema.MaxLength = &i
+		}
+	case "array":
+		if lower {
+			schema.MinItems = &i
+		} else {
+			schema.MaxItems = &i
+		}
+	case "integer", "number":
+		switch {
+		case lower && exclusive:
+			schema.ExclusiveMinimum = &n
+		case lower:
+			schema.Minimum = &n
+		case exclusive:
+			schema.ExclusiveMaximum = &n
+		default:
+			schema.Maximum = &n
+		}
+	}
+}
+
+// enumValue converts a oneof value to the schema's type
+func enumValue(schemaType, value string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
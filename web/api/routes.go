@@ -1,30 +1,127 @@
 ﻿package api
 
 import (
+	"math"
 	"net/http"
-	"runtime-dynamics/data"
+	"strconv"
+	"strings"
+
+	"runtime-dynamics/errs"
+	"runtime-dynamics/web/api/openapi"
+	"runtime-dynamics/web/middleware"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 	"github.com/rs/zerolog/log"
 )
 
 func RegisterRoutes(r *gin.Engine) {
+	// API contract and docs viewer
+	openapi.DefaultRegistry().SetErrorType(Problem{})
+	r.GET("/api/openapi.json", openapi.SpecHandler)
+	r.GET("/api/docs", openapi.DocsHandler)
+
+	// Versioned routes live under /api/v1, /api/v2, ... and are also served
+	// at /api/* for clients selecting the version with the API-Version header
+	versions := newVersionRouter(r)
+	registerV1(versions.version(V1))
+	registerV2(versions.version(V2))
+}
+
+// registerRoute registers an API route and documents it in the OpenAPI spec
+func registerRoute(g *gin.RouterGroup, method string, path string, handler gin.HandlerFunc, op openapi.Operation) {
+	g.Handle(method, path, handler)
+	op.Method = method
+	op.Path = joinPath(g.BasePath(), path)
+	openapi.Register(op)
+}
 
-	//r.GET("/api/exec-data-transit", execDataTransit)
+// joinPath joins a group base path and a relative route path
+func joinPath(base string, path string) string {
+	if path == "" || path == "/" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
 }
 
+// problemContentType is the RFC 7807 media type of error responses
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body with the request ID and
+// validation details as extension members
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    []errs.FieldError `json:"errors,omitempty"`
+}
+
+// renderError writes e as a problem+json response. Typed errors from the errs
+// package choose the status and user-facing detail; any other error uses
+// statusCode and message. The error text itself is only logged.
 func renderError(c *gin.Context, e error, statusCode int, message string) {
-	if data.IsNotFound(e) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": message,
-		})
-		log.Error().Err(e).Msg(message)
-		return
+	kind := errs.KindInternal
+	detail := message
+	var fields []errs.FieldError
+	if typed, ok := errs.As(e); ok {
+		kind = typed.Kind
+		statusCode = kind.Status()
+		fields = typed.Fields
+		if typed.Kind != errs.KindInternal && typed.Message != "" {
+			detail = typed.Message
+		}
+		if typed.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(typed.RetryAfter.Seconds()))))
+		}
+	} else {
+		kind = kindForStatus(statusCode)
+	}
+
+	requestID := middleware.GetRequestID(c)
+	event := log.Warn()
+	if statusCode >= http.StatusInternalServerError {
+		event = log.Error()
+	}
+	event.Err(e).Str("request_id", requestID).Int("status", statusCode).Msg(message)
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    detail,
+		Code:      kind.Code(),
+		RequestID: requestID,
+		Errors:    fields,
+	}
+	if c.Request != nil {
+		problem.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", problemContentType)
+	c.Render(statusCode, render.JSON{Data: problem})
+}
+
+// kindForStatus maps a caller supplied status code to an error kind
+func kindForStatus(statusCode int) errs.Kind {
+	switch statusCode {
+	case http.StatusNotFound:
+		return errs.KindNotFound
+	case http.StatusConflict:
+		return errs.KindConflict
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return errs.KindValidation
+	case http.StatusUnauthorized:
+		return errs.KindUnauthorized
+	case http.StatusForbidden:
+		return errs.KindForbidden
+	case http.StatusTooManyRequests:
+		return errs.KindRateLimited
+	default:
+		return errs.KindInternal
 	}
-	log.Error().Err(e).Msg(message)
-	c.JSON(statusCode, gin.H{
-		"error": message,
-	})
 }
 
 func renderSuccess(c *gin.Context) {
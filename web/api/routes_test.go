@@ -2,13 +2,18 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/datastore"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"runtime-dynamics/errs"
+	"runtime-dynamics/web/api/openapi"
+	"runtime-dynamics/web/middleware"
 )
 
 func init() {
@@ -31,7 +36,7 @@ func TestRenderError(t *testing.T) {
 			statusCode:     http.StatusInternalServerError,
 			message:        "entity not found",
 			expectedStatus: http.StatusNotFound,
-			expectedBody:   `{"error":"entity not found"}`,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"entity not found","code":"not_found"}`,
 		},
 		{
 			name:           "generic error",
@@ -39,7 +44,7 @@ func TestRenderError(t *testing.T) {
 			statusCode:     http.StatusInternalServerError,
 			message:        "internal server error",
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":"internal server error"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","code":"internal"}`,
 		},
 		{
 			name:           "bad request error",
@@ -47,7 +52,39 @@ func TestRenderError(t *testing.T) {
 			statusCode:     http.StatusBadRequest,
 			message:        "invalid input",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"invalid input"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid input","code":"validation_failed"}`,
+		},
+		{
+			name:           "typed conflict error",
+			err:            errs.Conflict("name already taken"),
+			statusCode:     http.StatusInternalServerError,
+			message:        "failed to create entity",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"type":"about:blank","title":"Conflict","status":409,"detail":"name already taken","code":"conflict"}`,
+		},
+		{
+			name:           "typed validation error with fields",
+			err:            fmt.Errorf("create: %w", errs.Validation("invalid entity", errs.FieldError{Field: "name", Message: "must be at least 3 characters"})),
+			statusCode:     http.StatusInternalServerError,
+			message:        "failed to create entity",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"invalid entity","code":"validation_failed","errors":[{"field":"name","message":"must be at least 3 characters"}]}`,
+		},
+		{
+			name:           "typed internal error hides cause",
+			err:            errs.Internal(errors.New("datastore put failed: connection timeout"), "failed to save entity"),
+			statusCode:     http.StatusBadRequest,
+			message:        "failed to create entity",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"failed to create entity","code":"internal"}`,
+		},
+		{
+			name:           "typed forbidden error",
+			err:            errs.Forbidden("not authorized to update this entity"),
+			statusCode:     http.StatusInternalServerError,
+			message:        "failed to update entity",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"about:blank","title":"Forbidden","status":403,"detail":"not authorized to update this entity","code":"forbidden"}`,
 		},
 	}
 
@@ -59,11 +96,29 @@ func TestRenderError(t *testing.T) {
 			renderError(c, tt.err, tt.statusCode, tt.message)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
 			assert.JSONEq(t, tt.expectedBody, w.Body.String())
 		})
 	}
 }
 
+func TestRenderError_RequestContext(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.GET("/api/items/:id", func(c *gin.Context) {
+		renderError(c, errs.RateLimited("too many requests", 1500*time.Millisecond), http.StatusInternalServerError, "failed to get item")
+	})
+
+	req, _ := http.NewRequest("GET", "/api/items/42", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"too many requests","instance":"/api/items/42","code":"rate_limited","request_id":"req-1"}`, w.Body.String())
+}
+
 func TestRenderSuccess(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -94,7 +149,7 @@ func TestRenderFinal(t *testing.T) {
 			err:            errors.New("something went wrong"),
 			message:        "operation failed",
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":"operation failed"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"operation failed","code":"internal"}`,
 		},
 	}
 
@@ -142,7 +197,7 @@ func TestRenderFinalContent(t *testing.T) {
 			key:            "data",
 			err:            errors.New("failed to fetch"),
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":"data"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"data","code":"internal"}`,
 		},
 		{
 			name:           "success with array",
@@ -219,3 +274,29 @@ func BenchmarkRenderFinalContent(b *testing.B) {
 		renderFinalContent(c, content, "data", nil)
 	}
 }
+
+func TestRegisterRoute(t *testing.T) {
+	type pingResponse struct {
+		Message string `json:"message"`
+	}
+
+	router := gin.New()
+	registerRoute(router.Group("/api"), http.MethodGet, "/ping/:name", func(c *gin.Context) {
+		c.JSON(http.StatusOK, pingResponse{Message: "pong " + c.Param("name")})
+	}, openapi.Operation{Summary: "Ping", Response: pingResponse{}})
+
+	req, _ := http.NewRequest("GET", "/api/ping/ada", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	doc := openapi.DefaultRegistry().Document("")
+	op := doc.Paths["/api/ping/{name}"]["get"]
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "Ping", op.Summary)
+		assert.Equal(t, "name", op.Parameters[0].Name)
+	}
+
+	assert.Equal(t, "/api", joinPath("/api", "/"))
+	assert.Equal(t, "/api/items", joinPath("/api/", "items"))
+}
@@ -0,0 +1,6 @@
+package api
+
+// registerV1 registers the routes of API version 1
+func registerV1(v1 *versionGroup) {
+	//v1.Handle(http.MethodPost, "/exec-data-transit", execDataTransit, openapi.Operation{Summary: "Execute a data transit"})
+}
@@ -0,0 +1,6 @@
+package api
+
+// registerV2 registers the routes of API version 2. Register every endpoint
+// the version serves, including the ones unchanged since v1.
+func registerV2(v2 *versionGroup) {
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/web/api/openapi"
+	"runtime-dynamics/web/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// VersionHeader selects the API version of an unversioned /api/* request,
+// e.g. "API-Version: 2". It is also set on every versioned response.
+const VersionHeader = "API-Version"
+
+// Version is a version of the JSON API, served under /api/<Name>
+type Version struct {
+	Name string
+	// Deprecation marks every endpoint of the version deprecated when set
+	Deprecation *Deprecation
+}
+
+// Deprecation describes when an API version or endpoint was deprecated and
+// when it will be removed
+type Deprecation struct {
+	// Since is sent in the Deprecation header (RFC 9745)
+	Since time.Time
+	// Sunset is sent in the Sunset header (RFC 8594) when not zero
+	Sunset time.Time
+	// Link points to migration notes and is sent as a Link header with
+	// rel="deprecation"
+	Link string
+}
+
+var (
+	V1 = Version{Name: "v1"}
+	V2 = Version{Name: "v2"}
+)
+
+// versionRouter registers per-version routes under /api/<version> and
+// dispatches unversioned /api/* requests by the VersionHeader or the Accept
+// header's version parameter
+type versionRouter struct {
+	api      *gin.RouterGroup
+	versions []string
+	// handlers maps "METHOD /path" to the handler of each version
+	handlers map[string]map[string]gin.HandlerFunc
+}
+
+func newVersionRouter(r *gin.Engine) *versionRouter {
+	return &versionRouter{
+		api:      r.Group("/api"),
+		handlers: map[string]map[string]gin.HandlerFunc{},
+	}
+}
+
+// version returns the group registering routes of v
+func (vr *versionRouter) version(v Version) *versionGroup {
+	vr.versions = append(vr.versions, v.Name)
+	return &versionGroup{
+		router:      vr,
+		version:     v,
+		group:       vr.api.Group("/" + v.Name),
+		deprecation: v.Deprecation,
+	}
+}
+
+// defaultVersion is the version of unversioned requests without a version
+// header: API_DEFAULT_VERSION when set, otherwise the first registered version
+func (vr *versionRouter) defaultVersion() string {
+	if cfg := config.Get(); cfg != nil && cfg.APIDefaultVersion != "" {
+		return normalizeVersion(cfg.APIDefaultVersion)
+	}
+	if len(vr.versions) == 0 {
+		return ""
+	}
+	return vr.versions[0]
+}
+
+// alias registers the unversioned route for method and path once, and the
+// version's handler for it
+func (vr *versionRouter) alias(version string, method string, path string, handler gin.HandlerFunc) {
+	key := method + " " + path
+	byVersion, exists := vr.handlers[key]
+	if !exists {
+		byVersion = map[string]gin.HandlerFunc{}
+		vr.handlers[key] = byVersion
+		vr.api.Handle(method, path, func(c *gin.Context) {
+			vr.dispatch(c, byVersion)
+		})
+	}
+	byVersion[version] = handler
+}
+
+// dispatch runs the handler of the requested version
+func (vr *versionRouter) dispatch(c *gin.Context, byVersion map[string]gin.HandlerFunc) {
+	middleware.AddVary(c.Writer.Header(), VersionHeader, "Accept")
+	version := requestedVersion(c)
+	if version == "" {
+		version = vr.defaultVersion()
+	}
+	handler, ok := byVersion[version]
+	if !ok {
+		if vr.known(version) {
+			renderError(c, nil, http.StatusNotFound, fmt.Sprintf("this endpoint is not available in API version %s", version))
+			return
+		}
+		renderError(c, nil, http.StatusBadRequest, fmt.Sprintf("API version %q is not supported", version))
+		return
+	}
+	handler(c)
+}
+
+func (vr *versionRouter) known(version string) bool {
+	for _, v := range vr.versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedVersion reads the version from the VersionHeader or from a
+// version parameter of the Accept header (application/json; version=2)
+func requestedVersion(c *gin.Context) string {
+	if v := c.GetHeader(VersionHeader); v != "" {
+		return normalizeVersion(v)
+	}
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && params["version"] != "" {
+			return normalizeVersion(params["version"])
+		}
+	}
+	return ""
+}
+
+// normalizeVersion turns "2", "V2" and "v2" into "v2"
+func normalizeVersion(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if _, err := strconv.Atoi(v); err == nil {
+		return "v" + v
+	}
+	return v
+}
+
+// versionGroup registers the routes of one API version
+type versionGroup struct {
+	router      *versionRouter
+	version     Version
+	group       *gin.RouterGroup
+	deprecation *Deprecation
+	// guards run in order before every handler of the group
+	guards []gin.HandlerFunc
+}
+
+// Deprecated returns a group whose routes are marked deprecated with d,
+// overriding the version's own deprecation
+func (g *versionGroup) Deprecated(d Deprecation) *versionGroup {
+	deprecated := *g
+	deprecated.deprecation = &d
+	return &deprecated
+}
+
+// With returns a group whose handlers first run guards, e.g. RequireUser,
+// in order. A guard rejects the request by writing a response and calling
+// c.Abort; it must not rely on c.Next to run the handler.
+func (g *versionGroup) With(guards ...gin.HandlerFunc) *versionGroup {
+	guarded := *g
+	guarded.guards = append(append([]gin.HandlerFunc{}, g.guards...), guards...)
+	return &guarded
+}
+
+// Handle registers the route under /api/<version>/path, makes it reachable
+// at /api/path through version negotiation, and documents it
+func (g *versionGroup) Handle(method string, path string, handler gin.HandlerFunc, op openapi.Operation) {
+	versioned := g.wrap(handler)
+	if g.deprecation != nil {
+		op.Deprecated = true
+	}
+	registerRoute(g.group, method, path, versioned, op)
+	g.router.alias(g.version.Name, method, path, versioned)
+}
+
+// wrap sets the version and deprecation headers and runs the group's guards
+// before running handler
+func (g *versionGroup) wrap(handler gin.HandlerFunc) gin.HandlerFunc {
+	version := g.version.Name
+	deprecation := g.deprecation
+	guards := g.guards
+	return func(c *gin.Context) {
+		c.Header(VersionHeader, version)
+		if deprecation != nil {
+			markDeprecated(c, version, *deprecation)
+		}
+		for _, guard := range guards {
+			if guard(c); c.IsAborted() {
+				return
+			}
+		}
+		handler(c)
+	}
+}
+
+// markDeprecated sets the Deprecation, Sunset and Link headers and logs the
+// call so remaining clients can be found before the sunset date
+func markDeprecated(c *gin.Context, version string, d Deprecation) {
+	if d.Since.IsZero() {
+		c.Header("Deprecation", "true")
+	} else {
+		c.Header("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"; type=\"text/html\"", d.Link))
+	}
+
+	event := log.Warn().
+		Str("version", version).
+		Str("method", c.Request.Method).
+		Str("route", c.FullPath()).
+		Str("user_agent", c.Request.UserAgent()).
+		Str("client_ip", c.ClientIP()).
+		Str("request_id", middleware.GetRequestID(c))
+	if !d.Sunset.IsZero() {
+		event = event.Time("sunset", d.Sunset)
+	}
+	event.Msg("deprecated API endpoint called")
+}
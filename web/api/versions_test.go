@@ -0,0 +1,244 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/config"
+	"runtime-dynamics/web/api/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func versionedRouter() *gin.Engine {
+	r := gin.New()
+	versions := newVersionRouter(r)
+
+	v1 := versions.version(Version{Name: "v1", Deprecation: &Deprecation{
+		Since:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Link:   "https://example.com/docs/migrate-v2",
+	}})
+	v1.Handle(http.MethodGet, "/widgets/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "v1 widget "+c.Param("id"))
+	}, openapi.Operation{})
+
+	v2 := versions.version(Version{Name: "v2"})
+	v2.Handle(http.MethodGet, "/widgets/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "v2 widget "+c.Param("id"))
+	}, openapi.Operation{})
+	v2.Handle(http.MethodGet, "/gadgets", func(c *gin.Context) {
+		c.String(http.StatusOK, "v2 gadgets")
+	}, openapi.Operation{})
+	v2.Deprecated(Deprecation{Since: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}).Handle(http.MethodGet, "/legacy", func(c *gin.Context) {
+		c.String(http.StatusOK, "v2 legacy")
+	}, openapi.Operation{})
+	return r
+}
+
+func TestVersionRouter(t *testing.T) {
+	router := versionedRouter()
+
+	tests := []struct {
+		name               string
+		path               string
+		headers            map[string]string
+		expectedStatus     int
+		expectedBody       string
+		expectedVersion    string
+		expectedDeprecated string
+	}{
+		{
+			name:               "versioned path",
+			path:               "/api/v1/widgets/7",
+			expectedStatus:     http.StatusOK,
+			expectedBody:       "v1 widget 7",
+			expectedVersion:    "v1",
+			expectedDeprecated: "@1735689600",
+		},
+		{
+			name:            "newer versioned path",
+			path:            "/api/v2/widgets/7",
+			expectedStatus:  http.StatusOK,
+			expectedBody:    "v2 widget 7",
+			expectedVersion: "v2",
+		},
+		{
+			name:               "unversioned path uses default version",
+			path:               "/api/widgets/7",
+			expectedStatus:     http.StatusOK,
+			expectedBody:       "v1 widget 7",
+			expectedVersion:    "v1",
+			expectedDeprecated: "@1735689600",
+		},
+		{
+			name:            "version header",
+			path:            "/api/widgets/7",
+			headers:         map[string]string{"API-Version": "2"},
+			expectedStatus:  http.StatusOK,
+			expectedBody:    "v2 widget 7",
+			expectedVersion: "v2",
+		},
+		{
+			name:            "accept version parameter",
+			path:            "/api/widgets/7",
+			headers:         map[string]string{"Accept": "application/json; version=v2"},
+			expectedStatus:  http.StatusOK,
+			expectedBody:    "v2 widget 7",
+			expectedVersion: "v2",
+		},
+		{
+			name:               "endpoint deprecated in a current version",
+			path:               "/api/v2/legacy",
+			expectedStatus:     http.StatusOK,
+			expectedBody:       "v2 legacy",
+			expectedVersion:    "v2",
+			expectedDeprecated: "@1748736000",
+		},
+		{
+			name:           "unknown version",
+			path:           "/api/widgets/7",
+			headers:        map[string]string{"API-Version": "9"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"API version \"v9\" is not supported","instance":"/api/widgets/7","code":"validation_failed"}`,
+		},
+		{
+			name:           "endpoint missing from version",
+			path:           "/api/gadgets",
+			headers:        map[string]string{"API-Version": "v1"},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"this endpoint is not available in API version v1","instance":"/api/gadgets","code":"not_found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			} else {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			assert.Equal(t, tt.expectedVersion, w.Header().Get(VersionHeader))
+			assert.Equal(t, tt.expectedDeprecated, w.Header().Get("Deprecation"))
+		})
+	}
+}
+
+func TestVersionRouter_DeprecationHeaders(t *testing.T) {
+	router := versionedRouter()
+
+	req, _ := http.NewRequest("GET", "/api/v1/widgets/7", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "Thu, 01 Jan 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/docs/migrate-v2>; rel="deprecation"; type="text/html"`, w.Header().Get("Link"))
+
+	req, _ = http.NewRequest("GET", "/api/v2/legacy", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Sunset"))
+	assert.Empty(t, w.Header().Get("Link"))
+
+	op := openapi.DefaultRegistry().Document("").Paths["/api/v1/widgets/{id}"]["get"]
+	if assert.NotNil(t, op) {
+		assert.True(t, op.Deprecated)
+	}
+}
+
+func TestVersionRouter_ConfiguredDefault(t *testing.T) {
+	os.Setenv("API_DEFAULT_VERSION", "v2")
+	defer func() {
+		os.Unsetenv("API_DEFAULT_VERSION")
+		config.LoadConfig()
+	}()
+	assert.NoError(t, config.LoadConfig())
+
+	req, _ := http.NewRequest("GET", "/api/widgets/7", nil)
+	w := httptest.NewRecorder()
+	versionedRouter().ServeHTTP(w, req)
+
+	assert.Equal(t, "v2 widget 7", w.Body.String())
+	assert.Equal(t, "API-Version, Accept", w.Header().Get("Vary"))
+}
+
+func TestVersionRouter_KeepsVary(t *testing.T) {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Header("Vary", "Origin, accept")
+	})
+	newVersionRouter(r).version(Version{Name: "v1"}).Handle(http.MethodGet, "/widgets", func(c *gin.Context) {
+		c.String(http.StatusOK, "widgets")
+	}, openapi.Operation{})
+
+	req, _ := http.NewRequest("GET", "/api/widgets", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, []string{"Origin, accept", "API-Version"}, w.Header().Values("Vary"))
+}
+
+func TestVersionGroup_With(t *testing.T) {
+	user := &auth.User{ID: "u1"}
+	tests := []struct {
+		name           string
+		path           string
+		user           *auth.User
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{name: "anonymous", path: "/api/v1/widgets", expectedStatus: http.StatusUnauthorized},
+		{name: "anonymous unversioned", path: "/api/widgets", expectedStatus: http.StatusUnauthorized},
+		{name: "cross-site", path: "/api/v1/widgets", user: user, headers: map[string]string{"Sec-Fetch-Site": "cross-site"}, expectedStatus: http.StatusForbidden},
+		{name: "other origin unversioned", path: "/api/widgets", user: user, headers: map[string]string{"Origin": "https://evil.test"}, expectedStatus: http.StatusForbidden},
+		{name: "same-origin", path: "/api/v1/widgets", user: user, headers: map[string]string{"Sec-Fetch-Site": "same-origin"}, expectedStatus: http.StatusCreated},
+		{name: "non-browser client", path: "/api/widgets", user: user, expectedStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set("user", tt.user)
+				}
+			})
+			v1 := newVersionRouter(r).version(Version{Name: "v1"})
+			v1.With(RequireUser(), RejectCrossSite()).Handle(http.MethodPost, "/widgets", func(c *gin.Context) {
+				c.Status(http.StatusCreated)
+			}, openapi.Operation{})
+
+			req, _ := http.NewRequest("POST", tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, "v1", w.Header().Get(VersionHeader))
+			if tt.expectedStatus >= http.StatusBadRequest {
+				assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	assert.Equal(t, "v2", normalizeVersion("2"))
+	assert.Equal(t, "v2", normalizeVersion(" V2 "))
+	assert.Equal(t, "beta", normalizeVersion("beta"))
+}
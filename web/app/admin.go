@@ -0,0 +1,434 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"runtime-dynamics/audit"
+	"runtime-dynamics/auth"
+	"runtime-dynamics/data"
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/errs"
+	"runtime-dynamics/views/pages"
+	"runtime-dynamics/web/app/admin"
+	"runtime-dynamics/web/app/seo"
+	"runtime-dynamics/web/middleware"
+	"runtime-dynamics/web/validate"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// adminEntities is the registry the admin pages are generated from
+var adminEntities = data.DefaultEntityRegistry()
+
+// registerAdminPages registers the generic CRUD pages of every registered
+// entity under /app/admin, restricted to users with the admin role. Writes
+// must come from this site.
+func registerAdminPages(r *gin.Engine) {
+	g := r.Group("/app/admin", middleware.RequireRole(auth.RoleAdmin), middleware.SameOrigin())
+	g.GET("", AdminDashboardHandler)
+	g.GET("/audit", AdminAuditHandler)
+	g.GET("/:entity", AdminListHandler)
+	g.GET("/:entity/new", AdminNewHandler)
+	g.POST("/:entity", AdminCreateHandler)
+	g.GET("/:entity/:id", AdminDetailHandler)
+	g.GET("/:entity/:id/edit", AdminEditHandler)
+	g.POST("/:entity/:id", AdminUpdateHandler)
+	g.DELETE("/:entity/:id", AdminDeleteHandler)
+	g.GET("/:entity/:id/fields/:field", AdminFieldHandler)
+	g.GET("/:entity/:id/fields/:field/edit", AdminFieldEditHandler)
+	g.PUT("/:entity/:id/fields/:field", AdminFieldUpdateHandler)
+}
+
+// adminMeta returns the metadata of an admin page, which is never indexed
+func adminMeta(c *gin.Context, title string) seo.Meta {
+	meta := seo.NewMeta(c, title, "")
+	meta.Robots = "noindex, nofollow"
+	return meta
+}
+
+// adminEntityNames lists the registered entities for the admin navigation
+func adminEntityNames() []string {
+	var names []string
+	for _, t := range adminEntities.All() {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// adminEntity returns the entity type named in the URL, writing a 404 when
+// it is not registered
+func adminEntity(c *gin.Context) (data.EntityType, bool) {
+	t, ok := adminEntities.Lookup(c.Param("entity"))
+	if !ok {
+		RenderError(c, errs.NotFound("unknown entity"), "unknown entity")
+		return nil, false
+	}
+	return t, true
+}
+
+// adminLoad returns the entity named by the :id param
+func adminLoad(c *gin.Context, t data.EntityType) (any, bool) {
+	entity, err := t.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RenderError(c, err, "failed to get "+t.Name())
+		return nil, false
+	}
+	return entity, true
+}
+
+// AdminDashboardHandler lists the registered entities
+func AdminDashboardHandler(c *gin.Context) {
+	Render(c, pages.AdminDashboard(adminMeta(c, "Admin"), adminEntityNames()), nil)
+}
+
+// AdminListHandler renders a page of entities. The list accepts the
+// entity's query.Spec parameters and q, a prefix search on its Search field.
+// Search and "Load more" requests receive only the table rows.
+func AdminListHandler(c *gin.Context) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return
+	}
+	values := c.Request.URL.Query()
+	search := strings.TrimSpace(values.Get("q"))
+	values.Del("q")
+
+	spec := t.ListSpec()
+	if search != "" && t.SearchField() != "" {
+		// a prefix range needs the first sort order on the same property
+		values.Del("sort")
+		spec.DefaultSort = t.SearchField()
+	}
+	list, err := query.Parse(values, spec)
+	if err != nil {
+		RenderError(c, err, "invalid list query")
+		return
+	}
+	if search != "" && t.SearchField() != "" {
+		property := spec.Fields[t.SearchField()].Property
+		list.Filters = append(list.Filters,
+			query.Filter{Field: t.SearchField(), Property: property, Op: query.Gte, Value: search},
+			query.Filter{Field: t.SearchField(), Property: property, Op: query.Lt, Value: search + "\uffff"},
+		)
+	}
+
+	entities, cursor, err := t.List(c.Request.Context(), list)
+	if err != nil {
+		RenderError(c, err, "failed to list "+t.Name())
+		return
+	}
+
+	columns := admin.Columns(admin.Fields(t.Type()))
+	view := admin.ListView{
+		Entity:     t.Name(),
+		Columns:    columns,
+		Rows:       admin.Rows(columns, entities),
+		Search:     search,
+		Searchable: t.SearchField() != "",
+	}
+	if cursor != "" {
+		next := c.Request.URL.Query()
+		next.Set("cursor", cursor)
+		view.Next = admin.Path(t.Name(), "") + "?" + next.Encode()
+	}
+	RenderTarget(c, pages.AdminList(adminMeta(c, t.Name()), adminEntityNames(), view), Fragments{
+		"admin-rows": pages.AdminRows(view),
+		"admin-more": pages.AdminRows(view),
+	})
+}
+
+// AdminDetailHandler shows every field of one entity
+func AdminDetailHandler(c *gin.Context) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return
+	}
+	entity, ok := adminLoad(c, t)
+	if !ok {
+		return
+	}
+	fields := admin.Fields(t.Type())
+	view := admin.DetailView{
+		Entity: t.Name(),
+		ID:     admin.ID(entity),
+		Fields: fields,
+		Values: admin.Values(fields, entity),
+	}
+	Render(c, pages.AdminDetail(adminMeta(c, t.Name()+" "+view.ID), adminEntityNames(), view), nil)
+}
+
+// AdminNewHandler renders the empty create form
+func AdminNewHandler(c *gin.Context) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return
+	}
+	fields := admin.Fields(t.Type())
+	view := admin.FormView{
+		Entity: t.Name(),
+		Action: admin.Path(t.Name(), ""),
+		Fields: fields,
+		Values: admin.Values(fields, t.New()),
+	}
+	Render(c, pages.AdminForm(adminMeta(c, "New "+t.Name()), adminEntityNames(), view), nil)
+}
+
+// AdminCreateHandler creates an entity from the submitted form
+func AdminCreateHandler(c *gin.Context) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return
+	}
+	entity := t.New()
+	adminSave(c, t, entity, admin.Path(t.Name(), ""), "New "+t.Name(), true)
+}
+
+// AdminEditHandler renders the edit form of an entity
+func AdminEditHandler(c *gin.Context) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return
+	}
+	entity, ok := adminLoad(c, t)
+	if !ok {
+		return
+	}
+	fields := admin.Fields(t.Type())
+	id := admin.ID(entity)
+	view := admin.FormView{
+		Entity: t.Name(),
+		ID:     id,
+		Action: admin.Path(t.Name(), id),
+		Fields: fields,
+		Values: admin.Values(fields, entity),
+	}
+	Render(c, pages.AdminForm(adminMeta(c, "Edit "+t.Name()+" "+id), adminEntityNames(), view), nil)
+}
+
+// AdminUpdateHandler saves the submitted edit form
+func AdminUpdateHandler(c *gin.Context) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return
+	}
+	entity, ok := adminLoad(c, t)
+	if !ok {
+		return
+	}
+	id := admin.ID(entity)
+	adminSave(c, t, entity, admin.Path(t.Name(), id), "Edit "+t.Name()+" "+id, false)
+}
+
+// adminSave decodes the form into entity and stores it, re-rendering the
+// form with 422 when a value is invalid or the store rejects the entity with
+// a validation error
+func adminSave(c *gin.Context, t data.EntityType, entity any, action string, title string, isNew bool) {
+	if err := c.Request.ParseForm(); err != nil {
+		RenderError(c, errs.Validation("invalid form"), "invalid form")
+		return
+	}
+	before, err := audit.Snapshot(entity)
+	if err != nil {
+		RenderError(c, err, "failed to save "+t.Name())
+		return
+	}
+	fields := admin.Fields(t.Type())
+	invalid := func(formErrors validate.Errors) {
+		view := admin.FormView{
+			Entity: t.Name(),
+			ID:     admin.ID(entity),
+			Action: action,
+			Fields: fields,
+			Values: adminFormValues(fields, entity, c.Request.PostForm),
+			Errors: formErrors,
+		}
+		c.Status(http.StatusUnprocessableEntity)
+		Render(c, pages.AdminForm(adminMeta(c, title), adminEntityNames(), view), pages.AdminFormContent(view))
+	}
+	if formErrors := admin.Decode(fields, entity, c.Request.PostForm); formErrors != nil {
+		invalid(formErrors)
+		return
+	}
+
+	admin.Prepare(entity, isNew)
+	save, verb := t.Update, "update"
+	if isNew {
+		save, verb = t.Create, "create"
+		before = nil
+	}
+	if err := save(c.Request.Context(), entity); err != nil {
+		if errs.Is(err, errs.KindValidation) {
+			invalid(validate.ErrorsOf(err))
+			return
+		}
+		RenderError(c, err, "failed to save "+t.Name())
+		return
+	}
+	adminRecord(c, verb, t, admin.ID(entity), before, entity)
+	Redirect(c, admin.Path(t.Name(), admin.ID(entity)))
+}
+
+// adminFormValues keeps what the user typed for invalid fields
+func adminFormValues(fields []admin.Field, entity any, form url.Values) map[string]string {
+	values := admin.Values(fields, entity)
+	for _, f := range fields {
+		if f.Editable && f.Kind != admin.KindBool {
+			values[f.Name] = form.Get(f.Name)
+		}
+	}
+	return values
+}
+
+// AdminDeleteHandler deletes an entity. Table rows swap themselves out with
+// the empty response; the detail page is sent back to the list.
+func AdminDeleteHandler(c *gin.Context) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return
+	}
+	entity, ok := adminLoad(c, t)
+	if !ok {
+		return
+	}
+	id := admin.ID(entity)
+	if err := t.Delete(c.Request.Context(), id); err != nil {
+		RenderError(c, err, "failed to delete "+t.Name())
+		return
+	}
+	adminRecord(c, "delete", t, id, entity, nil)
+	if IsHTMX(c) && strings.HasPrefix(c.GetHeader("HX-Target"), "admin-row-") {
+		c.Status(http.StatusOK)
+		return
+	}
+	Redirect(c, admin.Path(t.Name(), ""))
+}
+
+// adminField loads the entity and looks up the :field param
+func adminField(c *gin.Context) (data.EntityType, any, admin.Field, bool) {
+	t, ok := adminEntity(c)
+	if !ok {
+		return nil, nil, admin.Field{}, false
+	}
+	field, ok := admin.Lookup(admin.Fields(t.Type()), c.Param("field"))
+	if !ok {
+		RenderError(c, errs.NotFound("unknown field"), "unknown field")
+		return nil, nil, admin.Field{}, false
+	}
+	entity, ok := adminLoad(c, t)
+	if !ok {
+		return nil, nil, admin.Field{}, false
+	}
+	return t, entity, field, true
+}
+
+// AdminFieldHandler renders a field value, ending inline editing
+func AdminFieldHandler(c *gin.Context) {
+	t, entity, field, ok := adminField(c)
+	if !ok {
+		return
+	}
+	Render(c, pages.AdminCell(t.Name(), admin.ID(entity), field, field.Value(entity)), nil)
+}
+
+// AdminFieldEditHandler renders the inline editor of a field
+func AdminFieldEditHandler(c *gin.Context) {
+	t, entity, field, ok := adminField(c)
+	if !ok {
+		return
+	}
+	if !field.Editable {
+		RenderError(c, errs.Validation("field cannot be edited"), "field cannot be edited")
+		return
+	}
+	Render(c, pages.AdminCellEditor(t.Name(), admin.ID(entity), field, field.Value(entity), ""), nil)
+}
+
+// AdminFieldUpdateHandler saves a single field from the inline editor
+func AdminFieldUpdateHandler(c *gin.Context) {
+	t, entity, field, ok := adminField(c)
+	if !ok {
+		return
+	}
+	if !field.Editable {
+		RenderError(c, errs.Validation("field cannot be edited"), "field cannot be edited")
+		return
+	}
+	raw := c.PostForm(field.Name)
+	id := admin.ID(entity)
+	before, err := audit.Snapshot(entity)
+	if err != nil {
+		RenderError(c, err, "failed to save "+t.Name())
+		return
+	}
+	if err := field.Set(entity, raw); err != nil {
+		c.Status(http.StatusUnprocessableEntity)
+		Render(c, pages.AdminCellEditor(t.Name(), id, field, raw, err.Error()), nil)
+		return
+	}
+	admin.Prepare(entity, false)
+	if err := t.Update(c.Request.Context(), entity); err != nil {
+		if errs.Is(err, errs.KindValidation) {
+			message := validate.ErrorsOf(err).Get(field.Name)
+			if message == "" {
+				message = err.Error()
+			}
+			c.Status(http.StatusUnprocessableEntity)
+			Render(c, pages.AdminCellEditor(t.Name(), id, field, raw, message), nil)
+			return
+		}
+		RenderError(c, err, "failed to save "+t.Name())
+		return
+	}
+	adminRecord(c, "update", t, id, before, entity)
+	Render(c, pages.AdminCell(t.Name(), id, field, field.Value(entity)), nil)
+}
+
+// adminRecord records an admin change as "admin.<verb>" in the audit log.
+// The change is saved already, so a failure is logged and not shown.
+func adminRecord(c *gin.Context, verb string, t data.EntityType, id string, before any, after any) {
+	ctx := c.Request.Context()
+	err := audit.Record(ctx, audit.Entry{
+		Action:     "admin." + verb,
+		TargetKind: t.Name(),
+		TargetID:   id,
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		log.Error().Ctx(ctx).Err(err).Str("entity", t.Name()).Str("id", id).Msg("failed to record audit event")
+	}
+}
+
+// AdminAuditHandler renders the audit log filtered by actor, action, entity
+// and date. Filter changes and "Load more" receive only the table rows.
+func AdminAuditHandler(c *gin.Context) {
+	filter := admin.AuditFilterFrom(c.Request.URL.Query())
+	values := filter.ListValues()
+	if cursor := c.Query("cursor"); cursor != "" {
+		values.Set("cursor", cursor)
+	}
+	list, err := query.Parse(values, data.AuditEventList)
+	if err != nil {
+		RenderError(c, err, "invalid audit log filter")
+		return
+	}
+	page, err := audit.Default().List(c.Request.Context(), list)
+	if err != nil {
+		RenderError(c, err, "failed to list audit events")
+		return
+	}
+
+	view := admin.AuditView{Filter: filter, Events: page.Items}
+	if page.NextCursor != "" {
+		next := filter.Query()
+		next.Set("cursor", page.NextCursor)
+		view.Next = admin.AuditPath + "?" + next.Encode()
+	}
+	RenderTarget(c, pages.AdminAudit(adminMeta(c, "Audit log"), adminEntityNames(), view), Fragments{
+		"audit-rows": pages.AdminAuditRows(view),
+		"audit-more": pages.AdminAuditRows(view),
+	})
+}
@@ -0,0 +1,312 @@
+// Package admin describes registered data entities generically, by
+// reflection over their struct fields, for the /app/admin pages: which
+// fields exist, how to display them and how to decode submitted forms.
+package admin
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"runtime-dynamics/data"
+	"runtime-dynamics/web/validate"
+)
+
+// Field kinds
+const (
+	KindString = "string"
+	KindText   = "text"
+	KindInt    = "int"
+	KindFloat  = "float"
+	KindBool   = "bool"
+	KindTime   = "time"
+	// KindOther covers slices, maps, keys and nested structs, shown read-only
+	KindOther = "other"
+)
+
+// TimeLayout is the format of datetime-local inputs
+const TimeLayout = "2006-01-02T15:04"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Field describes one exported field of an entity
+type Field struct {
+	// Name is the json name, used as form field name and in URLs
+	Name     string
+	Label    string
+	Kind     string
+	Editable bool
+	index    []int
+}
+
+// Fields describes t's exported fields in declaration order. ID, CreatedAt,
+// UpdatedAt and fields of unsupported types are not editable.
+func Fields(t reflect.Type) []Field {
+	var fields []Field
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		name := strings.SplitN(sf.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || strings.SplitN(sf.Tag.Get("datastore"), ",", 2)[0] == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		f := Field{Name: name, Label: label(name), Kind: kindOf(sf), index: sf.Index}
+		switch sf.Name {
+		case "ID", "CreatedAt", "UpdatedAt":
+		default:
+			f.Editable = f.Kind != KindOther
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func kindOf(sf reflect.StructField) string {
+	if sf.Type == timeType {
+		return KindTime
+	}
+	switch sf.Type.Kind() {
+	case reflect.String:
+		if strings.Contains(sf.Tag.Get("datastore"), "noindex") {
+			return KindText
+		}
+		return KindString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return KindInt
+	case reflect.Float32, reflect.Float64:
+		return KindFloat
+	case reflect.Bool:
+		return KindBool
+	default:
+		return KindOther
+	}
+}
+
+// label turns "published_at" into "Published at"
+func label(name string) string {
+	s := strings.ReplaceAll(name, "_", " ")
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Lookup returns the field named name
+func Lookup(fields []Field, name string) (Field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Columns returns the fields shown in list tables: everything but long text
+func Columns(fields []Field) []Field {
+	var columns []Field
+	for _, f := range fields {
+		if f.Kind != KindText {
+			columns = append(columns, f)
+		}
+	}
+	return columns
+}
+
+func (f Field) value(entity any) reflect.Value {
+	return reflect.ValueOf(entity).Elem().FieldByIndex(f.index)
+}
+
+// Value formats the field of entity, a pointer to a struct, for display and
+// as form input value
+func (f Field) Value(entity any) string {
+	v := f.value(entity)
+	switch f.Kind {
+	case KindString, KindText:
+		return v.String()
+	case KindInt:
+		return strconv.FormatInt(v.Int(), 10)
+	case KindFloat:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case KindBool:
+		return strconv.FormatBool(v.Bool())
+	case KindTime:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(TimeLayout)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Set parses raw and stores it in the field of entity. The returned error is
+// a user-facing message.
+func (f Field) Set(entity any, raw string) error {
+	v := f.value(entity)
+	raw = strings.TrimSpace(raw)
+	switch f.Kind {
+	case KindString, KindText:
+		v.SetString(raw)
+	case KindInt:
+		if raw == "" {
+			raw = "0"
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return fmt.Errorf("must be a whole number")
+		}
+		v.SetInt(n)
+	case KindFloat:
+		if raw == "" {
+			raw = "0"
+		}
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		v.SetFloat(n)
+	case KindBool:
+		v.SetBool(raw == "true" || raw == "on")
+	case KindTime:
+		if raw == "" {
+			v.Set(reflect.ValueOf(time.Time{}))
+			return nil
+		}
+		t, err := time.Parse(TimeLayout, raw)
+		if err != nil {
+			return fmt.Errorf("must be a date and time")
+		}
+		v.Set(reflect.ValueOf(t))
+	default:
+		return fmt.Errorf("cannot be edited")
+	}
+	return nil
+}
+
+// Decode sets the editable fields of entity from form. Unchecked checkboxes
+// are absent from forms, so missing bool fields become false. Errors are
+// keyed by field name; nil means every field was set.
+func Decode(fields []Field, entity any, form url.Values) validate.Errors {
+	var errors validate.Errors
+	for _, f := range fields {
+		if !f.Editable {
+			continue
+		}
+		if err := f.Set(entity, form.Get(f.Name)); err != nil {
+			if errors == nil {
+				errors = validate.Errors{}
+			}
+			errors[f.Name] = err.Error()
+		}
+	}
+	return errors
+}
+
+// Prepare fills the generated fields before entity is saved: a new ID and
+// CreatedAt for new entities, and UpdatedAt
+func Prepare(entity any, isNew bool) {
+	v := reflect.ValueOf(entity).Elem()
+	now := time.Now().UTC()
+	if isNew {
+		if id := v.FieldByName("ID"); id.IsValid() && id.Kind() == reflect.String && id.String() == "" {
+			id.SetString(data.NewID())
+		}
+		if created := v.FieldByName("CreatedAt"); created.IsValid() && created.Type() == timeType {
+			created.Set(reflect.ValueOf(now))
+		}
+	}
+	if updated := v.FieldByName("UpdatedAt"); updated.IsValid() && updated.Type() == timeType {
+		updated.Set(reflect.ValueOf(now))
+	}
+}
+
+// ID returns the ID field of entity, "" when it has none
+func ID(entity any) string {
+	id := reflect.ValueOf(entity).Elem().FieldByName("ID")
+	if !id.IsValid() || id.Kind() != reflect.String {
+		return ""
+	}
+	return id.String()
+}
+
+// Row is one entity prepared for a list table
+type Row struct {
+	ID string
+	// Values are the formatted column values
+	Values []string
+}
+
+// Rows formats entities for the given columns
+func Rows(columns []Field, entities []any) []Row {
+	rows := make([]Row, 0, len(entities))
+	for _, entity := range entities {
+		row := Row{ID: ID(entity)}
+		for _, f := range columns {
+			row.Values = append(row.Values, f.Value(entity))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Values formats every field of entity by name, for detail pages and forms
+func Values(fields []Field, entity any) map[string]string {
+	values := make(map[string]string, len(fields))
+	for _, f := range fields {
+		values[f.Name] = f.Value(entity)
+	}
+	return values
+}
+
+// ListView is what the admin list page shows
+type ListView struct {
+	Entity  string
+	Columns []Field
+	Rows    []Row
+	// Next loads the following page; empty on the last page
+	Next string
+	// Search is the current search text; Searchable shows the search box
+	Search     string
+	Searchable bool
+}
+
+// DetailView is what the admin detail page shows
+type DetailView struct {
+	Entity string
+	ID     string
+	Fields []Field
+	Values map[string]string
+}
+
+// FormView is what the admin create and edit forms show
+type FormView struct {
+	Entity string
+	// ID is empty for new entities
+	ID     string
+	Action string
+	Fields []Field
+	Values map[string]string
+	Errors validate.Errors
+}
+
+// Path returns the admin URL of an entity type, or of one entity when id is set
+func Path(entity string, id string) string {
+	path := "/app/admin/" + url.PathEscape(entity)
+	if id != "" {
+		path += "/" + url.PathEscape(id)
+	}
+	return path
+}
+
+// FieldPath returns the URL of one field of an entity, used by inline editing
+func FieldPath(entity string, id string, field string) string {
+	return Path(entity, id) + "/fields/" + url.PathEscape(field)
+}
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type article struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body" datastore:",noindex"`
+	Views     int64     `json:"views"`
+	Rating    float64   `json:"rating"`
+	Published bool      `json:"published"`
+	DueAt     time.Time `json:"due_at"`
+	Tags      []string  `json:"tags"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func TestFields(t *testing.T) {
+	fields := Fields(reflect.TypeOf(article{}))
+
+	var names, kinds []string
+	var editable []bool
+	for _, f := range fields {
+		names = append(names, f.Name)
+		kinds = append(kinds, f.Kind)
+		editable = append(editable, f.Editable)
+	}
+	assert.Equal(t, []string{"id", "title", "body", "views", "rating", "published", "due_at", "tags", "created_at", "updated_at"}, names)
+	assert.Equal(t, []string{KindString, KindString, KindText, KindInt, KindFloat, KindBool, KindTime, KindOther, KindTime, KindTime}, kinds)
+	assert.Equal(t, []bool{false, true, true, true, true, true, true, false, false, false}, editable)
+	assert.Equal(t, "Due at", fields[6].Label)
+
+	columns := Columns(fields)
+	assert.Len(t, columns, len(fields)-1)
+	_, ok := Lookup(columns, "body")
+	assert.False(t, ok)
+}
+
+func TestDecode(t *testing.T) {
+	fields := Fields(reflect.TypeOf(article{}))
+
+	tests := []struct {
+		name           string
+		form           url.Values
+		expectedErrors map[string]string
+		check          func(t *testing.T, a *article)
+	}{
+		{
+			name: "valid values",
+			form: url.Values{
+				"id": {"ignored"}, "title": {" Hello "}, "views": {"42"}, "rating": {"4.5"},
+				"published": {"true"}, "due_at": {"2025-03-01T09:30"},
+			},
+			check: func(t *testing.T, a *article) {
+				assert.Equal(t, "a1", a.ID)
+				assert.Equal(t, "Hello", a.Title)
+				assert.Equal(t, int64(42), a.Views)
+				assert.Equal(t, 4.5, a.Rating)
+				assert.True(t, a.Published)
+				assert.Equal(t, time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC), a.DueAt)
+			},
+		},
+		{
+			name: "missing checkbox and empty values reset fields",
+			form: url.Values{},
+			check: func(t *testing.T, a *article) {
+				assert.False(t, a.Published)
+				assert.Equal(t, int64(0), a.Views)
+				assert.True(t, a.DueAt.IsZero())
+			},
+		},
+		{
+			name:           "invalid values",
+			form:           url.Values{"views": {"many"}, "rating": {"x"}, "due_at": {"tomorrow"}},
+			expectedErrors: map[string]string{"views": "must be a whole number", "rating": "must be a number", "due_at": "must be a date and time"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &article{ID: "a1", Published: true, Views: 7, DueAt: time.Now()}
+			errors := Decode(fields, a, tt.form)
+			if tt.expectedErrors == nil {
+				assert.Nil(t, errors)
+			} else {
+				assert.Equal(t, tt.expectedErrors, map[string]string(errors))
+			}
+			if tt.check != nil {
+				tt.check(t, a)
+			}
+		})
+	}
+}
+
+func TestPrepareAndRows(t *testing.T) {
+	a := &article{Title: "Hello", Views: 3}
+	Prepare(a, true)
+	assert.Len(t, a.ID, 32)
+	assert.False(t, a.CreatedAt.IsZero())
+	assert.Equal(t, a.CreatedAt, a.UpdatedAt)
+
+	fields := Fields(reflect.TypeOf(article{}))
+	columns := []Field{fields[1], fields[3], fields[5]}
+	rows := Rows(columns, []any{a})
+	assert.Equal(t, []Row{{ID: a.ID, Values: []string{"Hello", "3", "false"}}}, rows)
+	assert.Equal(t, "Hello", Values(fields, a)["title"])
+}
+
+func TestPaths(t *testing.T) {
+	assert.Equal(t, "/app/admin/BlogPost", Path("BlogPost", ""))
+	assert.Equal(t, "/app/admin/BlogPost/a%2Fb", Path("BlogPost", "a/b"))
+	assert.Equal(t, "/app/admin/BlogPost/1/fields/title", FieldPath("BlogPost", "1", "title"))
+}
+
+func TestAuditFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   AuditFilter
+		expected url.Values
+	}{
+		{name: "empty", filter: AuditFilter{}, expected: url.Values{}},
+		{
+			name:     "actor id",
+			filter:   AuditFilter{Actor: "u1", Action: "admin.update"},
+			expected: url.Values{"filter[actor_id]": {"u1"}, "filter[action]": {"admin.update"}},
+		},
+		{
+			name:     "actor email and dates",
+			filter:   AuditFilter{Actor: "Ada@Example.com", From: "2026-05-01", To: "2026-05-31"},
+			expected: url.Values{"filter[actor_email]": {"ada@example.com"}, "filter[created_at][gte]": {"2026-05-01"}, "filter[created_at][lt]": {"2026-06-01"}},
+		},
+		{
+			name:     "entity",
+			filter:   AuditFilter{Kind: "Note", Target: "n1"},
+			expected: url.Values{"filter[target_kind]": {"Note"}, "filter[target_id]": {"n1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.ListValues())
+			assert.Equal(t, tt.filter, AuditFilterFrom(tt.filter.Query()))
+		})
+	}
+	assert.Equal(t, "/app/admin/audit?kind=Note&target=n%2F1", HistoryPath("Note", "n/1"))
+}
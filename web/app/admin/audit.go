@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"runtime-dynamics/data"
+)
+
+// AuditPath is the URL of the audit log page
+const AuditPath = "/app/admin/audit"
+
+// AuditFilter is the search form of the audit log page. Its fields are the
+// plain query parameters of the page.
+type AuditFilter struct {
+	// Actor is a user ID, or an email when it contains "@"
+	Actor  string
+	Action string
+	Kind   string
+	Target string
+	// From and To are inclusive dates, 2006-01-02
+	From string
+	To   string
+}
+
+// AuditFilterFrom reads the filter from the page's query parameters
+func AuditFilterFrom(values url.Values) AuditFilter {
+	return AuditFilter{
+		Actor:  strings.TrimSpace(values.Get("actor")),
+		Action: strings.TrimSpace(values.Get("action")),
+		Kind:   strings.TrimSpace(values.Get("kind")),
+		Target: strings.TrimSpace(values.Get("target")),
+		From:   strings.TrimSpace(values.Get("from")),
+		To:     strings.TrimSpace(values.Get("to")),
+	}
+}
+
+// Query returns the filter as the page's query parameters
+func (f AuditFilter) Query() url.Values {
+	values := url.Values{}
+	for name, value := range map[string]string{
+		"actor": f.Actor, "action": f.Action, "kind": f.Kind,
+		"target": f.Target, "from": f.From, "to": f.To,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	return values
+}
+
+// ListValues returns the filter as data.AuditEventList filters for
+// query.Parse
+func (f AuditFilter) ListValues() url.Values {
+	values := url.Values{}
+	if f.Actor != "" {
+		if strings.Contains(f.Actor, "@") {
+			values.Set("filter[actor_email]", strings.ToLower(f.Actor))
+		} else {
+			values.Set("filter[actor_id]", f.Actor)
+		}
+	}
+	if f.Action != "" {
+		values.Set("filter[action]", f.Action)
+	}
+	if f.Kind != "" {
+		values.Set("filter[target_kind]", f.Kind)
+	}
+	if f.Target != "" {
+		values.Set("filter[target_id]", f.Target)
+	}
+	if f.From != "" {
+		values.Set("filter[created_at][gte]", f.From)
+	}
+	if f.To != "" {
+		// include the whole last day; invalid dates are reported by query.Parse
+		to := f.To
+		if day, err := time.Parse("2006-01-02", f.To); err == nil {
+			to = day.AddDate(0, 0, 1).Format("2006-01-02")
+		}
+		values.Set("filter[created_at][lt]", to)
+	}
+	return values
+}
+
+// AuditView is what the audit log page shows
+type AuditView struct {
+	Filter AuditFilter
+	Events []data.AuditEvent
+	// Next loads the following page; empty on the last page
+	Next string
+}
+
+// HistoryPath returns the audit log of one entity
+func HistoryPath(entity string, id string) string {
+	return AuditPath + "?" + AuditFilter{Kind: entity, Target: id}.Query().Encode()
+}
@@ -0,0 +1,457 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"runtime-dynamics/audit"
+	"runtime-dynamics/auth"
+	"runtime-dynamics/data"
+	"runtime-dynamics/data/query"
+	"runtime-dynamics/errs"
+
+	"cloud.google.com/go/datastore"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type gadget struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Stock     int64     `json:"stock"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// gadgetStore is an in-memory data.Store recording the last list query
+type gadgetStore struct {
+	items    map[string]gadget
+	lastList query.List
+}
+
+func (s *gadgetStore) GetByID(ctx context.Context, id string) (*gadget, error) {
+	g, ok := s.items[id]
+	if !ok {
+		return nil, datastore.ErrNoSuchEntity
+	}
+	return &g, nil
+}
+
+func (s *gadgetStore) List(ctx context.Context, list query.List) (query.Page[gadget], error) {
+	s.lastList = list
+	page := query.Page[gadget]{Items: []gadget{}}
+	for _, g := range s.items {
+		page.Items = append(page.Items, g)
+	}
+	sort.Slice(page.Items, func(i, j int) bool { return page.Items[i].ID < page.Items[j].ID })
+	return page, nil
+}
+
+func (s *gadgetStore) Create(ctx context.Context, g *gadget) error {
+	s.items[g.ID] = *g
+	return nil
+}
+
+func (s *gadgetStore) Update(ctx context.Context, g *gadget) error {
+	if g.Stock > 100 {
+		return errs.Validation("invalid gadget", errs.FieldError{Field: "stock", Message: "is more than the warehouse holds"})
+	}
+	s.items[g.ID] = *g
+	return nil
+}
+
+func (s *gadgetStore) Delete(ctx context.Context, id string) error {
+	delete(s.items, id)
+	return nil
+}
+
+// auditStore is an in-memory audit.Store recording the last list query
+type auditStore struct {
+	events   []data.AuditEvent
+	lastList query.List
+}
+
+func (s *auditStore) Create(ctx context.Context, event *data.AuditEvent) error {
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *auditStore) List(ctx context.Context, list query.List) (query.Page[data.AuditEvent], error) {
+	s.lastList = list
+	return query.Page[data.AuditEvent]{Items: s.events, NextCursor: "next"}, nil
+}
+
+// useAuditStore records audit events in a fresh auditStore for the test
+func useAuditStore(t *testing.T) *auditStore {
+	events := &auditStore{}
+	previous := audit.Default()
+	audit.SetDefault(audit.NewRecorder(events))
+	t.Cleanup(func() { audit.SetDefault(previous) })
+	return events
+}
+
+func validateGadget(ctx context.Context, g *gadget) error {
+	if g.Name == "" {
+		return errs.Validation("invalid gadget", errs.FieldError{Field: "name", Message: "is required"})
+	}
+	return nil
+}
+
+var gadgetList = query.Spec{
+	Fields: map[string]query.Field{
+		"name":       {Property: "Name", Ops: []query.Op{query.Eq}, Sortable: true},
+		"created_at": {Property: "CreatedAt", Type: query.Time, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// adminRouter serves the admin pages over a fresh registry holding one gadget,
+// as an admin unless user is given
+func adminRouter(t *testing.T, user *auth.User) (*gin.Engine, *gadgetStore) {
+	store := &gadgetStore{items: map[string]gadget{
+		"g1": {ID: "g1", Name: "Sprocket", Stock: 3},
+	}}
+	registry := data.NewEntityRegistry()
+	registry.Register(data.Entity[gadget]{Kind: "Gadget", Store: store, Spec: gadgetList, Search: "name", Validate: validateGadget})
+	previous := adminEntities
+	adminEntities = registry
+	t.Cleanup(func() { adminEntities = previous })
+	useAuditStore(t)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+			c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), user))
+		}
+	})
+	registerAdminPages(router)
+	return router, store
+}
+
+var adminUser = &auth.User{ID: "admin", Roles: []string{auth.RoleAdmin}}
+
+// adminRequest sends a form as the browser does from the admin pages,
+// unless headers say otherwise
+func adminRequest(router *gin.Engine, method string, path string, form url.Values, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminPages_RequireAdmin(t *testing.T) {
+	tests := []struct {
+		name           string
+		user           *auth.User
+		expectedStatus int
+	}{
+		{name: "anonymous", expectedStatus: http.StatusUnauthorized},
+		{name: "regular user", user: &auth.User{ID: "u1"}, expectedStatus: http.StatusForbidden},
+		{name: "admin", user: adminUser, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := adminRouter(t, tt.user)
+			w := adminRequest(router, http.MethodGet, "/app/admin", nil, nil)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAdminPages_Render(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+		contains       []string
+		notContains    []string
+	}{
+		{
+			name:           "dashboard",
+			path:           "/app/admin",
+			expectedStatus: http.StatusOK,
+			contains:       []string{`href="/app/admin/Gadget"`, "noindex"},
+		},
+		{
+			name:           "list",
+			path:           "/app/admin/Gadget",
+			expectedStatus: http.StatusOK,
+			contains:       []string{"Sprocket", `id="admin-row-g1"`, `name="q"`, "<html"},
+		},
+		{
+			name:           "search renders only rows",
+			path:           "/app/admin/Gadget?q=Spr",
+			headers:        map[string]string{"HX-Request": "true", "HX-Target": "admin-rows"},
+			expectedStatus: http.StatusOK,
+			contains:       []string{"Sprocket"},
+			notContains:    []string{"<html"},
+		},
+		{
+			name:           "invalid list query",
+			path:           "/app/admin/Gadget?filter[stock]=1",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown entity",
+			path:           "/app/admin/Unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "detail",
+			path:           "/app/admin/Gadget/g1",
+			expectedStatus: http.StatusOK,
+			contains:       []string{"Sprocket", "Stock", `/app/admin/Gadget/g1/edit`},
+		},
+		{
+			name:           "missing entity",
+			path:           "/app/admin/Gadget/nope",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "new form",
+			path:           "/app/admin/Gadget/new",
+			expectedStatus: http.StatusOK,
+			contains:       []string{`id="admin-form"`, `name="stock"`, `name="active"`},
+			notContains:    []string{`name="created_at"`},
+		},
+		{
+			name:           "inline editor",
+			path:           "/app/admin/Gadget/g1/fields/stock/edit",
+			expectedStatus: http.StatusOK,
+			contains:       []string{`hx-put="/app/admin/Gadget/g1/fields/stock"`, `value="3"`},
+		},
+		{
+			name:           "inline editor of a read-only field",
+			path:           "/app/admin/Gadget/g1/fields/created_at/edit",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := adminRouter(t, adminUser)
+			w := adminRequest(router, http.MethodGet, tt.path, nil, tt.headers)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, s := range tt.contains {
+				assert.Contains(t, w.Body.String(), s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, w.Body.String(), s)
+			}
+		})
+	}
+}
+
+func TestAdminListHandler_Search(t *testing.T) {
+	router, store := adminRouter(t, adminUser)
+	w := adminRequest(router, http.MethodGet, "/app/admin/Gadget?q=Spr&sort=-created_at", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, []query.Order{{Field: "name", Property: "Name"}}, store.lastList.Sort)
+	assert.Equal(t, []query.Filter{
+		{Field: "name", Property: "Name", Op: query.Gte, Value: "Spr"},
+		{Field: "name", Property: "Name", Op: query.Lt, Value: "Spr￿"},
+	}, store.lastList.Filters)
+}
+
+func TestAdminPages_Write(t *testing.T) {
+	t.Run("create", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodPost, "/app/admin/Gadget", url.Values{"name": {"Widget"}, "stock": {"5"}, "active": {"true"}}, nil)
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Len(t, store.items, 2)
+		for id, g := range store.items {
+			if id != "g1" {
+				assert.Equal(t, "Widget", g.Name)
+				assert.Equal(t, int64(5), g.Stock)
+				assert.True(t, g.Active)
+				assert.False(t, g.CreatedAt.IsZero())
+				assert.Contains(t, w.Header().Get("Location"), "/app/admin/Gadget/"+id)
+			}
+		}
+	})
+
+	t.Run("create with invalid value", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodPost, "/app/admin/Gadget", url.Values{"name": {"Widget"}, "stock": {"lots"}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "must be a whole number")
+		assert.Contains(t, w.Body.String(), `value="lots"`)
+		assert.NotContains(t, w.Body.String(), "<html")
+		assert.Len(t, store.items, 1)
+	})
+
+	t.Run("create rejected by validator", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodPost, "/app/admin/Gadget", url.Values{"name": {""}, "stock": {"5"}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "is required")
+		assert.Len(t, store.items, 1)
+
+		w = adminRequest(router, http.MethodPut, "/app/admin/Gadget/g1/fields/name", url.Values{"name": {""}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "is required")
+		assert.Equal(t, "Sprocket", store.items["g1"].Name)
+	})
+
+	t.Run("cross-site requests", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		crossSite := map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.test"}
+		w := adminRequest(router, http.MethodPost, "/app/admin/Gadget", url.Values{"name": {"Widget"}}, crossSite)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = adminRequest(router, http.MethodPost, "/app/admin/Gadget/g1", url.Values{"name": {"Hacked"}}, crossSite)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = adminRequest(router, http.MethodPut, "/app/admin/Gadget/g1/fields/name", url.Values{"name": {"Hacked"}}, crossSite)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = adminRequest(router, http.MethodDelete, "/app/admin/Gadget/g1", nil, crossSite)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, map[string]gadget{"g1": {ID: "g1", Name: "Sprocket", Stock: 3}}, store.items)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodPost, "/app/admin/Gadget/g1", url.Values{"name": {"Sprocket XL"}, "stock": {"4"}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "/app/admin/Gadget/g1", w.Header().Get("HX-Redirect"))
+		assert.Equal(t, "Sprocket XL", store.items["g1"].Name)
+		assert.False(t, store.items["g1"].UpdatedAt.IsZero())
+	})
+
+	t.Run("update rejected by store", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodPost, "/app/admin/Gadget/g1", url.Values{"name": {"Sprocket"}, "stock": {"500"}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "is more than the warehouse holds")
+		assert.Contains(t, w.Body.String(), `value="500"`)
+		assert.Equal(t, int64(3), store.items["g1"].Stock)
+
+		w = adminRequest(router, http.MethodPut, "/app/admin/Gadget/g1/fields/stock", url.Values{"stock": {"500"}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "is more than the warehouse holds")
+	})
+
+	t.Run("inline update", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodPut, "/app/admin/Gadget/g1/fields/stock", url.Values{"stock": {"9"}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "9")
+		assert.Equal(t, int64(9), store.items["g1"].Stock)
+		assert.Equal(t, "Sprocket", store.items["g1"].Name)
+	})
+
+	t.Run("inline update with invalid value", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodPut, "/app/admin/Gadget/g1/fields/stock", url.Values{"stock": {"x"}}, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "must be a whole number")
+		assert.Equal(t, int64(3), store.items["g1"].Stock)
+	})
+
+	t.Run("delete row", func(t *testing.T) {
+		router, store := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodDelete, "/app/admin/Gadget/g1", nil, map[string]string{"HX-Request": "true", "HX-Target": "admin-row-g1"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Empty(t, store.items)
+	})
+
+	t.Run("delete from detail page", func(t *testing.T) {
+		router, _ := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodDelete, "/app/admin/Gadget/g1", nil, map[string]string{"HX-Request": "true"})
+		assert.Equal(t, "/app/admin/Gadget", w.Header().Get("HX-Redirect"))
+	})
+}
+
+func TestAdminPages_Audit(t *testing.T) {
+	t.Run("records changes", func(t *testing.T) {
+		router, _ := adminRouter(t, adminUser)
+		events := useAuditStore(t)
+
+		adminRequest(router, http.MethodPost, "/app/admin/Gadget/g1", url.Values{"name": {"Sprocket XL"}, "stock": {"3"}}, nil)
+		adminRequest(router, http.MethodPut, "/app/admin/Gadget/g1/fields/stock", url.Values{"stock": {"9"}}, nil)
+		adminRequest(router, http.MethodDelete, "/app/admin/Gadget/g1", nil, nil)
+
+		if assert.Len(t, events.events, 3) {
+			update := events.events[0]
+			assert.Equal(t, "admin.update", update.Action)
+			assert.Equal(t, "admin", update.ActorID)
+			assert.Equal(t, "Gadget", update.TargetKind)
+			assert.Equal(t, "g1", update.TargetID)
+			assert.Contains(t, update.Changes, data.AuditChange{Field: "name", Before: "Sprocket", After: "Sprocket XL"})
+
+			assert.Contains(t, events.events[1].Changes, data.AuditChange{Field: "stock", Before: "3", After: "9"})
+
+			deleted := events.events[2]
+			assert.Equal(t, "admin.delete", deleted.Action)
+			assert.Contains(t, deleted.Changes, data.AuditChange{Field: "name", Before: "Sprocket XL"})
+		}
+	})
+
+	t.Run("invalid form records nothing", func(t *testing.T) {
+		router, _ := adminRouter(t, adminUser)
+		events := useAuditStore(t)
+		adminRequest(router, http.MethodPost, "/app/admin/Gadget", url.Values{"stock": {"lots"}}, nil)
+		assert.Empty(t, events.events)
+	})
+
+	t.Run("log page", func(t *testing.T) {
+		router, _ := adminRouter(t, adminUser)
+		events := useAuditStore(t)
+		events.events = []data.AuditEvent{{
+			ID: "e1", Action: "admin.update", ActorID: "u1", ActorEmail: "ada@example.com",
+			TargetKind: "Gadget", TargetID: "g1", IP: "192.0.2.7",
+			Changes:   []data.AuditChange{{Field: "name", Before: "Sprocket", After: "Sprocket XL"}},
+			CreatedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+		}}
+
+		w := adminRequest(router, http.MethodGet, "/app/admin/audit?actor=Ada@example.com&kind=Gadget&to=2026-05-01", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "<html")
+		assert.Contains(t, body, "ada@example.com")
+		assert.Contains(t, body, `href="/app/admin/Gadget/g1"`)
+		assert.Contains(t, body, "Sprocket XL")
+		assert.Contains(t, body, "cursor=next")
+
+		filters := map[string]any{}
+		for _, f := range events.lastList.Filters {
+			filters[f.Field+"."+string(f.Op)] = f.Value
+		}
+		assert.Equal(t, map[string]any{
+			"actor_email.eq": "ada@example.com",
+			"target_kind.eq": "Gadget",
+			"created_at.lt":  time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC),
+		}, filters)
+
+		w = adminRequest(router, http.MethodGet, "/app/admin/audit?kind=Gadget", nil, map[string]string{"HX-Request": "true", "HX-Target": "audit-rows"})
+		assert.NotContains(t, w.Body.String(), "<html")
+		assert.Contains(t, w.Body.String(), "admin.update")
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		router, _ := adminRouter(t, adminUser)
+		w := adminRequest(router, http.MethodGet, "/app/admin/audit?from=yesterday", nil, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		router, _ := adminRouter(t, &auth.User{ID: "u1"})
+		w := adminRequest(router, http.MethodGet, "/app/admin/audit", nil, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
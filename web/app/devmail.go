@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	"runtime-dynamics/errs"
+	"runtime-dynamics/mail"
+	"runtime-dynamics/views/emails"
+	"runtime-dynamics/views/layouts"
+	"runtime-dynamics/views/pages"
+	"runtime-dynamics/web/proxy"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	mail.RegisterPreview("welcome", func(ctx context.Context) (mail.Message, error) {
+		return mail.Compose(ctx, mail.Message{
+			To:      []string{"Ada Lovelace <ada@example.com>"},
+			Subject: "Welcome to " + layouts.CurrentSite().Name,
+		}, emails.Welcome("Ada", proxy.CanonicalURL()+"/app"))
+	})
+}
+
+// registerDevMailPages registers the email previews under /app/dev/mail in
+// development only, since they render emails with sample data
+func registerDevMailPages(r *gin.Engine) {
+	if os.Getenv("IS_DEV") != "true" {
+		return
+	}
+	r.GET("/app/dev/mail", DevMailHandler)
+	r.GET("/app/dev/mail/:name", DevMailHandler)
+	r.GET("/app/dev/mail/:name/html", DevMailHTMLHandler)
+}
+
+// DevMailHandler renders the preview named in the URL, or the first one
+func DevMailHandler(c *gin.Context) {
+	names := mail.Previews()
+	selected := c.Param("name")
+	if selected == "" && len(names) > 0 {
+		selected = names[0]
+	}
+
+	var msg mail.Message
+	if selected != "" {
+		var ok bool
+		if msg, ok = renderPreview(c, selected); !ok {
+			return
+		}
+	}
+	Render(c, pages.DevMail(adminMeta(c, "Email previews"), names, selected, msg), nil)
+}
+
+// DevMailHTMLHandler serves the HTML part of a preview for the preview iframe
+func DevMailHTMLHandler(c *gin.Context) {
+	msg, ok := renderPreview(c, c.Param("name"))
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.HTML))
+}
+
+// renderPreview builds the preview named name, writing the error response
+// when it is unknown or fails
+func renderPreview(c *gin.Context, name string) (mail.Message, bool) {
+	msg, err := mail.RenderPreview(c.Request.Context(), name)
+	if errors.Is(err, mail.ErrNoPreview) {
+		err = errs.NotFound("unknown email preview")
+	}
+	if err != nil {
+		RenderError(c, err, "failed to render email preview")
+		return msg, false
+	}
+	return msg, true
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterDevMailPages(t *testing.T) {
+	tests := []struct {
+		name       string
+		isDev      string
+		wantStatus int
+	}{
+		{name: "development", isDev: "true", wantStatus: http.StatusOK},
+		{name: "production", isDev: "", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IS_DEV", tt.isDev)
+			router := gin.New()
+			registerDevMailPages(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/app/dev/mail", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestDevMailHandlers(t *testing.T) {
+	router := gin.New()
+	router.GET("/app/dev/mail/:name", DevMailHandler)
+	router.GET("/app/dev/mail/:name/html", DevMailHTMLHandler)
+
+	tests := []struct {
+		name         string
+		path         string
+		wantStatus   int
+		wantContains []string
+	}{
+		{
+			name:         "preview page",
+			path:         "/app/dev/mail/welcome",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"Email previews", "ada@example.com", `src="/app/dev/mail/welcome/html"`, "Welcome, Ada!"},
+		},
+		{
+			name:         "html part",
+			path:         "/app/dev/mail/welcome/html",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"Welcome, Ada!", "Open the app"},
+		},
+		{
+			name:       "unknown preview",
+			path:       "/app/dev/mail/missing",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			for _, want := range tt.wantContains {
+				assert.Contains(t, w.Body.String(), want)
+			}
+		})
+	}
+}
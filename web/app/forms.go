@@ -0,0 +1,25 @@
+package app
+
+import (
+	"runtime-dynamics/web/validate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BindForm decodes the submitted form into a new T and validates it. The
+// returned value is never nil so the form can be re-rendered with what the
+// user typed; errors is nil when the input is valid.
+//
+//	input, formErrors := BindForm[ContactInput](c)
+//	if formErrors != nil {
+//		c.Status(http.StatusUnprocessableEntity)
+//		Render(c, pages.Contact(meta, *input, formErrors), pages.ContactForm(*input, formErrors))
+//		return
+//	}
+func BindForm[T any](c *gin.Context) (*T, validate.Errors) {
+	v := new(T)
+	if err := validate.Form(c, v); err != nil {
+		return v, validate.ErrorsOf(err)
+	}
+	return v, nil
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"runtime-dynamics/views/components"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type contactInput struct {
+	Name  string `form:"name" binding:"required"`
+	Email string `form:"email" binding:"required,email"`
+}
+
+func TestBindForm(t *testing.T) {
+	router := gin.New()
+	router.POST("/contact", func(c *gin.Context) {
+		input, formErrors := BindForm[contactInput](c)
+		if formErrors != nil {
+			c.Status(http.StatusUnprocessableEntity)
+			Render(c, components.TextField("Email", "email", "email", input.Email, formErrors), nil)
+			return
+		}
+		c.String(http.StatusOK, "thanks "+input.Name)
+	})
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/contact", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(url.Values{"name": {"Ada"}, "email": {"ada@example.com"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "thanks Ada", w.Body.String())
+
+	w = post(url.Values{"name": {"Ada"}, "email": {"ada"}})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `value="ada"`)
+	assert.Contains(t, w.Body.String(), `aria-invalid="true"`)
+	assert.Contains(t, w.Body.String(), `<p id="email-error" class="mt-1 text-sm text-red-600">must be a valid email address</p>`)
+}
+
+func TestFormErrors_FormLevel(t *testing.T) {
+	var buf bytes.Buffer
+	err := components.FormErrors(map[string]string{"": "Something went wrong, please try again"}).Render(context.Background(), &buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `role="alert"`)
+
+	buf.Reset()
+	assert.NoError(t, components.FormErrors(nil).Render(context.Background(), &buf))
+	assert.Empty(t, buf.String())
+}
@@ -2,15 +2,16 @@ package app
 
 import (
 	"runtime-dynamics/views/pages"
+	"runtime-dynamics/web/app/seo"
 
 	"github.com/gin-gonic/gin"
 )
 
 // HomePageHandler renders the homepage
 func HomePageHandler(c *gin.Context) {
-	// Render the homepage using templ
-	component := pages.Home()
-	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
-		c.String(500, "Error rendering page")
-	}
+	meta := seo.NewMeta(c, "Welcome", "A modern Go web application built with HTMX, Alpine.js, and Templ.")
+	meta = meta.WithStructuredData(seo.WebSiteSchema(meta.SiteName, meta.Canonical))
+
+	// Render the homepage, or only its content for htmx requests
+	Render(c, pages.Home(meta), pages.HomeContent())
 }
@@ -3,8 +3,14 @@ package app
 import (
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
+
+	"runtime-dynamics/web/api"
+	"runtime-dynamics/web/app/seo"
+	"runtime-dynamics/web/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -126,8 +132,27 @@ func TestHomePageHandler_Navigation(t *testing.T) {
 
 	// Verify navigation elements
 	assert.Contains(t, body, "<nav", "Expected navigation element")
-	assert.Contains(t, body, "/about", "Expected About link")
-	assert.Contains(t, body, "/docs", "Expected Docs link")
+	assert.Contains(t, body, `href="/api/docs"`, "Expected API Docs link")
+	assert.NotContains(t, body, `href="/about"`, "No About page is registered")
+}
+
+// TestHomePageHandler_LinksResolve follows every internal link of the home
+// page, navigation and footer included, on the registered routes
+func TestHomePageHandler_LinksResolve(t *testing.T) {
+	router := gin.New()
+	api.RegisterRoutes(router)
+	RegisterWebRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	links := regexp.MustCompile(`<a [^>]*href="(/[^/"][^"]*|/)"`).FindAllStringSubmatch(w.Body.String(), -1)
+	assert.NotEmpty(t, links)
+
+	for _, link := range links {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", link[1], nil))
+		assert.NotEqual(t, http.StatusNotFound, w.Code, "link to %s", link[1])
+	}
 }
 
 func TestHomePageHandler_Footer(t *testing.T) {
@@ -188,6 +213,43 @@ func TestHomePageHandler_NoErrors(t *testing.T) {
 	}
 }
 
+func TestHomePageHandler_SEOMeta(t *testing.T) {
+	router := gin.New()
+	router.GET("/", HomePageHandler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Host = "example.com"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	body := w.Body.String()
+
+	// Verify page metadata supplied by the handler
+	assert.Contains(t, body, `<meta name="description"`, "Expected description meta tag")
+	assert.Contains(t, body, `<link rel="canonical" href="http://example.com/">`, "Expected canonical link")
+	assert.Contains(t, body, `<meta property="og:title" content="Welcome">`, "Expected Open Graph title")
+	assert.Contains(t, body, `<meta name="twitter:card" content="summary">`, "Expected Twitter card")
+	assert.Contains(t, body, `type="application/ld+json"`, "Expected JSON-LD structured data")
+}
+
+func TestHomePageHandler_HTMXFragment(t *testing.T) {
+	router := gin.New()
+	router.GET("/", HomePageHandler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	body := w.Body.String()
+
+	// Verify only the page content is returned
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, body, "H.A.T. Stack", "Expected homepage content")
+	assert.NotContains(t, body, "<html", "Expected fragment without layout")
+	assert.NotContains(t, body, "<footer", "Expected fragment without footer")
+}
+
 // Benchmark tests
 func BenchmarkHomePageHandler(b *testing.B) {
 	gin.SetMode(gin.TestMode)
@@ -202,3 +264,21 @@ func BenchmarkHomePageHandler(b *testing.B) {
 		router.ServeHTTP(w, req)
 	}
 }
+
+func TestRegisterPage_CachePolicy(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	router := gin.New()
+	registerPage(router, "/", HomePageHandler, seo.Entry{LastMod: seo.FixedLastMod(modified)}, middleware.CachePolicy{MaxAge: time.Minute})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", w.Header().Get("Last-Modified"), "LastModified defaults to the sitemap LastMod")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
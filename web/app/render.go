@@ -0,0 +1,143 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"runtime-dynamics/errs"
+	"runtime-dynamics/metrics"
+	"runtime-dynamics/tracing"
+	"runtime-dynamics/web/middleware"
+	"runtime-dynamics/web/proxy"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Fragments maps HX-Target element ids (without "#") to the component that
+// renders the content of that element
+type Fragments map[string]templ.Component
+
+// IsHTMX reports whether the request was made by htmx
+func IsHTMX(c *gin.Context) bool {
+	return c.GetHeader("HX-Request") == "true"
+}
+
+// IsBoosted reports whether the request comes from an hx-boost link or form,
+// which swaps the whole body and therefore needs the full page
+func IsBoosted(c *gin.Context) bool {
+	return c.GetHeader("HX-Boosted") == "true"
+}
+
+// Render writes the full page for normal and boosted requests, and only the
+// fragment for other htmx requests. A nil fragment always renders the page.
+func Render(c *gin.Context, page templ.Component, fragment templ.Component) {
+	middleware.AddVary(c.Writer.Header(), "HX-Request")
+	if fragment != nil && IsHTMX(c) && !IsBoosted(c) {
+		renderComponent(c, fragment)
+		return
+	}
+	renderComponent(c, page)
+}
+
+// RenderTarget is like Render but picks the fragment matching the HX-Target
+// header. Requests targeting an unknown element receive the full page.
+func RenderTarget(c *gin.Context, page templ.Component, fragments Fragments) {
+	middleware.AddVary(c.Writer.Header(), "HX-Request", "HX-Target")
+	if IsHTMX(c) && !IsBoosted(c) {
+		target := strings.TrimPrefix(c.GetHeader("HX-Target"), "#")
+		if fragment, ok := fragments[target]; ok {
+			renderComponent(c, fragment)
+			return
+		}
+	}
+	renderComponent(c, page)
+}
+
+// renderComponent renders the component into a buffer so a failed render
+// becomes a clean 500, then writes it with any status already set on c
+func renderComponent(c *gin.Context, component templ.Component) {
+	var buf bytes.Buffer
+	ctx, span := tracing.Start(c.Request.Context(), "render "+c.FullPath())
+	start := time.Now()
+	err := component.Render(ctx, &buf)
+	metrics.ObserveRender(c.FullPath(), time.Since(start))
+	tracing.End(span, &err)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to render page: %s", c.Request.URL.Path)
+		c.String(http.StatusInternalServerError, "Error rendering page")
+		return
+	}
+	c.Data(c.Writer.Status(), "text/html; charset=utf-8", buf.Bytes())
+}
+
+// RenderError writes a plain-text error response for err. Typed errors from
+// the errs package choose the status and the text shown; any other error is
+// shown as message with 500 Internal Server Error.
+func RenderError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	text := message
+	if typed, ok := errs.As(err); ok {
+		status = typed.Kind.Status()
+		if typed.Kind != errs.KindInternal && typed.Message != "" {
+			text = typed.Message
+		}
+	}
+	event := log.Warn()
+	if status >= http.StatusInternalServerError {
+		event = log.Error()
+	}
+	event.Err(err).Int("status", status).Msg(message)
+	c.String(status, text)
+}
+
+// HXRedirect makes htmx perform a full page navigation to url
+func HXRedirect(c *gin.Context, url string) {
+	c.Header("HX-Redirect", url)
+}
+
+// HXPushURL pushes url into the browser history after the swap
+func HXPushURL(c *gin.Context, url string) {
+	c.Header("HX-Push-Url", url)
+}
+
+// HXRetarget swaps the response into the element matching selector instead
+// of the request's hx-target
+func HXRetarget(c *gin.Context, selector string) {
+	c.Header("HX-Retarget", selector)
+}
+
+// HXTrigger triggers the named client-side events after the response is received
+func HXTrigger(c *gin.Context, events ...string) {
+	c.Header("HX-Trigger", strings.Join(events, ", "))
+}
+
+// HXTriggerDetail triggers client-side events carrying detail values,
+// e.g. {"showMessage": {"level": "info", "text": "Saved"}}
+func HXTriggerDetail(c *gin.Context, events map[string]interface{}) {
+	value, err := json.Marshal(events)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode HX-Trigger events")
+		return
+	}
+	c.Header("HX-Trigger", string(value))
+}
+
+// Redirect sends the client to path: htmx requests get an HX-Redirect header,
+// others a 303 redirect to the public absolute URL
+func Redirect(c *gin.Context, path string) {
+	if IsHTMX(c) {
+		HXRedirect(c, path)
+		c.Status(http.StatusOK)
+		return
+	}
+	target := path
+	if strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") {
+		target = proxy.AbsoluteURL(c, path)
+	}
+	c.Redirect(http.StatusSeeOther, target)
+}
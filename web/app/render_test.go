@@ -0,0 +1,226 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"runtime-dynamics/errs"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func text(s string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	})
+}
+
+func serve(handler gin.HandlerFunc, headers map[string]string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/", handler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		fragment     templ.Component
+		expectedBody string
+	}{
+		{
+			name:         "normal request gets page",
+			fragment:     text("fragment"),
+			expectedBody: "page",
+		},
+		{
+			name:         "htmx request gets fragment",
+			headers:      map[string]string{"HX-Request": "true"},
+			fragment:     text("fragment"),
+			expectedBody: "fragment",
+		},
+		{
+			name:         "boosted request gets page",
+			headers:      map[string]string{"HX-Request": "true", "HX-Boosted": "true"},
+			fragment:     text("fragment"),
+			expectedBody: "page",
+		},
+		{
+			name:         "nil fragment gets page",
+			headers:      map[string]string{"HX-Request": "true"},
+			expectedBody: "page",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(func(c *gin.Context) {
+				Render(c, text("page"), tt.fragment)
+			}, tt.headers)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, "HX-Request", w.Header().Get("Vary"))
+			assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestRenderTarget(t *testing.T) {
+	fragments := Fragments{
+		"results": text("results"),
+		"summary": text("summary"),
+	}
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		expectedBody string
+	}{
+		{
+			name:         "matching target",
+			headers:      map[string]string{"HX-Request": "true", "HX-Target": "summary"},
+			expectedBody: "summary",
+		},
+		{
+			name:         "target with hash",
+			headers:      map[string]string{"HX-Request": "true", "HX-Target": "#results"},
+			expectedBody: "results",
+		},
+		{
+			name:         "unknown target",
+			headers:      map[string]string{"HX-Request": "true", "HX-Target": "sidebar"},
+			expectedBody: "page",
+		},
+		{
+			name:         "not htmx",
+			headers:      map[string]string{"HX-Target": "results"},
+			expectedBody: "page",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(func(c *gin.Context) {
+				RenderTarget(c, text("page"), fragments)
+			}, tt.headers)
+
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, "HX-Request, HX-Target", w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestRender_KeepsVary(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		// set by earlier middleware
+		c.Header("Vary", "Accept-Encoding, HX-Request")
+		Render(c, text("page"), nil)
+		RenderTarget(c, text("page"), nil)
+	}, nil)
+
+	assert.Equal(t, []string{"Accept-Encoding, HX-Request", "HX-Target"}, w.Header().Values("Vary"))
+}
+
+func TestRender_KeepsStatusAndHandlesErrors(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		c.Status(http.StatusUnprocessableEntity)
+		Render(c, text("page"), nil)
+	}, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "page", w.Body.String())
+
+	failing := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("boom")
+	})
+	w = serve(func(c *gin.Context) {
+		Render(c, failing, nil)
+	}, nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "Error rendering page", w.Body.String())
+}
+
+func TestRenderError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "typed not found",
+			err:            errs.NotFound("item not found"),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "item not found",
+		},
+		{
+			name:           "typed internal keeps the caller message",
+			err:            errs.Internal(errors.New("db down"), "failed to save item"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "failed to load item",
+		},
+		{
+			name:           "untyped error",
+			err:            errors.New("boom"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "failed to load item",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(func(c *gin.Context) {
+				RenderError(c, tt.err, "failed to load item")
+			}, nil)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHXResponseHeaders(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		HXPushURL(c, "/items?page=2")
+		HXRetarget(c, "#errors")
+		HXTrigger(c, "itemSaved", "refreshList")
+		c.Status(http.StatusNoContent)
+	}, nil)
+
+	assert.Equal(t, "/items?page=2", w.Header().Get("HX-Push-Url"))
+	assert.Equal(t, "#errors", w.Header().Get("HX-Retarget"))
+	assert.Equal(t, "itemSaved, refreshList", w.Header().Get("HX-Trigger"))
+
+	w = serve(func(c *gin.Context) {
+		HXTriggerDetail(c, map[string]interface{}{"showMessage": map[string]string{"text": "Saved"}})
+	}, nil)
+	assert.JSONEq(t, `{"showMessage":{"text":"Saved"}}`, w.Header().Get("HX-Trigger"))
+}
+
+func TestRedirect(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		Redirect(c, "/app/dashboard")
+	}, map[string]string{"HX-Request": "true"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/app/dashboard", w.Header().Get("HX-Redirect"))
+
+	w = serve(func(c *gin.Context) {
+		c.Request.Host = "example.com"
+		Redirect(c, "/app/dashboard")
+	}, nil)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "http://example.com/app/dashboard", w.Header().Get("Location"))
+}
@@ -1,7 +1,10 @@
 ﻿package app
 
 import (
+	"time"
+
 	"runtime-dynamics/web/app/seo"
+	"runtime-dynamics/web/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,8 +14,34 @@ func RegisterWebRoutes(r *gin.Engine) {
 	// SEO and crawler files
 	r.GET("/robots.txt", seo.RobotsTxtHandler)
 	r.GET("/sitemap.xml", seo.SitemapXMLHandler)
+	r.GET("/sitemaps/:file", seo.SitemapFileHandler)
+
+	// Authenticated app pages are not for crawlers
+	seo.Disallow("/app/")
+
+	// Generic CRUD pages of registered entities, admins only
+	registerAdminPages(r)
+
+	// Email previews, development only
+	registerDevMailPages(r)
 
 	// Homepage (public)
-	r.GET("/", HomePageHandler)
+	registerPage(r, "/", HomePageHandler, seo.Entry{
+		Priority:   1.0,
+		ChangeFreq: seo.Weekly,
+		LastMod:    seo.DeployLastMod,
+	}, middleware.CachePolicy{ServerTTL: 5 * time.Minute})
+
+}
 
+// registerPage registers a public GET page, declares its sitemap entry and
+// applies its cache policy. The policy's LastModified defaults to the
+// entry's LastMod.
+func registerPage(r *gin.Engine, path string, handler gin.HandlerFunc, entry seo.Entry, policy middleware.CachePolicy) {
+	if policy.LastModified == nil && entry.LastMod != nil {
+		policy.LastModified = entry.LastMod
+	}
+	r.GET(path, middleware.HTTPCache(policy), handler)
+	entry.Path = path
+	seo.Register(entry)
 }
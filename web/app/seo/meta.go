@@ -0,0 +1,105 @@
+package seo
+
+import (
+	"runtime-dynamics/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Meta describes the document metadata a page handler supplies to the layout:
+// title, description, canonical URL, Open Graph / Twitter card tags and
+// JSON-LD structured data.
+type Meta struct {
+	Title       string
+	Description string
+	// Canonical is the absolute URL of the page
+	Canonical string
+	// Robots is the robots meta directive, e.g. "noindex, nofollow"
+	Robots string
+	// Image is the absolute URL of the image shown when the page is shared
+	Image string
+	// Type is the Open Graph type, "website" when empty
+	Type     string
+	SiteName string
+	// TwitterCard is the card type, derived from Image when empty
+	TwitterCard string
+	// TwitterSite is the @handle of the site
+	TwitterSite string
+	// StructuredData holds JSON-LD objects rendered as ld+json scripts
+	StructuredData []interface{}
+}
+
+// NewMeta creates page metadata with the canonical URL of the current
+// request path (query string dropped) and the configured site name.
+func NewMeta(c *gin.Context, title, description string) Meta {
+	m := Meta{
+		Title:       title,
+		Description: description,
+		Canonical:   absoluteURL(baseURL(c), c.Request.URL.Path),
+	}
+	if cfg := config.Get(); cfg != nil {
+		m.SiteName = cfg.SiteName
+	}
+	return m
+}
+
+// DocumentTitle returns the <title> text, suffixed with the site name
+func (m Meta) DocumentTitle() string {
+	if m.SiteName == "" || m.Title == m.SiteName {
+		return m.Title
+	}
+	if m.Title == "" {
+		return m.SiteName
+	}
+	return m.Title + " - " + m.SiteName
+}
+
+// OGType returns the Open Graph type of the page
+func (m Meta) OGType() string {
+	if m.Type == "" {
+		return "website"
+	}
+	return m.Type
+}
+
+// Card returns the Twitter card type of the page
+func (m Meta) Card() string {
+	if m.TwitterCard != "" {
+		return m.TwitterCard
+	}
+	if m.Image != "" {
+		return "summary_large_image"
+	}
+	return "summary"
+}
+
+// WithStructuredData returns a copy of m with the JSON-LD objects appended
+func (m Meta) WithStructuredData(objects ...interface{}) Meta {
+	m.StructuredData = append(append([]interface{}(nil), m.StructuredData...), objects...)
+	return m
+}
+
+// WebSiteSchema returns a schema.org WebSite JSON-LD object
+func WebSiteSchema(name, url string) map[string]interface{} {
+	return map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "WebSite",
+		"name":     name,
+		"url":      url,
+	}
+}
+
+// OrganizationSchema returns a schema.org Organization JSON-LD object.
+// logo may be empty.
+func OrganizationSchema(name, url, logo string) map[string]interface{} {
+	org := map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "Organization",
+		"name":     name,
+		"url":      url,
+	}
+	if logo != "" {
+		org["logo"] = logo
+	}
+	return org
+}
@@ -0,0 +1,82 @@
+package seo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMeta(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/pricing?utm_source=mail", nil)
+	c.Request.Host = "example.com"
+
+	meta := NewMeta(c, "Pricing", "Plans and prices")
+
+	assert.Equal(t, "Pricing", meta.Title)
+	assert.Equal(t, "Plans and prices", meta.Description)
+	assert.Equal(t, "http://example.com/pricing", meta.Canonical)
+}
+
+func TestMeta_Defaults(t *testing.T) {
+	tests := []struct {
+		name          string
+		meta          Meta
+		expectedTitle string
+		expectedType  string
+		expectedCard  string
+	}{
+		{
+			name:          "title with site name",
+			meta:          Meta{Title: "About", SiteName: "Acme"},
+			expectedTitle: "About - Acme",
+			expectedType:  "website",
+			expectedCard:  "summary",
+		},
+		{
+			name:          "title equal to site name",
+			meta:          Meta{Title: "Acme", SiteName: "Acme", Type: "article"},
+			expectedTitle: "Acme",
+			expectedType:  "article",
+			expectedCard:  "summary",
+		},
+		{
+			name:          "image uses large card",
+			meta:          Meta{SiteName: "Acme", Image: "https://example.com/og.png"},
+			expectedTitle: "Acme",
+			expectedType:  "website",
+			expectedCard:  "summary_large_image",
+		},
+		{
+			name:          "explicit card",
+			meta:          Meta{Title: "Docs", Image: "https://example.com/og.png", TwitterCard: "summary"},
+			expectedTitle: "Docs",
+			expectedType:  "website",
+			expectedCard:  "summary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedTitle, tt.meta.DocumentTitle())
+			assert.Equal(t, tt.expectedType, tt.meta.OGType())
+			assert.Equal(t, tt.expectedCard, tt.meta.Card())
+		})
+	}
+}
+
+func TestMeta_WithStructuredData(t *testing.T) {
+	base := Meta{Title: "Home"}
+	meta := base.WithStructuredData(WebSiteSchema("Acme", "https://example.com/"))
+
+	assert.Empty(t, base.StructuredData)
+	assert.Len(t, meta.StructuredData, 1)
+	assert.Equal(t, "WebSite", meta.StructuredData[0].(map[string]interface{})["@type"])
+
+	org := OrganizationSchema("Acme", "https://example.com/", "")
+	assert.NotContains(t, org, "logo")
+}
@@ -2,97 +2,79 @@
 
 import (
 	"net/http"
-	"time"
+	"sort"
+	"strings"
+
+	"runtime-dynamics/config"
+	"runtime-dynamics/web/proxy"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RobotsTxtHandler serves the robots.txt file for web crawlers
 func RobotsTxtHandler(c *gin.Context) {
-	// Get the base URL from the request
-	scheme := "https"
-	if c.Request.TLS == nil {
-		scheme = "http"
-	}
-	baseURL := scheme + "://" + c.Request.Host
+	defaultRegistry.RobotsTxtHandler(c)
+}
 
-	robotsTxt := `User-agent: *
-Allow: /
-Disallow: /app/
-Disallow: /api/
-Disallow: /desktop-login
+// Disallow excludes a path prefix from crawling in the default registry
+func Disallow(prefix string) {
+	defaultRegistry.Disallow(prefix)
+}
 
-Sitemap: ` + baseURL + `/sitemap.xml
-`
-	c.Header("Content-Type", "text/plain")
-	c.String(http.StatusOK, robotsTxt)
+// Disallow excludes a path prefix from crawling, typically called next to the
+// registration of a route group that is not meant for search engines
+func (r *Registry) Disallow(prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disallow[prefix] = struct{}{}
 }
 
-// SitemapXMLHandler serves the sitemap.xml file for search engines
-func SitemapXMLHandler(c *gin.Context) {
-	// Get the base URL from the request
-	scheme := "https"
-	if c.Request.TLS == nil {
-		scheme = "http"
+// disallowed returns the registered and configured disallow rules, sorted
+func (r *Registry) disallowed() []string {
+	r.mu.RLock()
+	rules := make(map[string]struct{}, len(r.disallow))
+	for prefix := range r.disallow {
+		rules[prefix] = struct{}{}
+	}
+	r.mu.RUnlock()
+
+	if cfg := config.Get(); cfg != nil {
+		for _, prefix := range cfg.RobotsDisallow {
+			rules[prefix] = struct{}{}
+		}
 	}
-	baseURL := scheme + "://" + c.Request.Host
+	sorted := make([]string, 0, len(rules))
+	for prefix := range rules {
+		sorted = append(sorted, prefix)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
 
-	// Get the current time for lastmod
-	now := time.Now().Format("2006-01-02")
+// RobotsTxt builds the robots.txt body for the given site base URL.
+// ROBOTS_BLOCK_ALL=true disallows everything, e.g. on staging deployments.
+func (r *Registry) RobotsTxt(baseURL string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if cfg := config.Get(); cfg != nil && cfg.RobotsBlockAll {
+		b.WriteString("Disallow: /\n")
+		return b.String()
+	}
+	b.WriteString("Allow: /\n")
+	for _, prefix := range r.disallowed() {
+		b.WriteString("Disallow: " + prefix + "\n")
+	}
+	b.WriteString("\nSitemap: " + absoluteURL(baseURL, "/sitemap.xml") + "\n")
+	return b.String()
+}
 
-	// Build sitemap XML
-	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
-<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
-  <url>
-    <loc>` + baseURL + `/</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>weekly</changefreq>
-    <priority>1.0</priority>
-  </url>
-  <url>
-    <loc>` + baseURL + `/features</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>weekly</changefreq>
-    <priority>0.8</priority>
-  </url>
-  <url>
-    <loc>` + baseURL + `/about</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>monthly</changefreq>
-    <priority>0.7</priority>
-  </url>
-  <url>
-    <loc>` + baseURL + `/help</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>weekly</changefreq>
-    <priority>0.6</priority>
-  </url>
-  <url>
-    <loc>` + baseURL + `/contact</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>monthly</changefreq>
-    <priority>0.6</priority>
-  </url>
-  <url>
-    <loc>` + baseURL + `/privacy</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>yearly</changefreq>
-    <priority>0.3</priority>
-  </url>
-  <url>
-    <loc>` + baseURL + `/terms</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>yearly</changefreq>
-    <priority>0.3</priority>
-  </url>
-  <url>
-    <loc>` + baseURL + `/cookies</loc>
-    <lastmod>` + now + `</lastmod>
-    <changefreq>yearly</changefreq>
-    <priority>0.3</priority>
-  </url>
-</urlset>`
+// RobotsTxtHandler serves the registry as robots.txt
+func (r *Registry) RobotsTxtHandler(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.String(http.StatusOK, r.RobotsTxt(baseURL(c)))
+}
 
-	c.Header("Content-Type", "application/xml")
-	c.String(http.StatusOK, sitemap)
+// baseURL returns the public base URL of the site for the request
+func baseURL(c *gin.Context) string {
+	return proxy.BaseURL(c)
 }
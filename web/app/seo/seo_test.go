@@ -0,0 +1,75 @@
+package seo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"runtime-dynamics/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobotsTxtHandler(t *testing.T) {
+	os.Unsetenv("ROBOTS_DISALLOW")
+	os.Unsetenv("ROBOTS_BLOCK_ALL")
+	assert.NoError(t, config.LoadConfig())
+
+	reg := NewRegistry()
+	reg.Disallow("/app/")
+	reg.Disallow("/api/")
+
+	router := gin.New()
+	router.GET("/robots.txt", reg.RobotsTxtHandler)
+	req, _ := http.NewRequest("GET", "/robots.txt", nil)
+	req.Host = "example.com"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Equal(t, "User-agent: *\nAllow: /\nDisallow: /api/\nDisallow: /app/\n\nSitemap: http://example.com/sitemap.xml\n", w.Body.String())
+	assert.NotContains(t, w.Body.String(), "/desktop-login")
+}
+
+func TestRegistry_RobotsTxtFromConfig(t *testing.T) {
+	defer func() {
+		os.Unsetenv("ROBOTS_DISALLOW")
+		os.Unsetenv("ROBOTS_BLOCK_ALL")
+		config.LoadConfig()
+	}()
+
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected string
+	}{
+		{
+			name:     "extra disallow rules",
+			envVars:  map[string]string{"ROBOTS_DISALLOW": "/search, /tmp/"},
+			expected: "User-agent: *\nAllow: /\nDisallow: /app/\nDisallow: /search\nDisallow: /tmp/\n\nSitemap: https://example.com/sitemap.xml\n",
+		},
+		{
+			name:     "block all crawlers",
+			envVars:  map[string]string{"ROBOTS_BLOCK_ALL": "true"},
+			expected: "User-agent: *\nDisallow: /\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Unsetenv("ROBOTS_DISALLOW")
+			os.Unsetenv("ROBOTS_BLOCK_ALL")
+			for key, value := range tt.envVars {
+				os.Setenv(key, value)
+			}
+			assert.NoError(t, config.LoadConfig())
+
+			reg := NewRegistry()
+			reg.Disallow("/app/")
+			assert.Equal(t, tt.expected, reg.RobotsTxt("https://example.com"))
+		})
+	}
+}
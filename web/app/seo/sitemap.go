@@ -0,0 +1,370 @@
+package seo
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxURLsPerSitemap is the protocol limit of URLs in a single sitemap file.
+// Sources producing more entries are split across numbered sitemap files.
+const MaxURLsPerSitemap = 50000
+
+// sitemapNamespace is the XML namespace of the sitemaps.org protocol
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// pagesSitemap is the name of the sitemap holding registered page routes
+const pagesSitemap = "pages"
+
+// ChangeFreq is the sitemap hint for how often a page changes
+type ChangeFreq string
+
+const (
+	Always  ChangeFreq = "always"
+	Hourly  ChangeFreq = "hourly"
+	Daily   ChangeFreq = "daily"
+	Weekly  ChangeFreq = "weekly"
+	Monthly ChangeFreq = "monthly"
+	Yearly  ChangeFreq = "yearly"
+	Never   ChangeFreq = "never"
+)
+
+// LastModFunc returns the last modification time of a page.
+// A zero time omits <lastmod> from the sitemap entry.
+type LastModFunc func(ctx context.Context) time.Time
+
+// FixedLastMod returns a LastModFunc that always reports t
+func FixedLastMod(t time.Time) LastModFunc {
+	return func(context.Context) time.Time { return t }
+}
+
+// startedAt is when the process started, used as the deploy time of static pages
+var startedAt = time.Now()
+
+// DeployLastMod reports the process start time. Use it for pages whose
+// content only changes when a new version is deployed.
+func DeployLastMod(context.Context) time.Time {
+	return startedAt
+}
+
+// Entry declares how a page route appears in the sitemap
+type Entry struct {
+	Path       string
+	Priority   float64
+	ChangeFreq ChangeFreq
+	LastMod    LastModFunc
+}
+
+// URL is a single sitemap location. Loc may be a path (prefixed with the
+// site base URL when rendered) or an absolute URL.
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq ChangeFreq
+	Priority   float64
+}
+
+// Source produces dynamic sitemap URLs, typically from a repository. The
+// URLs are read one sitemap file at a time, so a source of millions of
+// entities is never loaded at once.
+type Source struct {
+	// Count returns the number of URLs
+	Count func(ctx context.Context) (int, error)
+	// URLs returns up to limit URLs starting at offset, in a stable order
+	URLs func(ctx context.Context, offset, limit int) ([]URL, error)
+}
+
+type source struct {
+	name string
+	Source
+}
+
+// sourceNamePattern matches the URL safe source names
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// pageSuffixPattern matches the "-<page>" suffix of split sitemap files
+var pageSuffixPattern = regexp.MustCompile(`-[0-9]+$`)
+
+// Registry holds the page entries, dynamic sources and crawler rules that
+// make up the sitemap and robots.txt
+type Registry struct {
+	mu       sync.RWMutex
+	entries  map[string]Entry
+	sources  []source
+	disallow map[string]struct{}
+}
+
+// NewRegistry creates an empty SEO registry
+func NewRegistry() *Registry {
+	return &Registry{
+		entries:  make(map[string]Entry),
+		disallow: make(map[string]struct{}),
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry used by the package-level functions and handlers
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a page entry to the default registry
+func Register(e Entry) {
+	defaultRegistry.Register(e)
+}
+
+// RegisterSource adds a dynamic URL source to the default registry
+func RegisterSource(name string, src Source) {
+	defaultRegistry.RegisterSource(name, src)
+}
+
+// Register adds or replaces the entry for e.Path
+func (r *Registry) Register(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[e.Path] = e
+}
+
+// RegisterSource adds or replaces a named dynamic URL source. The name
+// becomes the sitemap file name: it must be URL safe, must not be "pages"
+// and must not end in "-<n>", which names the files of split sources.
+// RegisterSource panics on an invalid name or a source without funcs.
+func (r *Registry) RegisterSource(name string, src Source) {
+	if !sourceNamePattern.MatchString(name) || name == pagesSitemap || pageSuffixPattern.MatchString(name) {
+		panic(fmt.Sprintf("seo: invalid sitemap source name %q", name))
+	}
+	if src.Count == nil || src.URLs == nil {
+		panic(fmt.Sprintf("seo: sitemap source %q needs Count and URLs", name))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.sources {
+		if r.sources[i].name == name {
+			r.sources[i].Source = src
+			return
+		}
+	}
+	r.sources = append(r.sources, source{name: name, Source: src})
+}
+
+// Entries returns the registered page entries ordered by path
+func (r *Registry) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// pageURLs returns the URLs of the registered pages
+func (r *Registry) pageURLs(ctx context.Context) []URL {
+	entries := r.Entries()
+	urls := make([]URL, 0, len(entries))
+	for _, e := range entries {
+		u := URL{Loc: e.Path, ChangeFreq: e.ChangeFreq, Priority: e.Priority}
+		if e.LastMod != nil {
+			u.LastMod = e.LastMod(ctx)
+		}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// source returns the source registered under name
+func (r *Registry) source(name string) (Source, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.sources {
+		if s.name == name {
+			return s.Source, true
+		}
+	}
+	return Source{}, false
+}
+
+// sitemapNames lists the sitemap files making up the index
+func (r *Registry) sitemapNames(ctx context.Context) ([]string, error) {
+	r.mu.RLock()
+	sources := append([]source(nil), r.sources...)
+	r.mu.RUnlock()
+
+	names := []string{pagesSitemap}
+	for _, s := range sources {
+		count, err := s.Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pages := (count + MaxURLsPerSitemap - 1) / MaxURLsPerSitemap
+		if pages <= 1 {
+			names = append(names, s.name)
+			continue
+		}
+		for p := 1; p <= pages; p++ {
+			names = append(names, fmt.Sprintf("%s-%d", s.name, p))
+		}
+	}
+	return names, nil
+}
+
+// hasSources reports whether any dynamic source is registered
+func (r *Registry) hasSources() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.sources) > 0
+}
+
+type xmlURLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []xmlURL `xml:"url"`
+}
+
+type xmlURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type xmlSitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []xmlSitemap `xml:"sitemap"`
+}
+
+type xmlSitemap struct {
+	Loc string `xml:"loc"`
+}
+
+// absoluteURL prefixes loc with baseURL unless it is already absolute
+func absoluteURL(baseURL, loc string) string {
+	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		return loc
+	}
+	if !strings.HasPrefix(loc, "/") {
+		loc = "/" + loc
+	}
+	return strings.TrimSuffix(baseURL, "/") + loc
+}
+
+func newURLSet(baseURL string, urls []URL) xmlURLSet {
+	set := xmlURLSet{Xmlns: sitemapNamespace, URLs: make([]xmlURL, 0, len(urls))}
+	for _, u := range urls {
+		x := xmlURL{Loc: absoluteURL(baseURL, u.Loc), ChangeFreq: string(u.ChangeFreq)}
+		if !u.LastMod.IsZero() {
+			x.LastMod = u.LastMod.UTC().Format("2006-01-02")
+		}
+		if u.Priority > 0 {
+			x.Priority = strconv.FormatFloat(u.Priority, 'f', 1, 64)
+		}
+		set.URLs = append(set.URLs, x)
+	}
+	return set
+}
+
+func renderXML(c *gin.Context, v interface{}) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode sitemap")
+		c.String(http.StatusInternalServerError, "failed to render sitemap")
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+// SitemapXMLHandler serves the sitemap.xml file for search engines
+func SitemapXMLHandler(c *gin.Context) {
+	defaultRegistry.SitemapXMLHandler(c)
+}
+
+// SitemapFileHandler serves an individual sitemap listed in the sitemap index
+func SitemapFileHandler(c *gin.Context) {
+	defaultRegistry.SitemapFileHandler(c)
+}
+
+// SitemapXMLHandler serves the registry as sitemap.xml.
+// Without dynamic sources it is a plain urlset of the registered pages,
+// otherwise it is a sitemap index pointing at /sitemaps/<name>.xml.
+func (r *Registry) SitemapXMLHandler(c *gin.Context) {
+	base := baseURL(c)
+	if !r.hasSources() {
+		renderXML(c, newURLSet(base, r.pageURLs(c.Request.Context())))
+		return
+	}
+
+	names, err := r.sitemapNames(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build sitemap index")
+		c.String(http.StatusInternalServerError, "failed to render sitemap")
+		return
+	}
+	index := xmlSitemapIndex{Xmlns: sitemapNamespace}
+	for _, name := range names {
+		index.Sitemaps = append(index.Sitemaps, xmlSitemap{Loc: absoluteURL(base, "/sitemaps/"+name+".xml")})
+	}
+	renderXML(c, index)
+}
+
+// SitemapFileHandler serves a single sitemap of the registry. The :file
+// parameter is "<name>.xml", or "<name>-<page>.xml" for sources with more
+// than MaxURLsPerSitemap URLs, which have no unsplit file.
+func (r *Registry) SitemapFileHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := strings.TrimSuffix(c.Param("file"), ".xml")
+	if name == pagesSitemap {
+		renderXML(c, newURLSet(baseURL(c), r.pageURLs(ctx)))
+		return
+	}
+	page := 0
+	suffix := pageSuffixPattern.FindString(name)
+	if suffix != "" {
+		page, _ = strconv.Atoi(suffix[1:])
+		name = strings.TrimSuffix(name, suffix)
+	}
+	src, ok := r.source(name)
+	if !ok || (suffix != "" && page < 1) {
+		c.String(http.StatusNotFound, "sitemap not found")
+		return
+	}
+
+	count, err := src.Count(ctx)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to count sitemap source: %s", name)
+		c.String(http.StatusInternalServerError, "failed to render sitemap")
+		return
+	}
+	// numbered files exist only for split sources, as many as the count
+	// needs, and split sources have no unsplit file
+	offset := 0
+	if page > 0 {
+		offset = (page - 1) * MaxURLsPerSitemap
+	}
+	if (count > MaxURLsPerSitemap) != (page > 0) || (page > 0 && offset >= count) {
+		c.String(http.StatusNotFound, "sitemap not found")
+		return
+	}
+	urls, err := src.URLs(ctx, offset, MaxURLsPerSitemap)
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to load sitemap source: %s", name)
+		c.String(http.StatusInternalServerError, "failed to render sitemap")
+		return
+	}
+	if len(urls) > MaxURLsPerSitemap {
+		urls = urls[:MaxURLsPerSitemap]
+	}
+	renderXML(c, newURLSet(baseURL(c), urls))
+}
@@ -0,0 +1,173 @@
+package seo
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+}
+
+func serveRegistry(reg *Registry, path string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/sitemap.xml", reg.SitemapXMLHandler)
+	router.GET("/sitemaps/:file", reg.SitemapFileHandler)
+
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Host = "example.com"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// sliceSource serves urls, or err from both funcs
+func sliceSource(urls []URL, err error) Source {
+	return Source{
+		Count: func(context.Context) (int, error) { return len(urls), err },
+		URLs: func(ctx context.Context, offset, limit int) ([]URL, error) {
+			return urls[offset:min(offset+limit, len(urls))], err
+		},
+	}
+}
+
+func TestSitemapXMLHandler_URLSet(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(Entry{Path: "/", Priority: 1.0, ChangeFreq: Weekly,
+		LastMod: FixedLastMod(time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC))})
+	reg.Register(Entry{Path: "/pricing", Priority: 0.8, ChangeFreq: Monthly})
+
+	w := serveRegistry(reg, "/sitemap.xml")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+
+	var set xmlURLSet
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &set))
+	assert.Equal(t, []xmlURL{
+		{Loc: "http://example.com/", LastMod: "2025-03-04", ChangeFreq: "weekly", Priority: "1.0"},
+		{Loc: "http://example.com/pricing", ChangeFreq: "monthly", Priority: "0.8"},
+	}, set.URLs)
+}
+
+func TestSitemapXMLHandler_NoHardcodedPages(t *testing.T) {
+	w := serveRegistry(NewRegistry(), "/sitemap.xml")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "/features")
+	assert.NotContains(t, w.Body.String(), "<lastmod>")
+}
+
+func TestRegistry_RegisterReplacesPath(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(Entry{Path: "/", Priority: 0.5})
+	reg.Register(Entry{Path: "/", Priority: 1.0})
+
+	entries := reg.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 1.0, entries[0].Priority)
+}
+
+func TestSitemapXMLHandler_IndexWithSources(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(Entry{Path: "/"})
+	reg.RegisterSource("posts", sliceSource([]URL{{Loc: "/posts/hello"}, {Loc: "https://cdn.example.com/a"}}, nil))
+
+	w := serveRegistry(reg, "/sitemap.xml")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var index xmlSitemapIndex
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &index))
+	assert.Equal(t, []xmlSitemap{
+		{Loc: "http://example.com/sitemaps/pages.xml"},
+		{Loc: "http://example.com/sitemaps/posts.xml"},
+	}, index.Sitemaps)
+
+	w = serveRegistry(reg, "/sitemaps/posts.xml")
+	var set xmlURLSet
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &set))
+	assert.Equal(t, "http://example.com/posts/hello", set.URLs[0].Loc)
+	assert.Equal(t, "https://cdn.example.com/a", set.URLs[1].Loc)
+
+	w = serveRegistry(reg, "/sitemaps/posts-1.xml")
+	assert.Equal(t, http.StatusNotFound, w.Code, "unsplit sources have no numbered files")
+}
+
+func TestSitemapFileHandler_SplitsLargeSources(t *testing.T) {
+	reg := NewRegistry()
+	var loaded [][2]int
+	reg.RegisterSource("items", Source{
+		Count: func(context.Context) (int, error) { return MaxURLsPerSitemap + 1, nil },
+		URLs: func(ctx context.Context, offset, limit int) ([]URL, error) {
+			loaded = append(loaded, [2]int{offset, limit})
+			var urls []URL
+			for i := offset; i < min(offset+limit, MaxURLsPerSitemap+1); i++ {
+				urls = append(urls, URL{Loc: fmt.Sprintf("/items/%d", i)})
+			}
+			return urls, nil
+		},
+	})
+
+	var index xmlSitemapIndex
+	w := serveRegistry(reg, "/sitemap.xml")
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &index))
+	assert.Len(t, index.Sitemaps, 3)
+	assert.Equal(t, "http://example.com/sitemaps/items-2.xml", index.Sitemaps[2].Loc)
+	assert.Empty(t, loaded, "the index only counts")
+
+	var set xmlURLSet
+	w = serveRegistry(reg, "/sitemaps/items-2.xml")
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &set))
+	assert.Len(t, set.URLs, 1)
+	assert.Equal(t, fmt.Sprintf("http://example.com/items/%d", MaxURLsPerSitemap), set.URLs[0].Loc)
+	assert.Equal(t, [][2]int{{MaxURLsPerSitemap, MaxURLsPerSitemap}}, loaded, "files load only their page")
+
+	for _, file := range []string{"items.xml", "items-0.xml", "items-3.xml"} {
+		w = serveRegistry(reg, "/sitemaps/"+file)
+		assert.Equal(t, http.StatusNotFound, w.Code, file)
+	}
+}
+
+func TestRegistry_RegisterSourceRejectsNames(t *testing.T) {
+	for _, name := range []string{"", "pages", "items-2", "posts/all"} {
+		assert.Panics(t, func() {
+			NewRegistry().RegisterSource(name, sliceSource(nil, nil))
+		}, name)
+	}
+	assert.Panics(t, func() {
+		NewRegistry().RegisterSource("posts", Source{})
+	})
+	assert.NotPanics(t, func() {
+		NewRegistry().RegisterSource("blog-posts", sliceSource(nil, nil))
+	})
+}
+
+func TestSitemapFileHandler_Errors(t *testing.T) {
+	reg := NewRegistry()
+	reg.RegisterSource("broken", sliceSource(nil, errors.New("datastore unavailable")))
+	reg.RegisterSource("flaky", Source{
+		Count: func(context.Context) (int, error) { return 1, nil },
+		URLs: func(ctx context.Context, offset, limit int) ([]URL, error) {
+			return nil, errors.New("datastore unavailable")
+		},
+	})
+
+	w := serveRegistry(reg, "/sitemaps/missing.xml")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	for _, file := range []string{"broken.xml", "flaky.xml"} {
+		w = serveRegistry(reg, "/sitemaps/"+file)
+		assert.Equal(t, http.StatusInternalServerError, w.Code, file)
+		assert.NotContains(t, w.Body.String(), "datastore unavailable")
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"runtime-dynamics/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditRequest stores the request metadata recorded with audit events in the
+// request context. Register it after RequestID.
+func AuditRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(audit.WithRequest(c.Request.Context(), audit.Request{
+			ID:        GetRequestID(c),
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+		}))
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"runtime-dynamics/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRequest(t *testing.T) {
+	var got audit.Request
+	router := gin.New()
+	router.Use(RequestID(), AuditRequest())
+	router.POST("/notes/:id", func(c *gin.Context) {
+		got = audit.RequestFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("POST", "/notes/42?draft=1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("User-Agent", "test-agent")
+	req.RemoteAddr = "192.0.2.7:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, audit.Request{
+		ID:        "req-1",
+		IP:        "192.0.2.7",
+		UserAgent: "test-agent",
+		Method:    "POST",
+		Path:      "/notes/42",
+	}, got)
+}
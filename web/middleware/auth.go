@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+
+	"runtime-dynamics/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// userKey is the gin key of the authenticated user
+const userKey = "user"
+
+// Authenticate identifies the user of every request with auth.Authenticate
+// and stores it in the gin and request contexts. Invalid credentials are
+// logged and the request continues anonymously.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.Authenticate(c.Request)
+		if err != nil {
+			log.Warn().Err(err).Str("request_id", GetRequestID(c)).Msg("invalid credentials")
+		}
+		if user != nil {
+			c.Set(userKey, user)
+			c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), user))
+		}
+		c.Next()
+	}
+}
+
+// GetUser returns the user stored by Authenticate, or nil for anonymous requests
+func GetUser(c *gin.Context) *auth.User {
+	user, _ := c.Get(userKey)
+	u, _ := user.(*auth.User)
+	return u
+}
+
+// RequireUser rejects anonymous requests with 401
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUser(c) == nil {
+			c.Abort()
+			c.String(http.StatusUnauthorized, "Sign in to continue")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole rejects anonymous requests with 401 and users without role
+// with 403
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetUser(c)
+		if user == nil {
+			c.Abort()
+			c.String(http.StatusUnauthorized, "Sign in to continue")
+			return
+		}
+		if !user.HasRole(role) {
+			log.Warn().Str("request_id", GetRequestID(c)).Str("user_id", user.ID).Str("role", role).Msg("missing role")
+			c.Abort()
+			c.String(http.StatusForbidden, "You do not have access to this page")
+			return
+		}
+		c.Next()
+	}
+}
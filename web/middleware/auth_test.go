@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"runtime-dynamics/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	defer auth.SetAuthenticator(nil)
+
+	tests := []struct {
+		name          string
+		authenticator auth.Authenticator
+		expectedID    string
+	}{
+		{name: "anonymous without authenticator"},
+		{
+			name: "authenticated user",
+			authenticator: func(r *http.Request) (*auth.User, error) {
+				return &auth.User{ID: "u1"}, nil
+			},
+			expectedID: "u1",
+		},
+		{
+			name: "invalid credentials continue anonymously",
+			authenticator: func(r *http.Request) (*auth.User, error) {
+				return nil, errors.New("bad token")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth.SetAuthenticator(tt.authenticator)
+			var fromGin, fromContext string
+			router := gin.New()
+			router.Use(Authenticate())
+			router.GET("/", func(c *gin.Context) {
+				if user := GetUser(c); user != nil {
+					fromGin = user.ID
+				}
+				if user := auth.UserFrom(c.Request.Context()); user != nil {
+					fromContext = user.ID
+				}
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedID, fromGin)
+			assert.Equal(t, tt.expectedID, fromContext)
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		user           *auth.User
+		expectedStatus int
+	}{
+		{name: "anonymous", expectedStatus: http.StatusUnauthorized},
+		{name: "missing role", user: &auth.User{ID: "u1"}, expectedStatus: http.StatusForbidden},
+		{name: "has role", user: &auth.User{ID: "u2", Roles: []string{auth.RoleAdmin}}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set(userKey, tt.user)
+				}
+			})
+			router.GET("/admin", RequireRole(auth.RoleAdmin), func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		user           *auth.User
+		expectedStatus int
+	}{
+		{name: "anonymous", expectedStatus: http.StatusUnauthorized},
+		{name: "signed in", user: &auth.User{ID: "u1"}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set(userKey, tt.user)
+				}
+			})
+			router.POST("/items", RequireUser(), func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/items", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"runtime-dynamics/flags"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireFlag answers 404, as for an unknown route, unless feature flag key
+// is on for the user. Register it after Authenticate.
+func RequireFlag(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !flags.On(c.Request.Context(), key) {
+			c.Abort()
+			c.String(http.StatusNotFound, "404 page not found")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/data"
+	"runtime-dynamics/flags"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type flagStore []*data.Flag
+
+func (s flagStore) All(ctx context.Context) ([]*data.Flag, error) {
+	return s, nil
+}
+
+func TestRequireFlag(t *testing.T) {
+	set := flags.NewSet(flagStore{{Key: "new-dashboard", Enabled: true, Rules: `[{"roles": ["beta"], "variant": "on"}]`}}, 0)
+	assert.NoError(t, set.Refresh(context.Background()))
+	previous := flags.Default()
+	flags.SetDefault(set)
+	defer flags.SetDefault(previous)
+
+	tests := []struct {
+		name   string
+		user   *auth.User
+		status int
+	}{
+		{name: "anonymous", user: nil, status: http.StatusNotFound},
+		{name: "flag off", user: &auth.User{ID: "u1"}, status: http.StatusNotFound},
+		{name: "flag on", user: &auth.User{ID: "u2", Roles: []string{"beta"}}, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), tt.user))
+			})
+			router.GET("/dashboard", RequireFlag("new-dashboard"), func(c *gin.Context) {
+				c.String(http.StatusOK, "new dashboard")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/dashboard", nil))
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/cache"
+	"runtime-dynamics/web/proxy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// CachePolicy is how browsers, shared caches and the server cache the
+// responses of a GET route
+type CachePolicy struct {
+	// MaxAge lets clients reuse a response without asking again; zero makes
+	// them revalidate every time, which the ETag answers with a 304
+	MaxAge time.Duration
+	// Private keeps responses out of shared caches such as CDNs. Responses
+	// to signed-in users are always private.
+	Private bool
+	// ServerTTL keeps full responses for anonymous users in the server-side
+	// cache for this long; zero renders every request
+	ServerTTL time.Duration
+	// Tags returns the cache tags of the response, e.g.
+	// cache.EntityTag("BlogPost", c.Param("id")), so writes to that data
+	// drop the stored response. PageTag(route) is always added.
+	Tags func(c *gin.Context) []string
+	// LastModified returns when the content last changed, for
+	// Last-Modified and If-Modified-Since; nil sends no Last-Modified
+	LastModified func(ctx context.Context) time.Time
+}
+
+// PageTag is the cache tag of every stored response of route, the route
+// template such as /posts/:id
+func PageTag(route string) string {
+	return "page:" + route
+}
+
+// response is a captured response, as stored in the server-side cache
+type response struct {
+	Status int
+	// Header holds the headers set by the handler
+	Header http.Header
+	Body   []byte
+	ETag   string
+}
+
+// shareable reports whether r may be served to other users: successful,
+// without cookies and not marked private by the handler
+func (r *response) shareable() bool {
+	control := r.Header.Get("Cache-Control")
+	return r.Status == http.StatusOK && len(r.Header.Values("Set-Cookie")) == 0 &&
+		!strings.Contains(control, "private") && !strings.Contains(control, "no-store")
+}
+
+// errNotShareable keeps a response out of the server-side cache
+var errNotShareable = errors.New("response is not shareable")
+
+// HTTPCache applies policy to a GET route: it buffers the response, tags it
+// with an ETag of its body, answers If-None-Match and If-Modified-Since with
+// 304 Not Modified and sets Cache-Control unless the handler did. With
+// ServerTTL, anonymous requests are served from the server-side cache.
+// Register it on routes whose responses fit in memory, not on streams, and
+// after Authenticate.
+func HTTPCache(policy CachePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		anonymous := auth.UserFrom(c.Request.Context()) == nil
+		if policy.ServerTTL <= 0 || !anonymous {
+			serveResponse(c, policy, capture(c), anonymous)
+			return
+		}
+
+		tags := []string{PageTag(c.FullPath())}
+		if policy.Tags != nil {
+			tags = append(tags, policy.Tags(c)...)
+		}
+		var own *response
+		stored, err := cache.GetOrLoad(c.Request.Context(), cache.Default(), responseKey(c), policy.ServerTTL,
+			func(ctx context.Context) (*response, error) {
+				own = capture(c)
+				if !own.shareable() {
+					return nil, errNotShareable
+				}
+				return own, nil
+			}, tags...)
+		switch {
+		case err == nil:
+			if own == nil {
+				// served from the cache, skip the handler
+				c.Abort()
+			}
+			serveResponse(c, policy, stored, anonymous)
+		case own != nil:
+			// this request ran the handler
+			if !errors.Is(err, errNotShareable) {
+				log.Warn().Ctx(c.Request.Context()).Err(err).Msg("failed to cache response")
+			}
+			serveResponse(c, policy, own, anonymous)
+		default:
+			// the request that ran the handler got a response for itself only
+			serveResponse(c, policy, capture(c), anonymous)
+		}
+	}
+}
+
+// capture runs the rest of the chain with a buffering writer and returns
+// the response it wrote
+func capture(c *gin.Context) *response {
+	header := c.Writer.Header()
+	before := header.Clone()
+	w := &bufferWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = w
+	defer func() { c.Writer = w.ResponseWriter }()
+	c.Next()
+
+	r := &response{Status: w.status, Header: http.Header{}, Body: w.body.Bytes()}
+	for key, values := range header {
+		if !slices.Equal(before[key], values) {
+			r.Header[key] = values
+		}
+	}
+	if r.Status == http.StatusOK {
+		sum := sha256.Sum256(r.Body)
+		r.ETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	return r
+}
+
+// serveResponse writes r, or 304 Not Modified when the client has it
+func serveResponse(c *gin.Context, policy CachePolicy, r *response, anonymous bool) {
+	header := c.Writer.Header()
+	for key, values := range r.Header {
+		if key == "Vary" {
+			AddVary(header, values...)
+			continue
+		}
+		header[key] = values
+	}
+	if r.Status != http.StatusOK {
+		writeResponse(c, r.Status, r.Body)
+		return
+	}
+
+	if header.Get("Cache-Control") == "" {
+		control := policy.cacheControl(anonymous)
+		header.Set("Cache-Control", control)
+		if strings.HasPrefix(control, "public") {
+			// shared caches must not serve the anonymous page to signed-in users
+			AddVary(header, "Cookie", "Authorization")
+		}
+	}
+	header.Set("ETag", r.ETag)
+	var modified time.Time
+	if policy.LastModified != nil {
+		modified = policy.LastModified(c.Request.Context()).UTC().Truncate(time.Second)
+		if !modified.IsZero() {
+			header.Set("Last-Modified", modified.Format(http.TimeFormat))
+		}
+	}
+	if notModified(c.Request, r.ETag, modified) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		writeResponse(c, http.StatusNotModified, nil)
+		return
+	}
+	writeResponse(c, http.StatusOK, r.Body)
+}
+
+func writeResponse(c *gin.Context, status int, body []byte) {
+	c.Status(status)
+	if len(body) == 0 {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	if _, err := c.Writer.Write(body); err != nil {
+		log.Debug().Err(err).Msg("failed to write response")
+	}
+}
+
+func (p CachePolicy) cacheControl(anonymous bool) string {
+	scope := "public"
+	if p.Private || !anonymous {
+		scope = "private"
+	}
+	if p.MaxAge <= 0 {
+		return scope + ", no-cache"
+	}
+	return fmt.Sprintf("%s, max-age=%d", scope, int(p.MaxAge.Seconds()))
+}
+
+// notModified evaluates the conditional headers of req. If-None-Match takes
+// precedence over If-Modified-Since, as in RFC 9110.
+func notModified(req *http.Request, etag string, modified time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if since := req.Header.Get("If-Modified-Since"); since != "" && !modified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !modified.After(t)
+	}
+	return false
+}
+
+// AddVary adds values to the Vary header of h, skipping those already
+// listed, so the values set by earlier middleware are kept. Values may be
+// comma-separated lists. Use it instead of c.Header("Vary", …), which
+// replaces the header.
+func AddVary(h http.Header, values ...string) {
+	var present, added []string
+	for _, value := range varyValues(h.Values("Vary")) {
+		present = append(present, strings.ToLower(value))
+	}
+	for _, value := range varyValues(values) {
+		if !slices.Contains(present, strings.ToLower(value)) {
+			added = append(added, value)
+			present = append(present, strings.ToLower(value))
+		}
+	}
+	if len(added) > 0 {
+		h.Add("Vary", strings.Join(added, ", "))
+	}
+}
+
+// varyValues splits Vary lines into header names
+func varyValues(lines []string) []string {
+	var values []string
+	for _, line := range lines {
+		for _, value := range strings.Split(line, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// responseKey identifies a stored response by public base URL, path, query
+// and the htmx headers app.Render varies on. The base URL is the one pages
+// embed in canonical and social links, so a request with forged forwarding
+// headers never shares an entry with clean requests.
+func responseKey(c *gin.Context) string {
+	h := sha256.New()
+	for _, part := range []string{
+		proxy.BaseURL(c), c.Request.URL.RequestURI(),
+		c.GetHeader("HX-Request"), c.GetHeader("HX-Boosted"), c.GetHeader("HX-Target"),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return "page:" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// bufferWriter keeps the status and body written by handlers
+type bufferWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op: the response is written once the handler returns
+func (w *bufferWriter) Flush() {}
@@ -0,0 +1,258 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/cache"
+	"runtime-dynamics/config"
+	"runtime-dynamics/web/proxy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// cachedRouter serves /page with policy and counts the handler runs
+func cachedRouter(policy CachePolicy, user *auth.User, runs *int) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), user))
+	})
+	router.GET("/page", HTTPCache(policy), func(c *gin.Context) {
+		*runs++
+		c.Header("Vary", "HX-Request")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("<h1>Hello</h1>"))
+	})
+	router.GET("/missing", HTTPCache(policy), func(c *gin.Context) {
+		*runs++
+		c.String(http.StatusNotFound, "not found")
+	})
+	return router
+}
+
+func get(router *gin.Engine, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func useCache(t *testing.T) *cache.Cache {
+	c := cache.New(cache.NewMemory(0), 0)
+	previous := cache.Default()
+	cache.SetDefault(c)
+	t.Cleanup(func() { cache.SetDefault(previous) })
+	return c
+}
+
+func TestHTTPCache_ConditionalGet(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	policy := CachePolicy{LastModified: func(context.Context) time.Time { return modified }}
+	var runs int
+	router := cachedRouter(policy, nil, &runs)
+
+	first := get(router, "/page", nil)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "<h1>Hello</h1>", first.Body.String())
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "public, no-cache", first.Header().Get("Cache-Control"))
+	assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", first.Header().Get("Last-Modified"))
+	assert.Equal(t, "HX-Request", first.Header().Get("Vary"))
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{name: "matching etag", header: map[string]string{"If-None-Match": etag}, status: http.StatusNotModified},
+		{name: "weak etag in list", header: map[string]string{"If-None-Match": `"other", W/` + etag}, status: http.StatusNotModified},
+		{name: "other etag", header: map[string]string{"If-None-Match": `"other"`}, status: http.StatusOK},
+		{name: "etag wins over date", header: map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"}, status: http.StatusOK},
+		{name: "not modified since", header: map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"}, status: http.StatusNotModified},
+		{name: "modified since", header: map[string]string{"If-Modified-Since": "Sat, 28 Feb 2026 12:00:00 GMT"}, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(router, "/page", tt.header)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			if tt.status == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHTTPCache_CacheControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CachePolicy
+		user   *auth.User
+		want   string
+	}{
+		{name: "revalidate", policy: CachePolicy{}, want: "public, no-cache"},
+		{name: "max age", policy: CachePolicy{MaxAge: time.Minute}, want: "public, max-age=60"},
+		{name: "private route", policy: CachePolicy{MaxAge: time.Minute, Private: true}, want: "private, max-age=60"},
+		{name: "signed in", policy: CachePolicy{MaxAge: time.Minute}, user: &auth.User{ID: "u1"}, want: "private, max-age=60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs int
+			w := get(cachedRouter(tt.policy, tt.user, &runs), "/page", nil)
+			assert.Equal(t, tt.want, w.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestHTTPCache_ServerCache(t *testing.T) {
+	c := useCache(t)
+	ctx := context.Background()
+	policy := CachePolicy{
+		ServerTTL: time.Minute,
+		Tags:      func(*gin.Context) []string { return []string{cache.KindTag("Post")} },
+	}
+	var runs int
+	router := cachedRouter(policy, nil, &runs)
+
+	first := get(router, "/page", nil)
+	second := get(router, "/page", nil)
+	assert.Equal(t, 1, runs, "anonymous requests share the stored response")
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+	assert.Equal(t, "text/html; charset=utf-8", second.Header().Get("Content-Type"))
+	assert.Equal(t, "HX-Request", second.Header().Get("Vary"))
+
+	get(router, "/page", map[string]string{"HX-Request": "true"})
+	assert.Equal(t, 2, runs, "htmx requests are stored separately")
+
+	assert.NoError(t, c.Invalidate(ctx, cache.KindTag("Post")))
+	get(router, "/page", nil)
+	assert.Equal(t, 3, runs, "invalidating a tag drops the response")
+
+	assert.NoError(t, c.Invalidate(ctx, PageTag("/page")))
+	get(router, "/page", nil)
+	assert.Equal(t, 4, runs, "invalidating the page tag drops the response")
+
+	get(router, "/missing", nil)
+	w := get(router, "/missing", nil)
+	assert.Equal(t, 6, runs, "errors are not stored")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestHTTPCache_ServerCacheSkipsSignedInUsers(t *testing.T) {
+	useCache(t)
+	var runs int
+	router := cachedRouter(CachePolicy{ServerTTL: time.Minute}, &auth.User{ID: "u1"}, &runs)
+
+	get(router, "/page", nil)
+	get(router, "/page", nil)
+	assert.Equal(t, 2, runs)
+}
+
+func TestHTTPCache_SkipsCookies(t *testing.T) {
+	useCache(t)
+	runs := 0
+	router := gin.New()
+	router.GET("/page", HTTPCache(CachePolicy{ServerTTL: time.Minute}), func(c *gin.Context) {
+		runs++
+		c.SetCookie("seen", "1", 60, "/", "", false, true)
+		c.String(http.StatusOK, "hello")
+	})
+
+	get(router, "/page", nil)
+	w := get(router, "/page", nil)
+	assert.Equal(t, 2, runs, "responses setting cookies are not shared")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "seen=1")
+}
+
+func TestHTTPCache_ForwardedHostDoesNotPoison(t *testing.T) {
+	os.Setenv("TRUSTED_PROXIES", "*")
+	defer func() {
+		os.Unsetenv("TRUSTED_PROXIES")
+		config.LoadConfig()
+	}()
+
+	tests := []struct {
+		name      string
+		canonical string
+		want      string
+	}{
+		{name: "canonical URL", canonical: "https://example.com", want: "https://example.com/"},
+		{name: "request host", want: "http://example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("CANONICAL_URL", tt.canonical)
+			defer os.Unsetenv("CANONICAL_URL")
+			assert.NoError(t, config.LoadConfig())
+			useCache(t)
+
+			runs := 0
+			router := gin.New()
+			router.GET("/", HTTPCache(CachePolicy{ServerTTL: time.Minute}), func(c *gin.Context) {
+				runs++
+				c.String(http.StatusOK, `<link rel="canonical" href="%s">`, proxy.AbsoluteURL(c, "/"))
+			})
+
+			get(router, "/", map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.test"})
+			w := get(router, "/", nil)
+			assert.NotContains(t, w.Body.String(), "evil.test")
+			assert.Contains(t, w.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestHTTPCache_Vary(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CachePolicy
+		user   *auth.User
+		want   []string
+	}{
+		{name: "public", policy: CachePolicy{MaxAge: time.Minute}, want: []string{"Accept-Encoding", "HX-Request", "Cookie", "Authorization"}},
+		{name: "private", policy: CachePolicy{MaxAge: time.Minute, Private: true}, want: []string{"Accept-Encoding", "HX-Request"}},
+		{name: "signed in", policy: CachePolicy{MaxAge: time.Minute}, user: &auth.User{ID: "u1"}, want: []string{"Accept-Encoding", "HX-Request"}},
+		{name: "server cache", policy: CachePolicy{ServerTTL: time.Minute}, want: []string{"Accept-Encoding", "HX-Request", "Cookie", "Authorization"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCache(t)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				// set by a compression middleware, say
+				c.Header("Vary", "Accept-Encoding")
+				c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), tt.user))
+			})
+			router.GET("/page", HTTPCache(tt.policy), func(c *gin.Context) {
+				AddVary(c.Writer.Header(), "HX-Request")
+				c.String(http.StatusOK, "hello")
+			})
+
+			for i := 0; i < 2; i++ {
+				w := get(router, "/page", nil)
+				assert.Equal(t, tt.want, varyValues(w.Header().Values("Vary")))
+			}
+		})
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	h := http.Header{}
+	h.Set("Vary", "Accept-Encoding, hx-request")
+	AddVary(h, "HX-Request", "HX-Target, Accept-Encoding", "Cookie")
+	assert.Equal(t, []string{"Accept-Encoding", "hx-request", "HX-Target", "Cookie"}, varyValues(h.Values("Vary")))
+}
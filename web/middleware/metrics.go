@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"time"
+
+	"runtime-dynamics/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request counts, latencies and in-flight requests, labeled
+// by the matched route template rather than the raw path
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		done := metrics.RequestStarted()
+		defer done()
+		c.Next()
+		metrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
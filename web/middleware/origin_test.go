@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		status int
+	}{
+		{name: "get", method: http.MethodGet, status: http.StatusOK},
+		{name: "same-origin fetch", method: http.MethodPost, header: map[string]string{"Sec-Fetch-Site": "same-origin"}, status: http.StatusOK},
+		{name: "cross-site fetch", method: http.MethodPost, header: map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "http://example.com"}, status: http.StatusForbidden},
+		{name: "same-site fetch", method: http.MethodDelete, header: map[string]string{"Sec-Fetch-Site": "same-site"}, status: http.StatusForbidden},
+		{name: "same origin", method: http.MethodPost, header: map[string]string{"Origin": "http://example.com"}, status: http.StatusOK},
+		{name: "other origin", method: http.MethodPut, header: map[string]string{"Origin": "https://evil.test"}, status: http.StatusForbidden},
+		{name: "null origin", method: http.MethodPost, header: map[string]string{"Origin": "null"}, status: http.StatusForbidden},
+		{name: "same referer", method: http.MethodPost, header: map[string]string{"Referer": "http://example.com/app/admin"}, status: http.StatusOK},
+		{name: "other referer", method: http.MethodPost, header: map[string]string{"Referer": "https://evil.test/"}, status: http.StatusForbidden},
+		{name: "no origin", method: http.MethodPost, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(SameOrigin())
+			router.Any("/", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestCrossSite(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   map[string]string
+		expected bool
+	}{
+		{name: "no browser headers"},
+		{name: "same-origin fetch", header: map[string]string{"Sec-Fetch-Site": "same-origin"}},
+		{name: "cross-site fetch", header: map[string]string{"Sec-Fetch-Site": "cross-site"}, expected: true},
+		{name: "same-site fetch", header: map[string]string{"Sec-Fetch-Site": "same-site"}, expected: true},
+		{name: "same origin", header: map[string]string{"Origin": "http://example.com"}},
+		{name: "other origin", header: map[string]string{"Origin": "https://evil.test"}, expected: true},
+		{name: "null origin", header: map[string]string{"Origin": "null"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			assert.Equal(t, tt.expected, CrossSite(req))
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		incoming   string
+		expectSame bool
+	}{
+		{name: "generates id", incoming: "", expectSame: false},
+		{name: "reuses valid id", incoming: "abc-123_DEF.4", expectSame: true},
+		{name: "rejects unsafe id", incoming: "abc\ninjected", expectSame: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromGin, fromContext string
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/", func(c *gin.Context) {
+				fromGin = GetRequestID(c)
+				fromContext = RequestIDFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.NotEmpty(t, fromGin)
+			assert.Equal(t, fromGin, fromContext)
+			assert.Equal(t, fromGin, w.Header().Get(RequestIDHeader))
+			if tt.expectSame {
+				assert.Equal(t, tt.incoming, fromGin)
+			} else {
+				assert.NotEqual(t, tt.incoming, fromGin)
+				assert.Len(t, fromGin, 32)
+			}
+		})
+	}
+}
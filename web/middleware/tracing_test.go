@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"runtime-dynamics/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(previous)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		name        string
+		traceparent string
+		status      int
+		wantTraceID string
+		wantStatus  codes.Code
+	}{
+		{name: "new trace", status: http.StatusOK, wantStatus: codes.Unset},
+		{name: "continues traceparent", traceparent: "00-" + traceID + "-00f067aa0ba902b7-01", status: http.StatusOK, wantTraceID: traceID, wantStatus: codes.Unset},
+		{name: "server error", status: http.StatusInternalServerError, wantStatus: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			var inHandler string
+			router := gin.New()
+			router.Use(Tracing())
+			router.GET("/notes/:id", func(c *gin.Context) {
+				inHandler = tracing.TraceID(c.Request.Context())
+				c.Status(tt.status)
+			})
+
+			req := httptest.NewRequest("GET", "/notes/42", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			if !assert.Len(t, spans, 1) {
+				return
+			}
+			span := spans[0]
+			assert.Equal(t, "GET /notes/:id", span.Name)
+			assert.Equal(t, span.SpanContext.TraceID().String(), inHandler)
+			if tt.wantTraceID != "" {
+				assert.Equal(t, tt.wantTraceID, inHandler)
+				assert.True(t, span.Parent.IsRemote())
+			}
+			assert.Equal(t, tt.wantStatus, span.Status.Code)
+			assert.Contains(t, span.Attributes, attribute.Int("http.response.status_code", tt.status))
+		})
+	}
+}
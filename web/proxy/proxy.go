@@ -0,0 +1,318 @@
+// Package proxy resolves the public scheme and host of a request when the
+// application runs behind TLS-terminating proxies such as Cloud Run.
+//
+// Forwarding headers (Forwarded, X-Forwarded-Proto, X-Forwarded-Host) are
+// client-controlled, so they are only honored when the connection comes from
+// a trusted proxy listed in TRUSTED_PROXIES, only in the family named by
+// FORWARDED_HEADER, and with CANONICAL_URL set only for the scheme and for
+// hosts listed in ALLOWED_HOSTS.
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"runtime-dynamics/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Header families a proxy may use to forward the original scheme and host
+const (
+	// HeaderXForwarded is X-Forwarded-Proto and X-Forwarded-Host, sent by
+	// Cloud Run, most load balancers and nginx setups
+	HeaderXForwarded = "x-forwarded"
+	// HeaderForwarded is the RFC 7239 Forwarded header
+	HeaderForwarded = "forwarded"
+)
+
+// Options configures a Resolver
+type Options struct {
+	// TrustedProxies are the IPs or CIDR ranges of the proxies. "*" trusts
+	// the nearest hop whatever its address, for platforms where the app is
+	// only reachable through the proxy.
+	TrustedProxies []string
+	// CanonicalURL is the public base URL. When set, forwarded headers only
+	// choose the scheme, and the host unless it is in AllowedHosts.
+	CanonicalURL string
+	// Header is the header family the proxies send, HeaderXForwarded when
+	// empty; the other family is ignored, as clients can send it unchanged
+	Header string
+	// AllowedHosts are the forwarded hosts accepted. Empty accepts any host
+	// when CanonicalURL is empty and none otherwise.
+	AllowedHosts []string
+}
+
+// Resolver computes base URLs from requests using a set of trusted proxies
+type Resolver struct {
+	trusted   []*net.IPNet
+	trustAll  bool
+	canonical string
+	header    string
+	allowed   []string
+}
+
+// NewResolver creates a resolver from opts
+func NewResolver(opts Options) (*Resolver, error) {
+	r := &Resolver{
+		canonical: strings.TrimSuffix(opts.CanonicalURL, "/"),
+		header:    opts.Header,
+		trustAll:  slices.Contains(opts.TrustedProxies, "*"),
+	}
+	if r.header == "" {
+		r.header = HeaderXForwarded
+	}
+	if r.header != HeaderXForwarded && r.header != HeaderForwarded {
+		return nil, fmt.Errorf("unknown forwarded header %q", opts.Header)
+	}
+	for _, host := range opts.AllowedHosts {
+		r.allowed = append(r.allowed, strings.ToLower(host))
+	}
+	for _, p := range expandTrusted(opts.TrustedProxies) {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, err
+		}
+		r.trusted = append(r.trusted, ipNet)
+	}
+	return r, nil
+}
+
+// GinTrustedProxies converts the TRUSTED_PROXIES values to the form accepted
+// by gin.Engine.SetTrustedProxies, so ClientIP uses the same trust list.
+func GinTrustedProxies(trustedProxies []string) []string {
+	return expandTrusted(trustedProxies)
+}
+
+func expandTrusted(trustedProxies []string) []string {
+	var expanded []string
+	for _, p := range trustedProxies {
+		if p == "*" {
+			expanded = append(expanded, "0.0.0.0/0", "::/0")
+			continue
+		}
+		expanded = append(expanded, p)
+	}
+	return expanded
+}
+
+// isTrusted reports whether addr (an IP, optionally with port) is a trusted proxy
+func (r *Resolver) isTrusted(addr string) bool {
+	addr = strings.Trim(strings.TrimSpace(addr), `"`)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip := net.ParseIP(strings.Trim(addr, "[]"))
+	if ip == nil {
+		return false
+	}
+	for _, n := range r.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// BaseURL returns the public scheme://host of the request without a trailing slash
+func (r *Resolver) BaseURL(req *http.Request) string {
+	if r.isTrusted(req.RemoteAddr) {
+		var scheme, host string
+		var ok bool
+		if r.header == HeaderForwarded {
+			scheme, host, ok = r.forwarded(req)
+		} else {
+			scheme, host, ok = r.xForwarded(req)
+		}
+		if ok {
+			return scheme + "://" + r.publicHost(req, host)
+		}
+	}
+	if r.canonical != "" {
+		return r.canonical
+	}
+	scheme := "https"
+	if req.TLS == nil {
+		scheme = "http"
+	}
+	return scheme + "://" + req.Host
+}
+
+// publicHost returns the forwarded host when it is allowed, otherwise the
+// host of CANONICAL_URL, or the request Host without one
+func (r *Resolver) publicHost(req *http.Request, forwarded string) string {
+	if forwarded != "" {
+		if slices.Contains(r.allowed, strings.ToLower(forwarded)) || (r.canonical == "" && len(r.allowed) == 0) {
+			return forwarded
+		}
+	}
+	if _, host, ok := strings.Cut(r.canonical, "://"); ok {
+		return host
+	}
+	return req.Host
+}
+
+// forwarded walks the RFC 7239 Forwarded header from the nearest hop outwards
+// while hops are trusted, returning the proto and host seen by the outermost
+// trusted proxy. Trusting "*" only reads the nearest hop, as the addresses
+// of the other hops are as client-controlled as their values.
+func (r *Resolver) forwarded(req *http.Request) (string, string, bool) {
+	header := strings.Join(req.Header.Values("Forwarded"), ",")
+	if header == "" {
+		return "", "", false
+	}
+	elements := strings.Split(header, ",")
+	scheme, host := "", ""
+	for i := len(elements) - 1; i >= 0; i-- {
+		params := parseForwardedElement(elements[i])
+		if p := params["proto"]; p != "" {
+			scheme = p
+		}
+		if h := params["host"]; h != "" {
+			host = h
+		}
+		if r.trustAll || !r.isTrusted(params["for"]) {
+			break
+		}
+	}
+	return resolved(scheme, host)
+}
+
+// xForwarded resolves X-Forwarded-Proto / X-Forwarded-Host, picking the value
+// appended by the outermost trusted proxy according to X-Forwarded-For.
+// Trusting "*" only reads the value of the nearest hop.
+func (r *Resolver) xForwarded(req *http.Request) (string, string, bool) {
+	hops := 1
+	forwardedFor := splitHeader(req.Header.Get("X-Forwarded-For"))
+	for i := len(forwardedFor) - 1; i >= 0 && !r.trustAll && r.isTrusted(forwardedFor[i]); i-- {
+		hops++
+	}
+	scheme := pickHop(splitHeader(req.Header.Get("X-Forwarded-Proto")), hops)
+	host := pickHop(splitHeader(req.Header.Get("X-Forwarded-Host")), hops)
+	return resolved(scheme, host)
+}
+
+// resolved validates forwarded values; the host may be empty
+func resolved(scheme, host string) (string, string, bool) {
+	scheme = strings.ToLower(scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", "", false
+	}
+	if host != "" && !validHost(host) {
+		return "", "", false
+	}
+	return scheme, host, true
+}
+
+func parseForwardedElement(element string) map[string]string {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(key)] = strings.Trim(value, `"`)
+	}
+	return params
+}
+
+func splitHeader(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// pickHop returns the value appended by the hops-th proxy counted from the
+// nearest one. A shorter list means that proxy appended nothing, and the
+// first value may be the client's, so none is returned.
+func pickHop(values []string, hops int) string {
+	i := len(values) - hops
+	if i < 0 {
+		return ""
+	}
+	return values[i]
+}
+
+// validHost rejects host values that could inject paths or schemes into URLs
+func validHost(host string) bool {
+	if host == "" || strings.ContainsAny(host, "/\\@?# \t") {
+		return false
+	}
+	return true
+}
+
+var (
+	resolverLock   sync.Mutex
+	resolver       *Resolver
+	resolverConfig *config.AppConfig
+)
+
+// fromConfig returns the resolver for the current configuration, rebuilding
+// it when the configuration is reloaded
+func fromConfig() *Resolver {
+	cfg := config.Get()
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+	if resolver != nil && resolverConfig == cfg {
+		return resolver
+	}
+	var opts Options
+	if cfg != nil {
+		opts = Options{
+			TrustedProxies: cfg.TrustedProxies,
+			CanonicalURL:   cfg.CanonicalURL,
+			Header:         cfg.ForwardedHeader,
+			AllowedHosts:   cfg.AllowedHosts,
+		}
+	}
+	r, err := NewResolver(opts)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid TRUSTED_PROXIES, forwarding headers will be ignored")
+		r, _ = NewResolver(Options{CanonicalURL: opts.CanonicalURL})
+	}
+	resolver, resolverConfig = r, cfg
+	return resolver
+}
+
+// BaseURL returns the public scheme://host of the request, honoring
+// forwarding headers from trusted proxies and falling back to CANONICAL_URL
+func BaseURL(c *gin.Context) string {
+	return fromConfig().BaseURL(c.Request)
+}
+
+// AbsoluteURL returns the public absolute URL of path for the request
+func AbsoluteURL(c *gin.Context, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return BaseURL(c) + path
+}
+
+// CanonicalURL returns the configured canonical base URL for links built
+// outside a request, such as in emails sent by background jobs
+func CanonicalURL() string {
+	cfg := config.Get()
+	if cfg == nil {
+		return ""
+	}
+	if cfg.CanonicalURL != "" {
+		return strings.TrimSuffix(cfg.CanonicalURL, "/")
+	}
+	return strings.TrimSuffix(cfg.FrontendEndpoint, "/")
+}
@@ -0,0 +1,254 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"runtime-dynamics/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolver_BaseURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		canonical  string
+		header     string
+		allowed    []string
+		remoteAddr string
+		tls        bool
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "direct request uses host",
+			remoteAddr: "203.0.113.5:1234",
+			expected:   "http://app.example.com",
+		},
+		{
+			name:       "direct TLS request",
+			remoteAddr: "203.0.113.5:1234",
+			tls:        true,
+			expected:   "https://app.example.com",
+		},
+		{
+			name:       "untrusted forwarding headers ignored",
+			remoteAddr: "203.0.113.5:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example.com"},
+			expected:   "http://app.example.com",
+		},
+		{
+			name:       "untrusted request falls back to canonical URL",
+			canonical:  "https://www.example.com/",
+			remoteAddr: "203.0.113.5:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https"},
+			expected:   "https://www.example.com",
+		},
+		{
+			name:       "trusted X-Forwarded-Proto",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.1.2.3:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https"},
+			expected:   "https://app.example.com",
+		},
+		{
+			name:       "trusted X-Forwarded-Host",
+			trusted:    []string{"10.1.2.3"},
+			remoteAddr: "10.1.2.3:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "www.example.com"},
+			expected:   "https://www.example.com",
+		},
+		{
+			name:       "proto from outermost trusted hop",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.7, 10.0.0.1",
+				"X-Forwarded-Proto": "https, http",
+			},
+			expected: "https://app.example.com",
+		},
+		{
+			name:       "trust all hops",
+			trusted:    []string{"*"},
+			remoteAddr: "169.254.1.1:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https"},
+			expected:   "https://app.example.com",
+		},
+		{
+			name:       "Forwarded header",
+			trusted:    []string{"10.0.0.0/8"},
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"Forwarded": `for=198.51.100.7;proto=https;host="www.example.com"`},
+			expected:   "https://www.example.com",
+		},
+		{
+			name:       "Forwarded header stops at untrusted hop",
+			trusted:    []string{"10.0.0.0/8"},
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.2:1234",
+			headers: map[string]string{
+				"Forwarded": "for=198.51.100.7;proto=http;host=spoofed.example.com, for=203.0.113.9;proto=https;host=www.example.com",
+			},
+			expected: "https://www.example.com",
+		},
+		{
+			name:       "invalid forwarded values ignored",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "javascript", "X-Forwarded-Host": "evil.com/path"},
+			expected:   "http://app.example.com",
+		},
+		{
+			name:       "canonical URL keeps its host",
+			trusted:    []string{"10.0.0.0/8"},
+			canonical:  "https://www.example.com",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "http", "X-Forwarded-Host": "evil.test"},
+			expected:   "http://www.example.com",
+		},
+		{
+			name:       "allowed forwarded host",
+			trusted:    []string{"10.0.0.0/8"},
+			canonical:  "https://www.example.com",
+			allowed:    []string{"shop.example.com"},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "Shop.example.com"},
+			expected:   "https://Shop.example.com",
+		},
+		{
+			name:       "host outside the allowlist",
+			trusted:    []string{"10.0.0.0/8"},
+			allowed:    []string{"shop.example.com"},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.test"},
+			expected:   "https://app.example.com",
+		},
+		{
+			name:       "spoofed Forwarded with X-Forwarded proxy",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"Forwarded": "proto=https;host=evil.test", "X-Forwarded-Proto": "http"},
+			expected:   "http://app.example.com",
+		},
+		{
+			name:       "spoofed X-Forwarded with Forwarded proxy",
+			trusted:    []string{"10.0.0.0/8"},
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"Forwarded": "for=198.51.100.7;proto=http", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.test"},
+			expected:   "http://app.example.com",
+		},
+		{
+			name:       "spoofed X-Forwarded hops with trust all",
+			trusted:    []string{"*"},
+			remoteAddr: "169.254.1.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "6.6.6.6, 198.51.100.7",
+				"X-Forwarded-Proto": "http, https",
+				"X-Forwarded-Host":  "evil.test, www.example.com",
+			},
+			expected: "https://www.example.com",
+		},
+		{
+			name:       "client X-Forwarded-Host with trust all and canonical URL",
+			trusted:    []string{"*"},
+			canonical:  "https://www.example.com",
+			remoteAddr: "169.254.1.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "6.6.6.6, 198.51.100.7",
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "evil.test",
+			},
+			expected: "https://www.example.com",
+		},
+		{
+			name:       "spoofed Forwarded hops with trust all",
+			trusted:    []string{"*"},
+			header:     HeaderForwarded,
+			remoteAddr: "169.254.1.1:1234",
+			headers: map[string]string{
+				"Forwarded": "for=6.6.6.6;proto=http;host=evil.test, for=198.51.100.7;proto=https;host=www.example.com",
+			},
+			expected: "https://www.example.com",
+		},
+		{
+			name:       "missing value of outermost trusted hop",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.7, 10.0.0.1",
+				"X-Forwarded-Proto": "https, https",
+				"X-Forwarded-Host":  "evil.test",
+			},
+			expected: "https://app.example.com",
+		},
+		{
+			name:       "IPv6 trusted proxy",
+			trusted:    []string{"fd00::/8"},
+			remoteAddr: "[fd00::1]:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https"},
+			expected:   "https://app.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewResolver(Options{TrustedProxies: tt.trusted, CanonicalURL: tt.canonical, Header: tt.header, AllowedHosts: tt.allowed})
+			assert.NoError(t, err)
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.Host = "app.example.com"
+			req.RemoteAddr = tt.remoteAddr
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			assert.Equal(t, tt.expected, r.BaseURL(req))
+		})
+	}
+}
+
+func TestNewResolver_InvalidProxy(t *testing.T) {
+	_, err := NewResolver(Options{TrustedProxies: []string{"not-an-ip"}})
+	assert.Error(t, err)
+	_, err = NewResolver(Options{Header: "x-real-host"})
+	assert.Error(t, err)
+}
+
+func TestBaseURL_FromConfig(t *testing.T) {
+	os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	os.Setenv("CANONICAL_URL", "https://www.example.com")
+	defer func() {
+		os.Unsetenv("TRUSTED_PROXIES")
+		os.Unsetenv("CANONICAL_URL")
+		config.LoadConfig()
+	}()
+	assert.NoError(t, config.LoadConfig())
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.Host = "app.example.com"
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+	c.Request.Header.Set("X-Forwarded-Proto", "http")
+	c.Request.Header.Set("X-Forwarded-Host", "evil.test")
+
+	// the trusted proxy only picks the scheme of CANONICAL_URL
+	assert.Equal(t, "http://www.example.com", BaseURL(c))
+	assert.Equal(t, "http://www.example.com/sitemap.xml", AbsoluteURL(c, "sitemap.xml"))
+
+	c.Request.RemoteAddr = "203.0.113.5:1234"
+	assert.Equal(t, "https://www.example.com", BaseURL(c))
+	assert.Equal(t, "https://www.example.com", CanonicalURL())
+}
@@ -0,0 +1,143 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// outboxSize is the number of events queued for the broker before
+	// Publish stops forwarding them
+	outboxSize = 256
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// BrokerEvent is an event on its way between instances
+type BrokerEvent struct {
+	// Origin is the ID of the hub that published the event
+	Origin string
+	Topic  string
+	Name   string
+	Data   string
+}
+
+// Broker carries events between the hubs of all instances
+type Broker interface {
+	// Publish sends e to every listening hub, the publisher's included
+	Publish(ctx context.Context, e BrokerEvent) error
+	// Listen calls handle for every event published from now on, until ctx
+	// is done or the broker fails
+	Listen(ctx context.Context, handle func(BrokerEvent)) error
+}
+
+// Connect forwards the events published on h to broker and delivers the
+// events of other instances to the local subscribers, until ctx is done.
+// Failed listens are retried with backoff.
+func (h *Hub) Connect(ctx context.Context, broker Broker) {
+	outbox := make(chan BrokerEvent, outboxSize)
+	h.mu.Lock()
+	h.outbox = outbox
+	h.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				h.mu.Lock()
+				h.outbox = nil
+				h.mu.Unlock()
+				return
+			case e := <-outbox:
+				if err := broker.Publish(ctx, e); err != nil && ctx.Err() == nil {
+					log.Error().Err(err).Str("topic", e.Topic).Msg("failed to publish realtime event to broker")
+				}
+			}
+		}
+	}()
+
+	go func() {
+		delay := minReconnectDelay
+		for {
+			started := time.Now()
+			err := broker.Listen(ctx, h.receive)
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(started) > maxReconnectDelay {
+				delay = minReconnectDelay
+			}
+			log.Error().Err(err).Dur("retry_in", delay).Msg("realtime broker listener stopped")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxReconnectDelay)
+		}
+	}()
+}
+
+// receive delivers an event from the broker unless h published it itself,
+// in which case it was delivered locally already
+func (h *Hub) receive(e BrokerEvent) {
+	if e.Origin == h.id {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deliver(e.Topic, e.Name, e.Data)
+}
+
+// MemoryBroker connects hubs within one process. It is meant for tests and
+// single-instance deployments that want to exercise the broker path.
+type MemoryBroker struct {
+	mu        sync.Mutex
+	listeners map[chan BrokerEvent]struct{}
+}
+
+// NewMemoryBroker creates an in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{listeners: make(map[chan BrokerEvent]struct{})}
+}
+
+// Publish hands e to every listener. Listeners that are DefaultBufferSize
+// events behind miss it, like slow subscribers do.
+func (b *MemoryBroker) Publish(ctx context.Context, e BrokerEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for listener := range b.listeners {
+		select {
+		case listener <- e:
+		default:
+			log.Warn().Str("topic", e.Topic).Msg("realtime memory broker listener is behind, event dropped")
+		}
+	}
+	return nil
+}
+
+// Listen calls handle for every published event until ctx is done
+func (b *MemoryBroker) Listen(ctx context.Context, handle func(BrokerEvent)) error {
+	listener := make(chan BrokerEvent, DefaultBufferSize)
+	b.mu.Lock()
+	b.listeners[listener] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.listeners, listener)
+		b.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e := <-listener:
+			handle(e)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitForEvent returns the next event of sub or fails after a second
+func waitForEvent(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case e := <-sub.Events():
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestHub_ConnectFansOutAcrossHubs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := NewMemoryBroker()
+	first := NewHub(Options{})
+	second := NewHub(Options{})
+	first.Connect(ctx, broker)
+	second.Connect(ctx, broker)
+
+	local := first.Subscribe("orders")
+	remote := second.Subscribe("orders")
+	defer local.Close()
+	defer remote.Close()
+
+	// wait for both listeners to register with the broker
+	assert.Eventually(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		return len(broker.listeners) == 2
+	}, time.Second, time.Millisecond)
+
+	first.Publish("orders", "order-created", "<li>1</li>")
+
+	e := waitForEvent(t, remote)
+	assert.Equal(t, "order-created", e.Name)
+	assert.Equal(t, "<li>1</li>", e.Data)
+	assert.Equal(t, "<li>1</li>", waitForEvent(t, local).Data)
+
+	// the publisher does not receive its own event a second time
+	second.Publish("orders", "", "<li>2</li>")
+	assert.Equal(t, "<li>2</li>", waitForEvent(t, local).Data)
+	assert.Equal(t, "<li>2</li>", waitForEvent(t, remote).Data)
+	select {
+	case e := <-remote.Events():
+		t.Fatalf("unexpected duplicate event %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSeenEvents(t *testing.T) {
+	now := time.Now()
+	seen := newSeenEvents()
+
+	assert.True(t, seen.add(1, now.Add(-time.Minute)))
+	assert.True(t, seen.add(2, now))
+	assert.False(t, seen.add(1, now.Add(-time.Minute)))
+
+	seen.forgetBefore(now.Add(-time.Second))
+
+	assert.Len(t, seen, 1)
+	assert.False(t, seen.add(2, now))
+}
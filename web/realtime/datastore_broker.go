@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+)
+
+// realtimeEventKind is the Datastore kind of events in transit
+const realtimeEventKind = "RealtimeEvent"
+
+// storedEvent is a BrokerEvent saved for the other instances to poll
+type storedEvent struct {
+	Origin    string `datastore:",noindex"`
+	Topic     string `datastore:",noindex"`
+	Name      string `datastore:",noindex"`
+	Data      string `datastore:",noindex"`
+	CreatedAt time.Time
+	// ExpireAt lets a Datastore TTL policy delete delivered events
+	ExpireAt time.Time `datastore:",noindex"`
+}
+
+// DatastoreBroker exchanges events through Datastore: Publish saves an
+// entity and every listening instance polls for new ones. Events arrive up to
+// PollInterval late. Add a TTL policy on RealtimeEvent.ExpireAt so delivered
+// events are removed.
+type DatastoreBroker struct {
+	client *datastore.Client
+	// PollInterval is the time between polls
+	PollInterval time.Duration
+	// Window is how far back each poll looks, so events saved late or by an
+	// instance with a lagging clock are still picked up
+	Window time.Duration
+	// Retention is how long events are kept before ExpireAt
+	Retention time.Duration
+}
+
+// NewDatastoreBroker creates a broker polling every second
+func NewDatastoreBroker(client *datastore.Client) *DatastoreBroker {
+	return &DatastoreBroker{
+		client:       client,
+		PollInterval: time.Second,
+		Window:       10 * time.Second,
+		Retention:    time.Hour,
+	}
+}
+
+// Publish saves e
+func (b *DatastoreBroker) Publish(ctx context.Context, e BrokerEvent) error {
+	now := time.Now()
+	_, err := b.client.Put(ctx, datastore.IncompleteKey(realtimeEventKind, nil), &storedEvent{
+		Origin:    e.Origin,
+		Topic:     e.Topic,
+		Name:      e.Name,
+		Data:      e.Data,
+		CreatedAt: now,
+		ExpireAt:  now.Add(b.Retention),
+	})
+	return err
+}
+
+// Listen polls for events saved since it started until ctx is done or a
+// query fails
+func (b *DatastoreBroker) Listen(ctx context.Context, handle func(BrokerEvent)) error {
+	start := time.Now()
+	seen := newSeenEvents()
+	ticker := time.NewTicker(b.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		since := time.Now().Add(-b.Window)
+		if since.Before(start) {
+			since = start
+		}
+		q := datastore.NewQuery(realtimeEventKind).FilterField("CreatedAt", ">=", since).Order("CreatedAt")
+		it := b.client.Run(ctx, q)
+		for {
+			var e storedEvent
+			key, err := it.Next(&e)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if seen.add(key.ID, e.CreatedAt) {
+				handle(BrokerEvent{Origin: e.Origin, Topic: e.Topic, Name: e.Name, Data: e.Data})
+			}
+		}
+		seen.forgetBefore(since)
+	}
+}
+
+// seenEvents remembers the events already delivered, since consecutive polls
+// overlap by the window
+type seenEvents map[int64]time.Time
+
+func newSeenEvents() seenEvents {
+	return seenEvents{}
+}
+
+// add reports whether the event is new and remembers it
+func (s seenEvents) add(id int64, createdAt time.Time) bool {
+	if _, ok := s[id]; ok {
+		return false
+	}
+	s[id] = createdAt
+	return true
+}
+
+// forgetBefore drops events older than any future poll will return
+func (s seenEvents) forgetBefore(t time.Time) {
+	for id, createdAt := range s {
+		if createdAt.Before(t) {
+			delete(s, id)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Package realtime pushes server events to browsers. Services publish
+// events, often rendered templ fragments, to topics on a Hub; clients
+// subscribe to topics over Server-Sent Events, e.g. with htmx-ext-sse:
+//
+//	<div hx-ext="sse" sse-connect="/events?topic=orders" sse-swap="order-created"></div>
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultBufferSize is the number of events queued per subscriber
+	// before it is considered too slow and disconnected
+	DefaultBufferSize = 64
+	// DefaultHistorySize is the number of recent events kept for clients
+	// reconnecting with Last-Event-ID
+	DefaultHistorySize = 256
+	// DefaultHeartbeat is the interval of keep-alive comments on idle streams
+	DefaultHeartbeat = 15 * time.Second
+)
+
+// Event is a message published to a topic
+type Event struct {
+	// ID increases with every event delivered by the hub
+	ID    uint64
+	Topic string
+	// Name is the SSE event type, matched by sse-swap; empty means "message"
+	Name string
+	Data string
+}
+
+// Options configure a Hub. Zero values use the defaults.
+type Options struct {
+	BufferSize  int
+	HistorySize int
+	Heartbeat   time.Duration
+}
+
+// Hub fans published events out to the subscribers of their topic. Connect
+// it to a Broker to also reach the subscribers of other instances.
+type Hub struct {
+	// id tells this hub's event IDs apart from those of other instances
+	id          string
+	mu          sync.Mutex
+	subs        map[*Subscription]struct{}
+	history     []Event
+	historySize int
+	bufferSize  int
+	heartbeat   time.Duration
+	lastID      uint64
+	// outbox queues events for the broker; nil until Connect
+	outbox chan BrokerEvent
+}
+
+// NewHub creates a hub
+func NewHub(opts Options) *Hub {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	h := &Hub{
+		id:          hex.EncodeToString(b),
+		subs:        make(map[*Subscription]struct{}),
+		historySize: opts.HistorySize,
+		bufferSize:  opts.BufferSize,
+		heartbeat:   opts.Heartbeat,
+	}
+	if h.historySize <= 0 {
+		h.historySize = DefaultHistorySize
+	}
+	if h.bufferSize <= 0 {
+		h.bufferSize = DefaultBufferSize
+	}
+	if h.heartbeat <= 0 {
+		h.heartbeat = DefaultHeartbeat
+	}
+	return h
+}
+
+var defaultHub = NewHub(Options{})
+
+// DefaultHub returns the hub used by the package-level functions and the
+// /events endpoint
+func DefaultHub() *Hub {
+	return defaultHub
+}
+
+// Publish sends data as event name to the subscribers of topic on the default hub
+func Publish(topic string, name string, data string) Event {
+	return defaultHub.Publish(topic, name, data)
+}
+
+// PublishComponent renders component and publishes it on the default hub
+func PublishComponent(ctx context.Context, topic string, name string, component templ.Component) error {
+	return defaultHub.PublishComponent(ctx, topic, name, component)
+}
+
+// Publish sends data as event name to the subscribers of topic, and through
+// the broker to those of other instances. Subscribers whose queue is full are
+// disconnected so one slow client cannot hold up the others; browsers
+// reconnect and catch up with Last-Event-ID.
+func (h *Hub) Publish(topic string, name string, data string) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.outbox != nil {
+		select {
+		case h.outbox <- BrokerEvent{Origin: h.id, Topic: topic, Name: name, Data: data}:
+		default:
+			log.Warn().Str("topic", topic).Msg("realtime broker queue full, event not sent to other instances")
+		}
+	}
+	return h.deliver(topic, name, data)
+}
+
+// deliver sends an event to the local subscribers of topic; h.mu must be held
+func (h *Hub) deliver(topic string, name string, data string) Event {
+	h.lastID++
+	e := Event{ID: h.lastID, Topic: topic, Name: name, Data: data}
+	h.history = append(h.history, e)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for sub := range h.subs {
+		if !sub.topics[topic] {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			log.Warn().Str("topic", topic).Msg("disconnecting slow realtime subscriber")
+			h.remove(sub)
+		}
+	}
+	return e
+}
+
+// PublishComponent renders component and publishes the HTML, typically a
+// fragment swapped in by sse-swap
+func (h *Hub) PublishComponent(ctx context.Context, topic string, name string, component templ.Component) error {
+	var buf bytes.Buffer
+	if err := component.Render(ctx, &buf); err != nil {
+		return err
+	}
+	h.Publish(topic, name, buf.String())
+	return nil
+}
+
+// Subscribe receives the events of topics published from now on
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	return h.SubscribeFrom(0, topics...)
+}
+
+// SubscribeFrom is like Subscribe but first replays the retained events of
+// topics published after the event with ID lastID. Zero replays nothing.
+func (h *Hub) SubscribeFrom(lastID uint64, topics ...string) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if lastID > 0 {
+		for _, e := range h.history {
+			if e.ID > lastID && contains(topics, e.Topic) {
+				replay = append(replay, e)
+			}
+		}
+	}
+	size := h.bufferSize
+	if len(replay) > size {
+		size = len(replay)
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		topics: make(map[string]bool, len(topics)),
+		events: make(chan Event, size),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = true
+	}
+	for _, e := range replay {
+		sub.events <- e
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// ID identifies the hub among the instances sharing a broker
+func (h *Hub) ID() string {
+	return h.id
+}
+
+// Subscribers returns the number of connected subscribers
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Close disconnects every subscriber, ending their SSE streams so the HTTP
+// server can shut down; browsers reconnect to another instance
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		h.remove(sub)
+	}
+}
+
+// remove unregisters sub and closes its channel; h.mu must be held
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription receives the events of its topics
+type Subscription struct {
+	hub    *Hub
+	topics map[string]bool
+	events chan Event
+}
+
+// Events delivers the subscribed events. It is closed by Close or when the
+// subscriber was too slow to keep up.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unsubscribes
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// UserTopic is the private topic of a signed-in user. Only that user may
+// subscribe to it.
+func UserTopic(userID string) string {
+	return "user:" + userID
+}
+
+// formatID returns the SSE id of event id. It is prefixed with the hub ID
+// because every instance numbers the events it delivers on its own.
+func (h *Hub) formatID(id uint64) string {
+	return h.id + "-" + strconv.FormatUint(id, 10)
+}
+
+// parseID returns the event ID of an SSE id, or zero when it is invalid or
+// was issued by another instance, whose numbering means nothing here
+func (h *Hub) parseID(s string) uint64 {
+	hubID, seq, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok || hubID != h.id {
+		return 0
+	}
+	id, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,144 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(t *testing.T, sub *Subscription) []Event {
+	t.Helper()
+	var events []Event
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestHub_PublishToTopicSubscribers(t *testing.T) {
+	hub := NewHub(Options{})
+	orders := hub.Subscribe("orders")
+	both := hub.Subscribe("orders", "news")
+	defer orders.Close()
+	defer both.Close()
+
+	hub.Publish("orders", "order-created", "<li>1</li>")
+	hub.Publish("news", "", "hello")
+
+	got := receive(t, orders)
+	assert.Len(t, got, 1)
+	assert.Equal(t, Event{ID: 1, Topic: "orders", Name: "order-created", Data: "<li>1</li>"}, got[0])
+	assert.Len(t, receive(t, both), 2)
+}
+
+func TestHub_SubscribeFromReplaysMissedEvents(t *testing.T) {
+	hub := NewHub(Options{HistorySize: 4})
+	for _, data := range []string{"a", "b", "c", "d"} {
+		hub.Publish("orders", "", data)
+		hub.Publish("news", "", data)
+	}
+
+	tests := []struct {
+		name     string
+		lastID   uint64
+		expected []string
+	}{
+		{name: "no last event id", lastID: 0, expected: nil},
+		{name: "retained events after last id", lastID: 6, expected: []string{"d"}},
+		{name: "events dropped from history are lost", lastID: 1, expected: []string{"c", "d"}},
+		{name: "up to date", lastID: 8, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := hub.SubscribeFrom(tt.lastID, "orders")
+			defer sub.Close()
+			var data []string
+			for _, e := range receive(t, sub) {
+				data = append(data, e.Data)
+			}
+			assert.Equal(t, tt.expected, data)
+		})
+	}
+}
+
+func TestHub_DisconnectsSlowSubscribers(t *testing.T) {
+	hub := NewHub(Options{BufferSize: 2})
+	slow := hub.Subscribe("orders")
+	fast := hub.Subscribe("orders")
+
+	for i := 0; i < 3; i++ {
+		hub.Publish("orders", "", "x")
+		receive(t, fast)
+	}
+
+	assert.Len(t, receive(t, slow), 2)
+	_, open := <-slow.Events()
+	assert.False(t, open)
+	assert.Equal(t, 1, hub.Subscribers())
+
+	// closing an already dropped subscription is harmless
+	slow.Close()
+	fast.Close()
+	assert.Equal(t, 0, hub.Subscribers())
+}
+
+func TestHub_PublishComponent(t *testing.T) {
+	hub := NewHub(Options{})
+	sub := hub.Subscribe("orders")
+	defer sub.Close()
+
+	component := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "<li>order</li>")
+		return err
+	})
+	err := hub.PublishComponent(context.Background(), "orders", "order-created", component)
+
+	assert.NoError(t, err)
+	got := receive(t, sub)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "<li>order</li>", got[0].Data)
+}
+
+func TestHub_ParseID(t *testing.T) {
+	hub := NewHub(Options{})
+
+	tests := []struct {
+		name     string
+		id       string
+		expected uint64
+	}{
+		{name: "own event", id: hub.formatID(42), expected: 42},
+		{name: "other instance", id: "0badc0de-42", expected: 0},
+		{name: "without hub", id: "42", expected: 0},
+		{name: "invalid sequence", id: hub.ID() + "-x", expected: 0},
+		{name: "empty", id: "", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hub.parseID(tt.id))
+		})
+	}
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(Options{})
+	sub := hub.Subscribe("orders")
+
+	hub.Close()
+
+	_, open := <-sub.Events()
+	assert.False(t, open)
+	assert.Equal(t, 0, hub.Subscribers())
+}
@@ -0,0 +1,122 @@
+package realtime
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"runtime-dynamics/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// retryMillis tells browsers how long to wait before reconnecting
+const retryMillis = 3000
+
+// Authorize reports whether the request may subscribe to topic. The default
+// allows every topic except the user topics of other users.
+var Authorize = func(c *gin.Context, topic string) bool {
+	userID, private := strings.CutPrefix(topic, "user:")
+	if !private {
+		return true
+	}
+	user := middleware.GetUser(c)
+	return user != nil && user.ID == userID
+}
+
+// SSEHandler streams the events of the topics named by the topic query
+// parameters (?topic=a&topic=b or ?topic=a,b) as Server-Sent Events.
+// Reconnecting browsers send Last-Event-ID and receive the events they missed
+// while the hub still retains them, if they reconnect to the same instance.
+func SSEHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		topics := requestedTopics(c)
+		if len(topics) == 0 {
+			c.String(http.StatusBadRequest, "topic is required")
+			return
+		}
+		for _, topic := range topics {
+			if !Authorize(c, topic) {
+				c.String(http.StatusForbidden, "not allowed to subscribe to "+topic)
+				return
+			}
+		}
+
+		lastID := hub.parseID(c.GetHeader("Last-Event-ID"))
+		if lastID == 0 {
+			lastID = hub.parseID(c.Query("last_event_id"))
+		}
+		sub := hub.SubscribeFrom(lastID, topics...)
+		defer sub.Close()
+
+		header := c.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		// stop nginx and similar proxies from buffering the stream
+		header.Set("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", retryMillis)
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(hub.heartbeat)
+		defer heartbeat.Stop()
+		ctx := c.Request.Context()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-sub.Events():
+				if !ok {
+					// too slow: end the stream so the browser reconnects and catches up
+					return
+				}
+				if err := writeEvent(c.Writer, hub.formatID(e.ID), e); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			case <-heartbeat.C:
+				if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func requestedTopics(c *gin.Context) []string {
+	var topics []string
+	for _, value := range c.QueryArray("topic") {
+		for _, topic := range strings.Split(value, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" && !contains(topics, topic) {
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics
+}
+
+// lineBreaks removes CR and LF, which would end an SSE field early
+var lineBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// writeEvent writes e with the SSE id in the text/event-stream format.
+// Line breaks are stripped from the name so it cannot inject fields.
+// Multi-line data, with CRLF, LF or CR line ends, is split over several data
+// fields, which the browser joins with newlines.
+func writeEvent(w io.Writer, id string, e Event) error {
+	var b strings.Builder
+	b.WriteString("id: " + id + "\n")
+	if name := lineBreaks.Replace(e.Name); name != "" {
+		b.WriteString("event: " + name + "\n")
+	}
+	data := strings.ReplaceAll(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
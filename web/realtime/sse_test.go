@@ -0,0 +1,160 @@
+package realtime
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"runtime-dynamics/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newSSERouter(hub *Hub, user *auth.User) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+		}
+		c.Next()
+	})
+	router.GET("/events", SSEHandler(hub))
+	return router
+}
+
+// readEvent reads the next blank-line terminated block from the stream
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return strings.Join(lines, "\n")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestSSEHandler_RejectsRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		user           *auth.User
+		expectedStatus int
+	}{
+		{name: "missing topic", url: "/events", expectedStatus: http.StatusBadRequest},
+		{name: "anonymous user topic", url: "/events?topic=user:u1", expectedStatus: http.StatusForbidden},
+		{name: "other user's topic", url: "/events?topic=news,user:u1", user: &auth.User{ID: "u2"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			newSSERouter(NewHub(Options{}), tt.user).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestSSEHandler_StreamsEvents(t *testing.T) {
+	hub := NewHub(Options{Heartbeat: 20 * time.Millisecond})
+	server := httptest.NewServer(newSSERouter(hub, &auth.User{ID: "u1"}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?topic=orders&topic=" + UserTopic("u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	stream := bufio.NewReader(resp.Body)
+	assert.Equal(t, "retry: 3000", readEvent(t, stream))
+
+	hub.Publish("news", "", "not subscribed")
+	hub.Publish("orders", "order-created", "<li>\n1</li>")
+	assert.Equal(t, "id: "+hub.ID()+"-2\nevent: order-created\ndata: <li>\ndata: 1</li>", readEvent(t, stream))
+
+	hub.Publish(UserTopic("u1"), "", "private")
+	assert.Equal(t, "id: "+hub.ID()+"-3\ndata: private", readEvent(t, stream))
+
+	assert.Equal(t, ": heartbeat", readEvent(t, stream))
+}
+
+func TestSSEHandler_ReplaysFromLastEventID(t *testing.T) {
+	hub := NewHub(Options{})
+	hub.Publish("orders", "", "one")
+	hub.Publish("orders", "", "two")
+	hub.Publish("orders", "", "three")
+
+	server := httptest.NewServer(newSSERouter(hub, nil))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/events?topic=orders", nil)
+	req.Header.Set("Last-Event-ID", hub.ID()+"-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	stream := bufio.NewReader(resp.Body)
+	readEvent(t, stream)
+	assert.Equal(t, "id: "+hub.ID()+"-2\ndata: two", readEvent(t, stream))
+	assert.Equal(t, "id: "+hub.ID()+"-3\ndata: three", readEvent(t, stream))
+}
+
+func TestWriteEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    Event
+		expected string
+	}{
+		{
+			name:     "crlf data",
+			event:    Event{ID: 7, Name: "update", Data: "a\r\nb"},
+			expected: "id: h-7\nevent: update\ndata: a\ndata: b\n\n",
+		},
+		{
+			name:     "bare cr in data",
+			event:    Event{ID: 7, Data: "a\rdata: forged\rb\n"},
+			expected: "id: h-7\ndata: a\ndata: data: forged\ndata: b\ndata: \n\n",
+		},
+		{
+			name:     "line breaks in name",
+			event:    Event{ID: 7, Name: "update\r\ndata: forged\n\nevent: x\r", Data: "a"},
+			expected: "id: h-7\nevent: updatedata: forgedevent: x\ndata: a\n\n",
+		},
+		{
+			name:     "name of only line breaks",
+			event:    Event{ID: 7, Name: "\r\n", Data: "a"},
+			expected: "id: h-7\ndata: a\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeEvent(&buf, "h-7", tt.event)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
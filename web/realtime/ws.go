@@ -0,0 +1,396 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"runtime-dynamics/auth"
+	"runtime-dynamics/web/middleware"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultMaxMessageSize is the largest incoming message in bytes
+	DefaultMaxMessageSize = 32 << 10
+	// DefaultSendBuffer is the number of outgoing messages queued per connection
+	DefaultSendBuffer = 32
+	// DefaultMessageRate is the sustained number of incoming messages per second
+	DefaultMessageRate = 10
+	// DefaultMessageBurst is the number of incoming messages allowed at once
+	DefaultMessageBurst = 20
+
+	writeTimeout = 10 * time.Second
+	pongTimeout  = 60 * time.Second
+	pingInterval = pongTimeout * 9 / 10
+)
+
+// ErrConnClosed is returned when sending on a closed connection
+var ErrConnClosed = errors.New("websocket connection closed")
+
+// MessageHeaders are the request headers the htmx ws extension sends in
+// the HEADERS field of every message
+type MessageHeaders struct {
+	Request     string `json:"HX-Request"`
+	Trigger     string `json:"HX-Trigger"`
+	TriggerName string `json:"HX-Trigger-Name"`
+	Target      string `json:"HX-Target"`
+	CurrentURL  string `json:"HX-Current-URL"`
+}
+
+// Message is a message sent by an element with ws-send
+type Message struct {
+	Headers MessageHeaders
+	// Values are the form values of the sending element
+	Values url.Values
+}
+
+// Name is the route of the message: the name of the sending element, or its
+// id when it has no name
+func (m Message) Name() string {
+	if m.Headers.TriggerName != "" {
+		return m.Headers.TriggerName
+	}
+	return m.Headers.Trigger
+}
+
+// parseMessage decodes an htmx ws message: a JSON object of form values
+// plus HEADERS
+func parseMessage(data []byte) (Message, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Message{}, err
+	}
+	msg := Message{Values: url.Values{}}
+	if headers, ok := raw["HEADERS"]; ok {
+		if err := json.Unmarshal(headers, &msg.Headers); err != nil {
+			return Message{}, fmt.Errorf("invalid HEADERS: %w", err)
+		}
+		delete(raw, "HEADERS")
+	}
+	for key, value := range raw {
+		var values []any
+		if err := json.Unmarshal(value, &values); err != nil {
+			var single any
+			if err := json.Unmarshal(value, &single); err != nil {
+				return Message{}, err
+			}
+			values = []any{single}
+		}
+		for _, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				msg.Values.Add(key, v)
+			default:
+				msg.Values.Add(key, fmt.Sprint(v))
+			}
+		}
+	}
+	return msg, nil
+}
+
+// WSHandlerFunc handles a message. Handlers of one connection run one at a
+// time in the order the messages arrived.
+type WSHandlerFunc func(ctx context.Context, conn *Conn, msg Message) error
+
+// WSOptions configure a WSRouter. Zero values use the defaults.
+type WSOptions struct {
+	MaxMessageSize int64
+	SendBuffer     int
+	MessageRate    float64
+	MessageBurst   int
+	// RequireUser rejects anonymous connections with 401
+	RequireUser bool
+}
+
+// WSRouter accepts WebSocket connections from the htmx ws extension and
+// routes their messages to handlers by Message.Name
+type WSRouter struct {
+	mu       sync.RWMutex
+	handlers map[string]WSHandlerFunc
+	conns    map[*Conn]struct{}
+	opts     WSOptions
+	upgrader websocket.Upgrader
+}
+
+// NewWSRouter creates a router without handlers
+func NewWSRouter(opts WSOptions) *WSRouter {
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if opts.SendBuffer <= 0 {
+		opts.SendBuffer = DefaultSendBuffer
+	}
+	if opts.MessageRate <= 0 {
+		opts.MessageRate = DefaultMessageRate
+	}
+	if opts.MessageBurst <= 0 {
+		opts.MessageBurst = DefaultMessageBurst
+	}
+	return &WSRouter{
+		handlers: make(map[string]WSHandlerFunc),
+		conns:    make(map[*Conn]struct{}),
+		opts:     opts,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin:     sameOrigin,
+		},
+	}
+}
+
+var defaultWSRouter = NewWSRouter(WSOptions{})
+
+// DefaultWSRouter returns the router served at /ws
+func DefaultWSRouter() *WSRouter {
+	return defaultWSRouter
+}
+
+// HandleWS registers handler for messages named name on the default router
+func HandleWS(name string, handler WSHandlerFunc) {
+	defaultWSRouter.Handle(name, handler)
+}
+
+// Handle registers handler for messages named name, replacing any earlier one
+func (r *WSRouter) Handle(name string, handler WSHandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[name] = handler
+}
+
+func (r *WSRouter) handler(name string) (WSHandlerFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.handlers[name]
+	return h, ok
+}
+
+// Close closes every open connection so the HTTP server can shut down; the
+// htmx ws extension reconnects, typically to another instance
+func (r *WSRouter) Close() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for conn := range r.conns {
+		conn.closeWith(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// sameOrigin rejects cross-site connections, which would otherwise carry the
+// user's cookies. Clients that send no Origin header are not browsers.
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	return middleware.AllowedOrigin(req, origin)
+}
+
+// Handler upgrades the request to a WebSocket connection and serves it until
+// either side closes it
+func (r *WSRouter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := middleware.GetUser(c)
+		if r.opts.RequireUser && user == nil {
+			c.String(http.StatusUnauthorized, "Sign in to continue")
+			return
+		}
+
+		ws, err := r.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// the upgrader has already written the error response
+			log.Debug().Err(err).Str("request_id", middleware.GetRequestID(c)).Msg("websocket upgrade failed")
+			return
+		}
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		conn := &Conn{
+			ws:     ws,
+			user:   user,
+			send:   make(chan []byte, r.opts.SendBuffer),
+			ctx:    ctx,
+			cancel: cancel,
+		}
+		r.mu.Lock()
+		r.conns[conn] = struct{}{}
+		r.mu.Unlock()
+		defer func() {
+			r.mu.Lock()
+			delete(r.conns, conn)
+			r.mu.Unlock()
+		}()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			conn.writeLoop()
+		}()
+		r.readLoop(conn, middleware.GetRequestID(c))
+		<-done
+	}
+}
+
+// readLoop dispatches incoming messages until the connection fails or breaks
+// the size or rate limits
+func (r *WSRouter) readLoop(conn *Conn, requestID string) {
+	defer conn.Close()
+
+	limiter := rate.NewLimiter(rate.Limit(r.opts.MessageRate), r.opts.MessageBurst)
+	conn.ws.SetReadLimit(r.opts.MaxMessageSize)
+	conn.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.ws.SetPongHandler(func(string) error {
+		return conn.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		_, data, err := conn.ws.ReadMessage()
+		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				log.Warn().Str("request_id", requestID).Msg("websocket message too large")
+				conn.closeWith(websocket.CloseMessageTooBig, "message too large")
+			}
+			return
+		}
+		conn.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+		if !limiter.Allow() {
+			log.Warn().Str("request_id", requestID).Msg("websocket message rate exceeded")
+			conn.closeWith(websocket.ClosePolicyViolation, "too many messages")
+			return
+		}
+
+		msg, err := parseMessage(data)
+		if err != nil {
+			log.Debug().Err(err).Str("request_id", requestID).Msg("invalid websocket message")
+			continue
+		}
+		handler, ok := r.handler(msg.Name())
+		if !ok {
+			log.Debug().Str("request_id", requestID).Str("message", msg.Name()).Msg("no websocket handler")
+			continue
+		}
+		if err := handler(conn.ctx, conn, msg); err != nil {
+			log.Error().Err(err).Str("request_id", requestID).Str("message", msg.Name()).Msg("websocket handler failed")
+		}
+	}
+}
+
+// Conn is a connected client. Send is safe for concurrent use, so handlers
+// may keep the connection and push to it later.
+type Conn struct {
+	ws     *websocket.Conn
+	user   *auth.User
+	send   chan []byte
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// User returns the user who opened the connection, or nil
+func (c *Conn) User() *auth.User {
+	return c.user
+}
+
+// Context is canceled when the connection closes
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Send renders components into one message. The htmx ws extension swaps
+// every top-level element into the element with the same id, so components
+// should render elements with an id, optionally with hx-swap-oob to choose
+// the swap style.
+func (c *Conn) Send(components ...templ.Component) error {
+	var buf bytes.Buffer
+	for _, component := range components {
+		if err := component.Render(c.ctx, &buf); err != nil {
+			return err
+		}
+	}
+	return c.SendHTML(buf.String())
+}
+
+// SendHTML queues html for the client. A client that does not keep up with
+// its queue is disconnected rather than buffered without bound.
+func (c *Conn) SendHTML(html string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrConnClosed
+	}
+	select {
+	case c.send <- []byte(html):
+		return nil
+	default:
+		log.Warn().Msg("disconnecting slow websocket client")
+		c.closeLocked()
+		return ErrConnClosed
+	}
+}
+
+// Close closes the connection
+func (c *Conn) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *Conn) closeLocked() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.send)
+}
+
+// closeWith tells the client why the connection is closed before closing it
+func (c *Conn) closeWith(code int, reason string) {
+	c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeTimeout))
+	c.Close()
+}
+
+// writeLoop writes queued messages and keep-alive pings, and tears the
+// connection down once the queue is closed or a write fails
+func (c *Conn) writeLoop() {
+	ping := time.NewTicker(pingInterval)
+	defer func() {
+		ping.Stop()
+		c.cancel()
+		c.ws.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			if !ok {
+				c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+				return
+			}
+			c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.Close()
+				return
+			}
+		case <-ping.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				c.Close()
+				return
+			}
+		case <-c.ctx.Done():
+			c.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"runtime-dynamics/auth"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWSServer(router *WSRouter, user *auth.User) *httptest.Server {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+		}
+		c.Next()
+	})
+	r.GET("/ws", router.Handler())
+	return httptest.NewServer(r)
+}
+
+func dial(t *testing.T, server *httptest.Server, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		expectedName   string
+		expectedValues url.Values
+		expectErr      bool
+	}{
+		{
+			name:           "form values and headers",
+			data:           `{"message":"hi","count":2,"tags":["a","b"],"HEADERS":{"HX-Request":"true","HX-Trigger":"chat-form","HX-Trigger-Name":"chat"}}`,
+			expectedName:   "chat",
+			expectedValues: url.Values{"message": {"hi"}, "count": {"2"}, "tags": {"a", "b"}},
+		},
+		{
+			name:           "trigger id without name",
+			data:           `{"HEADERS":{"HX-Trigger":"refresh"}}`,
+			expectedName:   "refresh",
+			expectedValues: url.Values{},
+		},
+		{name: "not an object", data: `"hello"`, expectErr: true},
+		{name: "invalid headers", data: `{"HEADERS":"x"}`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := parseMessage([]byte(tt.data))
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedName, msg.Name())
+			assert.Equal(t, tt.expectedValues, msg.Values)
+		})
+	}
+}
+
+func TestWSRouter_RoutesMessagesAndSendsSwaps(t *testing.T) {
+	router := NewWSRouter(WSOptions{})
+	router.Handle("chat", func(ctx context.Context, conn *Conn, msg Message) error {
+		reply := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			_, err := io.WriteString(w, `<div id="chat-log" hx-swap-oob="beforeend">`+conn.User().ID+": "+msg.Values.Get("message")+`</div>`)
+			return err
+		})
+		return conn.Send(reply)
+	})
+	server := newWSServer(router, &auth.User{ID: "u1"})
+	defer server.Close()
+
+	ws, _, err := dial(t, server, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	// unrouted messages are ignored
+	assert.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{"HEADERS":{"HX-Trigger":"unknown"}}`)))
+	assert.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{"message":"hello","HEADERS":{"HX-Trigger-Name":"chat"}}`)))
+
+	_, data, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, `<div id="chat-log" hx-swap-oob="beforeend">u1: hello</div>`, string(data))
+}
+
+func TestWSRouter_RejectsConnections(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           WSOptions
+		user           *auth.User
+		origin         string
+		expectedStatus int
+	}{
+		{name: "anonymous when a user is required", opts: WSOptions{RequireUser: true}, expectedStatus: http.StatusUnauthorized},
+		{name: "cross-site origin", user: &auth.User{ID: "u1"}, origin: "https://evil.example", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWSServer(NewWSRouter(tt.opts), tt.user)
+			defer server.Close()
+
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			_, resp, err := dial(t, server, header)
+
+			assert.Error(t, err)
+			if assert.NotNil(t, resp) {
+				assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestWSRouter_EnforcesLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         WSOptions
+		messages     []string
+		expectedCode int
+	}{
+		{
+			name:         "message too large",
+			opts:         WSOptions{MaxMessageSize: 16},
+			messages:     []string{`{"message":"far too long for the limit"}`},
+			expectedCode: websocket.CloseMessageTooBig,
+		},
+		{
+			name:         "message rate exceeded",
+			opts:         WSOptions{MessageRate: 0.001, MessageBurst: 1},
+			messages:     []string{`{}`, `{}`},
+			expectedCode: websocket.ClosePolicyViolation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWSServer(NewWSRouter(tt.opts), nil)
+			defer server.Close()
+
+			ws, _, err := dial(t, server, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ws.Close()
+			for _, m := range tt.messages {
+				ws.WriteMessage(websocket.TextMessage, []byte(m))
+			}
+
+			_, _, err = ws.ReadMessage()
+			assert.True(t, websocket.IsCloseError(err, tt.expectedCode), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestConn_SendAfterClose(t *testing.T) {
+	conn := &Conn{send: make(chan []byte, 1), ctx: context.Background()}
+	conn.Close()
+
+	assert.ErrorIs(t, conn.SendHTML("<p>late</p>"), ErrConnClosed)
+}
+
+func TestConn_DisconnectsSlowClient(t *testing.T) {
+	conn := &Conn{send: make(chan []byte, 1), ctx: context.Background()}
+
+	assert.NoError(t, conn.SendHTML("<p>1</p>"))
+	assert.ErrorIs(t, conn.SendHTML("<p>2</p>"), ErrConnClosed)
+	assert.ErrorIs(t, conn.SendHTML("<p>3</p>"), ErrConnClosed)
+}
+
+func TestWSRouter_Close(t *testing.T) {
+	router := NewWSRouter(WSOptions{})
+	server := newWSServer(router, nil)
+	defer server.Close()
+
+	ws, _, err := dial(t, server, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	assert.Eventually(t, func() bool {
+		router.mu.RLock()
+		defer router.mu.RUnlock()
+		return len(router.conns) == 1
+	}, time.Second, time.Millisecond)
+
+	router.Close()
+
+	_, _, err = ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error: %v", err)
+}
@@ -0,0 +1,297 @@
+// Package validate binds request input into structs and turns validation
+// failures into per-field errors.
+//
+// Validation uses gin's go-playground validator, so structs declare their
+// rules with `binding` tags. Field names in errors come from the json tag,
+// falling back to the form and uri tags, so they match what clients send.
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"runtime-dynamics/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxBodyBytes caps the size of JSON and form bodies read by Request and Form
+const maxBodyBytes = 1 << 20
+
+var (
+	setupOnce sync.Once
+	setupErr  error
+
+	messagesLock sync.RWMutex
+	messages     = map[string]string{}
+)
+
+// engine returns gin's validator engine, configured to report field names
+// from struct tags
+func engine() (*validator.Validate, error) {
+	setupOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			setupErr = errors.New("validate: gin validator is not go-playground/validator")
+			return
+		}
+		v.RegisterTagNameFunc(fieldName)
+	})
+	if setupErr != nil {
+		return nil, setupErr
+	}
+	return binding.Validator.Engine().(*validator.Validate), nil
+}
+
+// fieldName returns the name clients use for a struct field
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// Register adds a custom validation tag. message is shown to users when the
+// rule fails, e.g. Register("slug", isSlug, "must contain only lowercase letters, digits and dashes").
+func Register(tag string, fn validator.Func, message string) error {
+	v, err := engine()
+	if err != nil {
+		return err
+	}
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+	messagesLock.Lock()
+	messages[tag] = message
+	messagesLock.Unlock()
+	return nil
+}
+
+// Struct validates obj's binding tags and returns an errs validation error
+// listing every invalid field
+func Struct(obj any) error {
+	if _, err := engine(); err != nil {
+		return err
+	}
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return toError(err)
+	}
+	return nil
+}
+
+// Request decodes the query string, the body and path params into obj, then
+// validates it. JSON bodies are decoded by content type, other bodies are read
+// as forms. Path params are bound last, so a body or query value never
+// replaces the ID named in the URL. Decoding and validation failures are errs
+// validation errors.
+func Request(c *gin.Context, obj any) error {
+	if err := binding.MapFormWithTag(obj, c.Request.URL.Query(), "form"); err != nil {
+		return errs.Validation("invalid query parameter").WithCause(err)
+	}
+	if hasBody(c.Request) {
+		if c.ContentType() == binding.MIMEJSON {
+			if err := decodeJSON(c, obj); err != nil {
+				return err
+			}
+		} else if err := mapForm(c, obj); err != nil {
+			return err
+		}
+	}
+	if len(c.Params) > 0 {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(obj, params, "uri"); err != nil {
+			return errs.Validation("invalid path parameter").WithCause(err)
+		}
+	}
+	return Struct(obj)
+}
+
+// Form decodes a submitted HTML form (urlencoded or multipart) into obj and
+// validates it
+func Form(c *gin.Context, obj any) error {
+	if err := mapForm(c, obj); err != nil {
+		return err
+	}
+	return Struct(obj)
+}
+
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+func decodeJSON(c *gin.Context, obj any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+	if err := decoder.Decode(obj); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return errs.Validation("invalid request body", errs.FieldError{
+				Field:   typeErr.Field,
+				Message: fmt.Sprintf("must be a %s", jsonType(typeErr.Type)),
+			}).WithCause(err)
+		}
+		return errs.Validation("invalid request body").WithCause(err)
+	}
+	return nil
+}
+
+func mapForm(c *gin.Context, obj any) error {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+	if err := c.Request.ParseMultipartForm(maxBodyBytes); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return errs.Validation("invalid form data").WithCause(err)
+	}
+	if err := binding.MapFormWithTag(obj, c.Request.PostForm, "form"); err != nil {
+		return errs.Validation("invalid form data").WithCause(err)
+	}
+	return nil
+}
+
+// jsonType names a Go type the way a JSON client thinks of it
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
+
+// toError converts validator errors into an errs validation error
+func toError(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return errs.Validation("invalid input").WithCause(err)
+	}
+	fields := make([]errs.FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		fields = append(fields, errs.FieldError{Field: path(fe), Message: message(fe)})
+	}
+	return errs.Validation("invalid input", fields...).WithCause(err)
+}
+
+// path returns the dotted field path without the top-level struct name,
+// e.g. "address.city" or "tags[0]"
+func path(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+// message returns a user-facing sentence for a failed rule
+func message(fe validator.FieldError) string {
+	messagesLock.RLock()
+	custom, ok := messages[fe.Tag()]
+	messagesLock.RUnlock()
+	if ok {
+		return custom
+	}
+
+	isString := fe.Kind() == reflect.String
+	isList := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map || fe.Kind() == reflect.Array
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min", "gte":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		if isList {
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		if isList {
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "len":
+		if isString {
+			return fmt.Sprintf("must be exactly %s characters", fe.Param())
+		}
+		return "must have length " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "eqfield":
+		return "must match " + fe.Param()
+	default:
+		return "is invalid"
+	}
+}
+
+// Errors maps field names to their first error message. The empty key holds
+// an error that applies to the whole form.
+type Errors map[string]string
+
+// ErrorsOf converts a validation error into Errors. Other errors, and
+// validation errors without fields, are reported under the empty key.
+func ErrorsOf(err error) Errors {
+	if err == nil {
+		return nil
+	}
+	e, ok := errs.As(err)
+	if !ok || e.Kind != errs.KindValidation {
+		return Errors{"": "Something went wrong, please try again"}
+	}
+	result := Errors{}
+	for _, f := range e.Fields {
+		if _, exists := result[f.Field]; !exists {
+			result[f.Field] = f.Message
+		}
+	}
+	if len(result) == 0 {
+		result[""] = e.Message
+	}
+	return result
+}
+
+// Get returns the error message for field, or "" when it is valid
+func (e Errors) Get(field string) string {
+	return e[field]
+}
+
+// Has reports whether field has an error
+func (e Errors) Has(field string) bool {
+	_, ok := e[field]
+	return ok
+}
@@ -0,0 +1,197 @@
+package validate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"runtime-dynamics/errs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type address struct {
+	City string `json:"city" binding:"required"`
+}
+
+type itemInput struct {
+	ID      string   `uri:"id" binding:"required"`
+	Dry     bool     `form:"dry"`
+	Name    string   `json:"name" form:"name" binding:"required,min=3"`
+	Email   string   `json:"email" form:"email" binding:"omitempty,email"`
+	Status  string   `json:"status" form:"status" binding:"omitempty,oneof=draft published"`
+	Tags    []string `json:"tags" binding:"max=2"`
+	Address *address `json:"address" binding:"omitempty"`
+}
+
+func bind(method, target, contentType, body string, obj any) error {
+	var err error
+	router := gin.New()
+	router.Handle(method, "/items/:id", func(c *gin.Context) {
+		err = Request(c, obj)
+	})
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	return err
+}
+
+func fieldsOf(err error) []errs.FieldError {
+	e, ok := errs.As(err)
+	if !ok {
+		return nil
+	}
+	return e.Fields
+}
+
+func TestRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		contentType    string
+		body           string
+		expectedErr    bool
+		expectedFields []errs.FieldError
+	}{
+		{
+			name:        "valid json with path and query",
+			method:      http.MethodPost,
+			target:      "/items/42?dry=true",
+			contentType: "application/json",
+			body:        `{"name":"Widget","email":"a@example.com","tags":["a"]}`,
+		},
+		{
+			name:        "missing and invalid fields",
+			method:      http.MethodPost,
+			target:      "/items/42",
+			contentType: "application/json",
+			body:        `{"name":"ab","email":"nope","status":"gone","tags":["a","b","c"],"address":{}}`,
+			expectedErr: true,
+			expectedFields: []errs.FieldError{
+				{Field: "name", Message: "must be at least 3 characters"},
+				{Field: "email", Message: "must be a valid email address"},
+				{Field: "status", Message: "must be one of: draft, published"},
+				{Field: "tags", Message: "must contain at most 2 items"},
+				{Field: "address.city", Message: "is required"},
+			},
+		},
+		{
+			name:           "wrong json type",
+			method:         http.MethodPost,
+			target:         "/items/42",
+			contentType:    "application/json",
+			body:           `{"name":7}`,
+			expectedErr:    true,
+			expectedFields: []errs.FieldError{{Field: "name", Message: "must be a string"}},
+		},
+		{
+			name:        "malformed json",
+			method:      http.MethodPost,
+			target:      "/items/42",
+			contentType: "application/json",
+			body:        `{"name":`,
+			expectedErr: true,
+		},
+		{
+			name:        "invalid query value",
+			method:      http.MethodGet,
+			target:      "/items/42?dry=maybe&name=Widget",
+			expectedErr: true,
+		},
+		{
+			name:        "form body",
+			method:      http.MethodPost,
+			target:      "/items/42",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=Widget&status=draft",
+		},
+		{
+			name:        "body id conflicting with path",
+			method:      http.MethodPut,
+			target:      "/items/42",
+			contentType: "application/json",
+			body:        `{"id":"someone-elses","name":"Widget"}`,
+		},
+		{
+			name:        "query and form id conflicting with path",
+			method:      http.MethodPut,
+			target:      "/items/42?ID=someone-elses",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "ID=someone-elses&name=Widget",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input itemInput
+			err := bind(tt.method, tt.target, tt.contentType, tt.body, &input)
+			if !tt.expectedErr {
+				assert.NoError(t, err)
+				assert.Equal(t, "42", input.ID)
+				return
+			}
+			assert.True(t, errs.Is(err, errs.KindValidation))
+			assert.ElementsMatch(t, tt.expectedFields, fieldsOf(err))
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	err := Register("slug", func(fl validator.FieldLevel) bool {
+		return !strings.ContainsAny(fl.Field().String(), " _")
+	}, "must contain only lowercase letters, digits and dashes")
+	assert.NoError(t, err)
+
+	type input struct {
+		Slug string `json:"slug" binding:"slug"`
+	}
+	assert.NoError(t, Struct(&input{Slug: "hello-world"}))
+
+	err = Struct(&input{Slug: "hello world"})
+	assert.Equal(t, []errs.FieldError{{Field: "slug", Message: "must contain only lowercase letters, digits and dashes"}}, fieldsOf(err))
+}
+
+func TestForm(t *testing.T) {
+	type contact struct {
+		Name  string `form:"name" binding:"required"`
+		Email string `form:"email" binding:"required,email"`
+	}
+
+	var input contact
+	var err error
+	router := gin.New()
+	router.POST("/contact", func(c *gin.Context) {
+		err = Form(c, &input)
+	})
+	form := url.Values{"name": {"Ada"}, "email": {"not-an-email"}}
+	req := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "Ada", input.Name)
+	assert.Equal(t, Errors{"email": "must be a valid email address"}, ErrorsOf(err))
+}
+
+func TestErrorsOf(t *testing.T) {
+	assert.Nil(t, ErrorsOf(nil))
+	assert.Equal(t, Errors{"": "invalid form data"}, ErrorsOf(errs.Validation("invalid form data")))
+	assert.Equal(t, Errors{"": "Something went wrong, please try again"}, ErrorsOf(errs.Conflict("taken")))
+
+	e := ErrorsOf(errs.Validation("invalid input",
+		errs.FieldError{Field: "name", Message: "is required"},
+		errs.FieldError{Field: "name", Message: "must be at least 3 characters"}))
+	assert.True(t, e.Has("name"))
+	assert.Equal(t, "is required", e.Get("name"))
+	assert.False(t, e.Has("email"))
+}
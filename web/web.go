@@ -8,8 +8,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
+	"runtime-dynamics/config"
+	"runtime-dynamics/metrics"
 	"runtime-dynamics/web/api"
 	"runtime-dynamics/web/app"
+	"runtime-dynamics/web/app/seo"
+	"runtime-dynamics/web/proxy"
+	"runtime-dynamics/web/realtime"
 )
 
 func GetStaticFiles(staticDir string) (map[string]string, error) {
@@ -40,11 +45,26 @@ func GetStaticFiles(staticDir string) (map[string]string, error) {
 func HandleRoutes(r *gin.Engine, staticDir string) *gin.Engine {
 	// Register API routes (JSON endpoints under /api/*)
 	api.RegisterRoutes(r)
+	seo.Disallow("/api/")
 
 	// Register web routes (HTML endpoints under /app/* and /)
 	// Note: This includes the homepage at /
 	app.RegisterWebRoutes(r)
 
+	// Server-Sent Events for live updates published through web/realtime
+	r.GET("/events", realtime.SSEHandler(realtime.DefaultHub()))
+	seo.Disallow("/events")
+
+	// WebSocket messages from the htmx ws extension (see web/realtime)
+	r.GET("/ws", realtime.DefaultWSRouter().Handler())
+	seo.Disallow("/ws")
+
+	// Prometheus metrics, unless METRICS_PORT serves them on an internal port
+	if cfg := config.Get(); cfg == nil || cfg.MetricsPort == 0 {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+		seo.Disallow("/metrics")
+	}
+
 	// In development mode, serve static directories
 	// In production, individual files are registered below
 	if os.Getenv("IS_DEV") == "true" {
@@ -76,7 +96,20 @@ func HandleRoutes(r *gin.Engine, staticDir string) *gin.Engine {
 	return r
 }
 
+// BaseURL returns the public scheme://host of the request. Forwarding headers
+// are only honored from TRUSTED_PROXIES, otherwise CANONICAL_URL is used when
+// configured. Packages below web/ call proxy.BaseURL directly.
+func BaseURL(c *gin.Context) string {
+	return proxy.BaseURL(c)
+}
+
 func Start(r *gin.Engine) *gin.Engine {
+	// Use the same proxy trust list for gin's ClientIP as for BaseURL
+	if cfg := config.Get(); cfg != nil {
+		if err := r.SetTrustedProxies(proxy.GinTrustedProxies(cfg.TrustedProxies)); err != nil {
+			log.Fatal().Err(err).Msg("invalid TRUSTED_PROXIES")
+		}
+	}
 	HandleRoutes(r, "static")
 	return r
 }